@@ -6,44 +6,71 @@ import (
 	"slices"
 	"strings"
 
-	"github.com/csnewman/team-cli/internal/team"
+	"github.com/csnewman/team-cli/team"
 	"github.com/spf13/cobra"
 )
 
 func listAccountsCmdRun(cmd *cobra.Command, args []string) error {
-	cfg, err := readConfigReAuth(cmd.Context())
+	offline, err := cmd.Flags().GetBool("offline")
 	if err != nil {
-		return fmt.Errorf("could not read config and authenticate: %w", err)
+		return fmt.Errorf("offline flag: %w", err)
 	}
 
-	fmt.Println()
-	fmt.Println("Fetching AWS accounts")
+	out := cmd.OutOrStdout()
 
-	accounts, err := team.FetchAccounts(cmd.Context(), cfg.ServerConfig, cfg.AuthToken)
-	if err != nil {
-		return fmt.Errorf("could not fetch accounts: %w", err)
-	}
+	var accounts map[string]*team.Account
+
+	if offline {
+		cache, ok, err := getAccountsCache()
+		if err != nil {
+			return fmt.Errorf("could not get accounts cache: %w", err)
+		}
+
+		if !ok {
+			return fmt.Errorf("%w: no cached accounts, run team-cli cache refresh", ErrInvalid)
+		}
+
+		fmt.Fprintln(out)
+		fmt.Fprintf(out, "Using cached accounts from %s\n", fmtDate(cache.FetchedAt))
 
-	if err := cacheAccounts(accounts); err != nil {
-		return fmt.Errorf("could not cache accounts: %w", err)
+		accounts = cache.Accounts
+	} else {
+		p := newPrompter(cmd)
+
+		cfg, err := readConfigReAuth(cmd.Context(), p)
+		if err != nil {
+			return fmt.Errorf("could not read config and authenticate: %w", err)
+		}
+
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "Fetching AWS accounts")
+
+		accounts, err = cfg.client().FetchAccounts(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("could not fetch accounts: %w", err)
+		}
+
+		if err := cacheAccounts(cfg, accounts); err != nil {
+			return fmt.Errorf("could not cache accounts: %w", err)
+		}
 	}
 
 	sortedAccs := slices.SortedFunc(maps.Values(accounts), func(a *team.Account, b *team.Account) int {
 		return strings.Compare(a.Name, b.Name)
 	})
 
-	fmt.Println()
-	fmt.Println("Accounts:")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Accounts:")
 
 	for i, account := range sortedAccs {
-		fmt.Printf("  [%d] id=%q name=%q\n", i+1, account.ID, account.Name)
+		fmt.Fprintf(out, "  [%d] id=%q name=%q\n", i+1, account.ID, account.Name)
 
 		roles := slices.SortedFunc(maps.Values(account.Roles), func(a *team.Role, b *team.Role) int {
 			return strings.Compare(a.Name, b.Name)
 		})
 
 		for _, role := range roles {
-			fmt.Printf(
+			fmt.Fprintf(out,
 				"    - role=%q max_duration_with_approval=%d max_duration_without_approval=%d\n",
 				role.Name,
 				role.MaxDurApproval,
@@ -2,130 +2,192 @@ package main
 
 import (
 	"fmt"
+	"slices"
 	"time"
 
-	"github.com/csnewman/team-cli/internal/team"
+	"github.com/csnewman/team-cli/team"
 	"github.com/spf13/cobra"
 )
 
 func approveCmdRun(cmd *cobra.Command, args []string) error {
-	cfg, err := readConfigReAuth(cmd.Context())
+	approveFlag, err := cmd.Flags().GetBool("approve")
 	if err != nil {
-		return fmt.Errorf("could not read config and authenticate: %w", err)
+		return fmt.Errorf("approve flag: %w", err)
 	}
 
-	requests, err := team.ListRequests(cmd.Context(), cfg.ServerConfig, cfg.AuthToken, team.ListRequestsFilterRequiresMyApproval)
+	rejectFlag, err := cmd.Flags().GetBool("reject")
 	if err != nil {
-		return fmt.Errorf("could not fetch requests: %w", err)
+		return fmt.Errorf("reject flag: %w", err)
 	}
 
-	fmt.Println()
+	comment, err := cmd.Flags().GetString("comment")
+	if err != nil {
+		return fmt.Errorf("comment flag: %w", err)
+	}
 
-	if len(requests) == 0 {
-		fmt.Println("There are no requests to approve")
+	autoConfirm, err := cmd.Flags().GetBool("confirm")
+	if err != nil {
+		return fmt.Errorf("confirm flag: %w", err)
+	}
 
-		return nil
+	watch, err := cmd.Flags().GetBool("watch")
+	if err != nil {
+		return fmt.Errorf("watch flag: %w", err)
 	}
 
-	fmt.Println("Please select the request:")
-	for i, req := range requests {
-		fmt.Printf(
-			"  [%d] requester=%q account=%q role=%q\n",
-			i+1,
-			req.Email,
-			req.AccountName,
-			req.Role,
-		)
-		fmt.Printf(
-			"\taccount_id=%q requested=%q start_time=%q duration=%q \n",
-			req.AccountID, fmtDate(req.CreatedAt), fmtDate(req.StartTime), req.Duration+" hours",
-		)
-		fmt.Printf(
-			"\tticket=%q justification=%q\n",
-			req.TicketNo,
-			req.Justification,
-		)
+	if watch && len(args) > 0 {
+		return fmt.Errorf("%w: the request ID cannot be used with --watch", ErrInvalid)
 	}
 
-	fmt.Println()
+	out := cmd.OutOrStdout()
+	p := newPrompter(cmd)
+
+	cfg, err := readConfigReAuth(cmd.Context(), p)
+	if err != nil {
+		return fmt.Errorf("could not read config and authenticate: %w", err)
+	}
+
+	if watch {
+		return approveWatchRun(cmd, p, cfg)
+	}
 
-	idx, err := promptSelection("Request option? ", 1, len(requests))
+	requests, err := cfg.client().ListRequests(cmd.Context(), team.ListRequestsFilterRequiresMyApproval)
 	if err != nil {
-		return fmt.Errorf("could not select request: %w", err)
+		return fmt.Errorf("could not fetch requests: %w", err)
 	}
 
-	selectedRequest := requests[idx-1]
+	fmt.Fprintln(out)
 
-	fmt.Println()
-	fmt.Println("Please select the response:")
-	fmt.Println("  [1] Approve")
-	fmt.Println("  [2] Approve without comment")
-	fmt.Println("  [3] Reject")
-	fmt.Println("  [4] Reject without comment")
-	fmt.Println()
+	if len(requests) == 0 {
+		fmt.Fprintln(out, "There are no requests to approve")
 
-	idx, err = promptSelection("Response option? ", 1, 4)
+		return nil
+	}
+
+	selectedRequest, err := selectRequest(p, requests, args)
 	if err != nil {
-		return fmt.Errorf("could not select request: %w", err)
+		return err
 	}
 
-	comment := "No comment."
-	approve := idx < 3
+	approve := approveFlag
+
+	if !approveFlag && !rejectFlag {
+		fmt.Fprintln(out)
 
-	if idx == 1 || idx == 3 {
-		comment, err = promptString("Comment? ")
+		idx, err := p.pick("Please select the response", "Response option? ", "--approve or --reject", []string{
+			"Approve",
+			"Approve without comment",
+			"Reject",
+			"Reject without comment",
+		})
 		if err != nil {
-			return fmt.Errorf("could not read comment: %w", err)
+			return fmt.Errorf("could not select request: %w", err)
+		}
+
+		approve = idx < 2
+
+		if comment == "" && (idx == 0 || idx == 2) {
+			comment, err = p.promptString("Comment? ", "--comment")
+			if err != nil {
+				return fmt.Errorf("could not read comment: %w", err)
+			}
 		}
 	}
 
+	if comment == "" {
+		comment = "No comment."
+	}
+
 	accResp := &team.AccessResponse{
 		ID:      selectedRequest.ID,
 		Comment: comment,
 	}
 
-	fmt.Println("")
-	fmt.Println("Details:")
-	fmt.Printf("  ID: %q\n", selectedRequest.ID)
-	fmt.Printf("  Requester: email=%q\n", selectedRequest.Email)
-	fmt.Printf("  Account: id=%q name=%q\n", selectedRequest.AccountID, selectedRequest.AccountName)
-	fmt.Printf("  Role: name=%q\n", selectedRequest.Role)
-	fmt.Printf("  Created: %q\n", fmtDate(selectedRequest.CreatedAt))
-	fmt.Printf("  Start: %q\n", fmtDate(selectedRequest.StartTime))
-	fmt.Printf("  Duration: %q\n", selectedRequest.Duration+" Hours")
-	fmt.Printf("  Ticket: %q\n", selectedRequest.TicketNo)
-	fmt.Printf("  Justification: %q\n", selectedRequest.Justification)
+	fmt.Fprintln(out, "")
+	fmt.Fprintln(out, "Details:")
+	fmt.Fprintf(out, "  ID: %q\n", selectedRequest.ID)
+	fmt.Fprintf(out, "  Requester: email=%q\n", selectedRequest.Email)
+	fmt.Fprintf(out, "  Account: id=%q name=%q\n", selectedRequest.AccountID, selectedRequest.AccountName)
+	fmt.Fprintf(out, "  Role: name=%q\n", selectedRequest.Role)
+	fmt.Fprintf(out, "  Created: %q\n", fmtDate(selectedRequest.CreatedAt))
+	fmt.Fprintf(out, "  Start: %q\n", fmtDate(selectedRequest.StartTime))
+	fmt.Fprintf(out, "  Duration: %q\n", selectedRequest.Duration+" Hours")
+	fmt.Fprintf(out, "  Ticket: %q\n", selectedRequest.TicketNo)
+	fmt.Fprintf(out, "  Justification: %q\n", selectedRequest.Justification)
 
 	if approve {
-		fmt.Print("  Response Action: Approve\n")
+		fmt.Fprint(out, "  Response Action: Approve\n")
 		accResp.Status = "approved"
 	} else {
-		fmt.Print("  Response Action: Reject\n")
+		fmt.Fprint(out, "  Response Action: Reject\n")
 		accResp.Status = "rejected"
 	}
 
-	fmt.Printf("  Response Comment: %q\n", comment)
+	fmt.Fprintf(out, "  Response Comment: %q\n", comment)
 
-	fmt.Println()
+	fmt.Fprintln(out)
 
-	cont, err := promptBool("Confirm (y/n)? ")
-	if err != nil {
-		return fmt.Errorf("could not select confirmation: %w", err)
-	}
+	if !autoConfirm {
+		cont, err := p.promptBool("Confirm (y/n)? ", "--confirm")
+		if err != nil {
+			return fmt.Errorf("could not select confirmation: %w", err)
+		}
 
-	if !cont {
-		return fmt.Errorf("%w: confirmation rejected", ErrInvalid)
+		if !cont {
+			return fmt.Errorf("%w: confirmation rejected", ErrInvalid)
+		}
 	}
 
-	if err := team.Respond(cmd.Context(), cfg.ServerConfig, cfg.AuthToken, accResp); err != nil {
+	if err := cfg.client().Respond(cmd.Context(), accResp); err != nil {
 		return fmt.Errorf("could not respond to request: %w", err)
 	}
 
-	fmt.Println("Responded")
+	fmt.Fprintln(out, "Responded")
 
 	return nil
 }
 
+// selectRequest returns the request with the ID given as the first argument, or prompts for one if absent.
+func selectRequest(p *prompter, requests []*team.PermissionRequest, args []string) (*team.PermissionRequest, error) {
+	if len(args) > 0 {
+		idx := slices.IndexFunc(requests, func(req *team.PermissionRequest) bool {
+			return req.ID == args[0]
+		})
+		if idx < 0 {
+			return nil, fmt.Errorf("%w: request %q not found", ErrInvalid, args[0])
+		}
+
+		return requests[idx], nil
+	}
+
+	items := make([]string, 0, len(requests))
+	for _, req := range requests {
+		items = append(items, fmt.Sprintf(
+			"requester=%q account=%q role=%q\n"+
+				"\taccount_id=%q requested=%q start_time=%q duration=%q \n"+
+				"\tticket=%q justification=%q",
+			req.Email,
+			req.AccountName,
+			req.Role,
+			req.AccountID, fmtDate(req.CreatedAt), fmtDate(req.StartTime), req.Duration+" hours",
+			req.TicketNo,
+			req.Justification,
+		))
+	}
+
+	idx, err := p.pick("Please select the request", "Request option? ", "the request ID", items)
+	if err != nil {
+		return nil, fmt.Errorf("could not select request: %w", err)
+	}
+
+	return requests[idx], nil
+}
+
 func fmtDate(t time.Time) string {
-	return t.Local().Format(time.UnixDate)
+	return fmtDateIn(t, time.Local)
+}
+
+// fmtDateIn formats a date like fmtDate, in the given time zone, such as the one requested times were given in.
+func fmtDateIn(t time.Time, loc *time.Location) string {
+	return t.In(loc).Format(time.UnixDate)
 }
@@ -0,0 +1,288 @@
+package main
+
+import (
+	"cmp"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/csnewman/team-cli/internal/gql"
+	"github.com/spf13/cobra"
+)
+
+var ErrNoSSOToken = errors.New("no valid AWS SSO token")
+
+// awsConfigPath returns the path of the AWS CLI config file, honouring AWS_CONFIG_FILE.
+func awsConfigPath() (string, error) {
+	if path := os.Getenv("AWS_CONFIG_FILE"); path != "" {
+		return path, nil
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user dir: %w", err)
+	}
+
+	return filepath.Join(homeDir, ".aws", "config"), nil
+}
+
+// awsProfileName returns a profile name for a session, usable without quoting in a shell.
+func awsProfileName(accountName string, role string) string {
+	return "team-" + awsConfigName(accountName+"-"+role)
+}
+
+// awsSSOSessionName returns the name of the sso-session used to sign in to the access portal.
+func awsSSOSessionName(startURL string) string {
+	name := startURL
+
+	if u, err := url.Parse(startURL); err == nil && u.Host != "" {
+		name = u.Host
+	}
+
+	return "team-" + awsConfigName(name)
+}
+
+// awsConfigName replaces characters which would require quoting in a shell.
+func awsConfigName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		default:
+			return '-'
+		}
+	}, name)
+}
+
+// ssoLoginCommand returns the command which signs in to the access portal via the sso-session.
+func ssoLoginCommand(session string) string {
+	return "aws sso login --sso-session " + session
+}
+
+// setINISection replaces the keys of a section within an INI file, appending the section if it does not exist.
+// Other sections, including their comments, are preserved.
+func setINISection(content string, section string, keys [][2]string) string {
+	var body strings.Builder
+
+	for _, kv := range keys {
+		body.WriteString(kv[0] + " = " + kv[1] + "\n")
+	}
+
+	lines := strings.SplitAfter(content, "\n")
+	header := "[" + section + "]"
+
+	var out strings.Builder
+
+	found := false
+	skipping := false
+
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, "[") {
+			skipping = false
+
+			if trimmed == header && !found {
+				found = true
+				skipping = true
+
+				out.WriteString(header + "\n")
+				out.WriteString(body.String())
+
+				continue
+			}
+		}
+
+		if skipping {
+			// Keep blank lines separating the section from the next one
+			if trimmed == "" {
+				out.WriteString(line)
+			}
+
+			continue
+		}
+
+		out.WriteString(line)
+	}
+
+	if found {
+		return out.String()
+	}
+
+	result := out.String()
+
+	if result != "" && !strings.HasSuffix(result, "\n") {
+		result += "\n"
+	}
+
+	if result != "" {
+		result += "\n"
+	}
+
+	return result + header + "\n" + body.String()
+}
+
+// awsConfigSection is a section of the AWS CLI config file, such as "profile name" or "sso-session name".
+type awsConfigSection struct {
+	name string
+	keys [][2]string
+}
+
+// writeAWSConfig creates or replaces sections within the AWS CLI config file.
+func writeAWSConfig(sections ...awsConfigSection) (string, error) {
+	path, err := awsConfigPath()
+	if err != nil {
+		return "", err
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("could not read AWS config: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return "", fmt.Errorf("could not create AWS config dir: %w", err)
+	}
+
+	updated := string(raw)
+
+	for _, section := range sections {
+		updated = setINISection(updated, section.name, section.keys)
+	}
+
+	if err := writePrivateFile(path, []byte(updated)); err != nil {
+		return "", fmt.Errorf("could not write AWS config: %w", err)
+	}
+
+	return path, nil
+}
+
+type ssoToken struct {
+	AccessToken string    `json:"accessToken"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+}
+
+// readSSOToken returns the access portal token cached by `aws sso login`, whether signed in via the sso-session or a
+// profile with the start URL.
+func readSSOToken(session string, startURL string) (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user dir: %w", err)
+	}
+
+	expired := false
+
+	// The AWS CLI names cache files after the SHA-1 of the session name, or of the start URL for legacy profiles
+	for _, key := range []string{session, startURL} {
+		hash := sha1.Sum([]byte(key))
+		path := filepath.Join(homeDir, ".aws", "sso", "cache", hex.EncodeToString(hash[:])+".json")
+
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+
+			return "", fmt.Errorf("could not read SSO cache: %w", err)
+		}
+
+		var token ssoToken
+
+		if err := json.Unmarshal(raw, &token); err != nil {
+			return "", fmt.Errorf("could not parse SSO cache: %w", err)
+		}
+
+		if token.AccessToken == "" || !timeNow().Before(token.ExpiresAt) {
+			expired = true
+
+			continue
+		}
+
+		return token.AccessToken, nil
+	}
+
+	if expired {
+		return "", fmt.Errorf("%w: token has expired", ErrNoSSOToken)
+	}
+
+	return "", fmt.Errorf("%w: not signed in to %s", ErrNoSSOToken, startURL)
+}
+
+// processCredentials is the output format of a credential_process.
+type processCredentials struct {
+	Version         int       `json:"Version"`
+	AccessKeyID     string    `json:"AccessKeyId"`
+	SecretAccessKey string    `json:"SecretAccessKey"`
+	SessionToken    string    `json:"SessionToken"`
+	Expiration      time.Time `json:"Expiration"`
+}
+
+// fetchRoleCredentials exchanges an access portal token for credentials of a role via the IAM Identity Center
+// GetRoleCredentials API.
+func fetchRoleCredentials(
+	cmd *cobra.Command,
+	region string,
+	token string,
+	accountID string,
+	role string,
+) (*processCredentials, error) {
+	endpoint := cmp.Or(os.Getenv("AWS_ENDPOINT_URL_SSO"), "https://portal.sso."+region+".amazonaws.com")
+
+	u, err := url.JoinPath(endpoint, "federation", "credentials")
+	if err != nil {
+		return nil, fmt.Errorf("could not build URL: %w", err)
+	}
+
+	u += "?" + url.Values{"account_id": {accountID}, "role_name": {role}}.Encode()
+
+	req, err := http.NewRequestWithContext(cmd.Context(), http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not create request: %w", err)
+	}
+
+	req.Header.Set("x-amz-sso_bearer_token", token)
+
+	resp, err := gql.HTTPClient(cmd.Context()).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("could not send request: %w", err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, fmt.Errorf("%w: token was rejected", ErrNoSSOToken)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: could not get role credentials: %v", ErrUnexpected, resp.Status)
+	}
+
+	var result struct {
+		RoleCredentials struct {
+			AccessKeyID     string `json:"accessKeyId"`
+			SecretAccessKey string `json:"secretAccessKey"`
+			SessionToken    string `json:"sessionToken"`
+			Expiration      int64  `json:"expiration"`
+		} `json:"roleCredentials"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("could not decode role credentials: %w", err)
+	}
+
+	return &processCredentials{
+		Version:         1,
+		AccessKeyID:     result.RoleCredentials.AccessKeyID,
+		SecretAccessKey: result.RoleCredentials.SecretAccessKey,
+		SessionToken:    result.RoleCredentials.SessionToken,
+		Expiration:      time.UnixMilli(result.RoleCredentials.Expiration).UTC(),
+	}, nil
+}
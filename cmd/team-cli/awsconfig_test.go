@@ -0,0 +1,57 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetINISection(t *testing.T) {
+	t.Parallel()
+
+	keys := [][2]string{{"sso_account_id", "111111111111"}, {"sso_role_name", "Admin"}}
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "empty",
+			input:    "",
+			expected: "[profile p]\nsso_account_id = 111111111111\nsso_role_name = Admin\n",
+		},
+		{
+			name:     "append",
+			input:    "[default]\nregion = eu-west-2",
+			expected: "[default]\nregion = eu-west-2\n\n[profile p]\nsso_account_id = 111111111111\nsso_role_name = Admin\n",
+		},
+		{
+			name: "replace",
+			input: "# comment\n[default]\nregion = eu-west-2\n\n[profile p]\nsso_account_id = 222222222222\n" +
+				"output = json\n\n[profile other]\nregion = us-east-1\n",
+			expected: "# comment\n[default]\nregion = eu-west-2\n\n[profile p]\nsso_account_id = 111111111111\n" +
+				"sso_role_name = Admin\n\n[profile other]\nregion = us-east-1\n",
+		},
+		{
+			name:     "replace last",
+			input:    "[default]\n\n  [profile p]  \nsso_role_name = Old\n",
+			expected: "[default]\n\n[profile p]\nsso_account_id = 111111111111\nsso_role_name = Admin\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tt.expected, setINISection(tt.input, "profile p", keys))
+		})
+	}
+}
+
+func TestAWSProfileName(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "team-prod-AdministratorAccess", awsProfileName("prod", "AdministratorAccess"))
+	require.Equal(t, "team-My-Account--1-ReadOnly", awsProfileName("My Account (1", "ReadOnly"))
+}
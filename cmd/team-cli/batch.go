@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/csnewman/team-cli/team"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// planEntry is a single request within a batch file. Accounts and roles may be given by ID or name.
+type planEntry struct {
+	Account       string `yaml:"account"`
+	Role          string `yaml:"role"`
+	StartTime     string `yaml:"start_time"`
+	Duration      int    `yaml:"duration"`
+	Ticket        string `yaml:"ticket"`
+	Justification string `yaml:"justification"`
+}
+
+// readPlan reads a list of entries from a YAML or JSON file.
+func readPlan(path string) ([]*planEntry, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read file: %w", err)
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(raw))
+	dec.KnownFields(true)
+
+	var entries []*planEntry
+
+	if err := dec.Decode(&entries); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%w: file is empty", ErrInvalid)
+		}
+
+		return nil, fmt.Errorf("%w: could not parse file: %w", ErrInvalid, err)
+	}
+
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("%w: file contains no requests", ErrInvalid)
+	}
+
+	return entries, nil
+}
+
+// resolve validates the entry against the accounts the user is entitled to, producing the request to submit.
+func (e *planEntry) resolve(accounts map[string]*team.Account, now time.Time, loc *time.Location) (*team.AccessRequest, error) {
+	if e == nil {
+		return nil, fmt.Errorf("%w: empty entry", ErrInvalid)
+	}
+
+	acc := findAccount(accounts, e.Account)
+	if acc == nil {
+		return nil, fmt.Errorf("%w: account %q not found", ErrInvalid, e.Account)
+	}
+
+	role := findRole(acc, e.Role)
+	if role == nil {
+		return nil, fmt.Errorf("%w: role %q not found in account %q", ErrInvalid, e.Role, acc.Name)
+	}
+
+	if e.Duration < 1 || e.Duration > role.MaxDurApproval {
+		return nil, fmt.Errorf("%w: duration must be between 1 and %d", ErrInvalid, role.MaxDurApproval)
+	}
+
+	if !team.TicketRegex.MatchString(e.Ticket) {
+		return nil, fmt.Errorf("%w: ticket format is not valid", ErrInvalid)
+	}
+
+	if strings.TrimSpace(e.Justification) == "" {
+		return nil, fmt.Errorf("%w: justification is required", ErrInvalid)
+	}
+
+	startTime, err := parseStartTime(e.StartTime, now, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	return &team.AccessRequest{
+		AccountID:     acc.ID,
+		AccountName:   acc.Name,
+		Role:          role.Name,
+		RoleID:        role.ID,
+		Duration:      e.Duration,
+		StartTime:     startTime,
+		Justification: e.Justification,
+		Ticket:        e.Ticket,
+	}, nil
+}
+
+func resolvePlan(
+	entries []*planEntry,
+	accounts map[string]*team.Account,
+	loc *time.Location,
+) ([]*team.AccessRequest, []error) {
+	now := timeNow()
+	requests := make([]*team.AccessRequest, len(entries))
+	errs := make([]error, 0)
+
+	for i, entry := range entries {
+		req, err := entry.resolve(accounts, now, loc)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("entry %d: %w", i+1, err))
+
+			continue
+		}
+
+		requests[i] = req
+	}
+
+	return requests, errs
+}
+
+func requestFromFileRun(cmd *cobra.Command, cfg *Config, path string, loc *time.Location, autoConfirm bool) error {
+	out := cmd.OutOrStdout()
+	p := newPrompter(cmd)
+
+	entries, err := readPlan(path)
+	if err != nil {
+		return err
+	}
+
+	cache, ok, err := getFreshAccountsCache(cfg)
+	if err != nil {
+		return fmt.Errorf("could not get accounts cache: %w", err)
+	}
+
+	var (
+		requests []*team.AccessRequest
+		errs     []error
+	)
+
+	if ok {
+		requests, errs = resolvePlan(entries, cache.Accounts, loc)
+	}
+
+	// The cache may be stale, so refresh it before reporting any failures
+	if !ok || len(errs) > 0 {
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "Fetching AWS accounts")
+
+		accounts, err := cfg.client().FetchAccounts(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("could not fetch accounts: %w", err)
+		}
+
+		if err := cacheAccounts(cfg, accounts); err != nil {
+			return fmt.Errorf("could not cache accounts: %w", err)
+		}
+
+		requests, errs = resolvePlan(entries, accounts, loc)
+	}
+
+	if len(errs) > 0 {
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "Invalid requests:")
+
+		for _, err := range errs {
+			fmt.Fprintf(out, "  %v\n", err)
+		}
+
+		return fmt.Errorf("%w: %d of %d requests are invalid", ErrInvalid, len(errs), len(entries))
+	}
+
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Details:")
+
+	for i, req := range requests {
+		start := "now"
+
+		if !req.StartTime.IsZero() {
+			start = fmtDateIn(req.StartTime, loc)
+		}
+
+		fmt.Fprintf(out, "  [%d] account=%q id=%q role=%q\n", i+1, req.AccountName, req.AccountID, req.Role)
+		fmt.Fprintf(out, "\tstart=%q duration=%d ticket=%q justification=%q\n", start, req.Duration, req.Ticket, req.Justification)
+	}
+
+	fmt.Fprintln(out)
+
+	if !autoConfirm {
+		cont, err := p.promptBool(fmt.Sprintf("Submit %d requests (y/n)? ", len(requests)), "--confirm")
+		if err != nil {
+			return fmt.Errorf("could not select confirmation: %w", err)
+		}
+
+		if !cont {
+			return fmt.Errorf("%w: confirmation rejected", ErrInvalid)
+		}
+	}
+
+	client := cfg.client()
+	tw := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	failed := 0
+
+	fmt.Fprintln(out)
+	fmt.Fprintln(tw, "#\tACCOUNT\tROLE\tREQUEST ID\tERROR")
+
+	for i, req := range requests {
+		id, err := client.Request(cmd.Context(), req)
+
+		errMsg := ""
+
+		if err != nil {
+			failed++
+			errMsg = err.Error()
+			id = "-"
+		}
+
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\n", i+1, req.AccountName, req.Role, id, errMsg)
+	}
+
+	if err := tw.Flush(); err != nil {
+		return fmt.Errorf("could not write table: %w", err)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%w: %d of %d requests failed", ErrUnexpected, failed, len(requests))
+	}
+
+	return nil
+}
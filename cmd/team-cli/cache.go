@@ -2,41 +2,91 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
+	"time"
 
-	"github.com/csnewman/team-cli/internal/team"
+	"github.com/csnewman/team-cli/team"
+	"github.com/spf13/cobra"
+)
+
+const (
+	accountCacheVersion    = 2
+	defaultAccountCacheTTL = time.Hour
 )
 
 type AccountCache struct {
-	Version  int
-	Accounts map[string]*team.Account
+	Version   int
+	FetchedAt time.Time
+	Server    string
+	UserID    string
+	Accounts  map[string]*team.Account
+}
+
+// accountCacheTTL returns how long cached accounts are trusted for. A TTL of zero disables the cache.
+func (c *Config) accountCacheTTL() time.Duration {
+	if c.AccountCacheTTL == "" {
+		return defaultAccountCacheTTL
+	}
+
+	ttl, err := time.ParseDuration(c.AccountCacheTTL)
+	if err != nil {
+		slog.Warn("Invalid account cache TTL, using default", "ttl", c.AccountCacheTTL, "err", err)
+
+		return defaultAccountCacheTTL
+	}
+
+	return ttl
 }
 
-func cacheAccounts(acc map[string]*team.Account) error {
+// identity returns the server and user the config is authenticated against.
+func (c *Config) identity() (string, string) {
+	var server, userID string
+
+	if c.ServerConfig != nil {
+		server = c.ServerConfig.Server
+	}
+
+	if c.AuthToken != nil {
+		if idTok, err := c.AuthToken.ParseIDToken(); err == nil {
+			userID = idTok.UserID
+		}
+	}
+
+	return server, userID
+}
+
+func cacheAccounts(cfg *Config, acc map[string]*team.Account) error {
+	server, userID := cfg.identity()
+
 	enc, err := json.MarshalIndent(&AccountCache{
-		Version:  1,
-		Accounts: acc,
+		Version:   accountCacheVersion,
+		FetchedAt: timeNow(),
+		Server:    server,
+		UserID:    userID,
+		Accounts:  acc,
 	}, "", "    ")
 	if err != nil {
 		return fmt.Errorf("could not marshal: %w", err)
 	}
 
-	path, err := configPath("accounts.json")
+	path, err := cachePath("accounts.json")
 	if err != nil {
 		return fmt.Errorf("could not determine path: %w", err)
 	}
 
-	if err := os.WriteFile(path, enc, 0644); err != nil {
+	if err := writePrivateFile(path, enc); err != nil {
 		return fmt.Errorf("could not write: %w", err)
 	}
 
 	return nil
 }
 
+// getAccountsCache returns the cached accounts regardless of their age or owner.
 func getAccountsCache() (*AccountCache, bool, error) {
-	path, err := configPath("accounts.json")
+	path, err := cachePath("accounts.json")
 	if err != nil {
 		return nil, false, fmt.Errorf("could not determine path: %w", err)
 	}
@@ -58,3 +108,77 @@ func getAccountsCache() (*AccountCache, bool, error) {
 
 	return cache, true, nil
 }
+
+// getFreshAccountsCache returns the cached accounts only if they were fetched for the configured server and user
+// within the TTL.
+func getFreshAccountsCache(cfg *Config) (*AccountCache, bool, error) {
+	cache, ok, err := getAccountsCache()
+	if err != nil || !ok {
+		return nil, false, err
+	}
+
+	server, userID := cfg.identity()
+
+	switch {
+	case cache.Version != accountCacheVersion:
+		slog.Debug("Ignoring account cache from older version", "version", cache.Version)
+	case cache.Server != server || cache.UserID != userID:
+		slog.Debug("Ignoring account cache for another server or user", "server", cache.Server, "user", cache.UserID)
+	case timeNow().Sub(cache.FetchedAt) >= cfg.accountCacheTTL():
+		slog.Debug("Ignoring expired account cache", "fetched_at", cache.FetchedAt)
+	default:
+		return cache, true, nil
+	}
+
+	return nil, false, nil
+}
+
+func clearAccountsCache() error {
+	path, err := cachePath("accounts.json")
+	if err != nil {
+		return fmt.Errorf("could not determine path: %w", err)
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("could not remove: %w", err)
+	}
+
+	return nil
+}
+
+func cacheClearCmdRun(cmd *cobra.Command, _ []string) error {
+	if err := clearAccountsCache(); err != nil {
+		return fmt.Errorf("could not clear accounts cache: %w", err)
+	}
+
+	fmt.Fprintln(cmd.OutOrStdout())
+	fmt.Fprintln(cmd.OutOrStdout(), "Account cache cleared")
+
+	return nil
+}
+
+func cacheRefreshCmdRun(cmd *cobra.Command, _ []string) error {
+	out := cmd.OutOrStdout()
+	p := newPrompter(cmd)
+
+	cfg, err := readConfigReAuth(cmd.Context(), p)
+	if err != nil {
+		return fmt.Errorf("could not read config and authenticate: %w", err)
+	}
+
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Fetching AWS accounts")
+
+	accounts, err := cfg.client().FetchAccounts(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("could not fetch accounts: %w", err)
+	}
+
+	if err := cacheAccounts(cfg, accounts); err != nil {
+		return fmt.Errorf("could not cache accounts: %w", err)
+	}
+
+	fmt.Fprintf(out, "Cached %d accounts\n", len(accounts))
+
+	return nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/csnewman/team-cli/team"
+	"github.com/spf13/cobra"
+)
+
+func cancelCmdRun(cmd *cobra.Command, args []string) error {
+	autoConfirm, err := cmd.Flags().GetBool("confirm")
+	if err != nil {
+		return fmt.Errorf("confirm flag: %w", err)
+	}
+
+	out := cmd.OutOrStdout()
+	p := newPrompter(cmd)
+
+	cfg, err := readConfigReAuth(cmd.Context(), p)
+	if err != nil {
+		return fmt.Errorf("could not read config and authenticate: %w", err)
+	}
+
+	requests, err := cfg.client().ListRequests(cmd.Context(), team.ListRequestsFilterMyPending)
+	if err != nil {
+		return fmt.Errorf("could not fetch requests: %w", err)
+	}
+
+	fmt.Fprintln(out)
+
+	if len(requests) == 0 {
+		fmt.Fprintln(out, "There are no pending requests to cancel")
+
+		return nil
+	}
+
+	selectedRequest, err := selectRequest(p, requests, args)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(out, "")
+	fmt.Fprintln(out, "Details:")
+	fmt.Fprintf(out, "  ID: %q\n", selectedRequest.ID)
+	fmt.Fprintf(out, "  Account: id=%q name=%q\n", selectedRequest.AccountID, selectedRequest.AccountName)
+	fmt.Fprintf(out, "  Role: name=%q\n", selectedRequest.Role)
+	fmt.Fprintf(out, "  Start: %q\n", fmtDate(selectedRequest.StartTime))
+	fmt.Fprintf(out, "  Duration: %q\n", selectedRequest.Duration+" Hours")
+	fmt.Fprintf(out, "  Ticket: %q\n", selectedRequest.TicketNo)
+
+	fmt.Fprintln(out)
+
+	if !autoConfirm {
+		cont, err := p.promptBool("Cancel request (y/n)? ", "--confirm")
+		if err != nil {
+			return fmt.Errorf("could not select confirmation: %w", err)
+		}
+
+		if !cont {
+			return fmt.Errorf("%w: confirmation rejected", ErrInvalid)
+		}
+	}
+
+	if err := cfg.client().Respond(cmd.Context(), &team.AccessResponse{
+		ID:     selectedRequest.ID,
+		Status: "cancelled",
+	}); err != nil {
+		return fmt.Errorf("could not cancel request: %w", err)
+	}
+
+	fmt.Fprintln(out, "Cancelled")
+
+	return nil
+}
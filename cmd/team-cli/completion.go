@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/csnewman/team-cli/team"
+	"github.com/spf13/cobra"
+)
+
+// Completions must never prompt, so they only use the account cache and existing tokens.
+
+func cachedAccounts() []*team.Account {
+	cache, ok, err := getAccountsCache()
+	if err != nil || !ok {
+		return nil
+	}
+
+	return slices.SortedFunc(maps.Values(cache.Accounts), func(a *team.Account, b *team.Account) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+}
+
+func completeAccounts(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+	var out []string
+
+	for _, acc := range cachedAccounts() {
+		out = append(out, acc.Name+"\t"+acc.ID, acc.ID+"\t"+acc.Name)
+	}
+
+	return out, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeRoles completes the roles of the account given by --account, or of every account if unset.
+func completeRoles(cmd *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+	account, _ := cmd.Flags().GetString("account")
+
+	accounts := cachedAccounts()
+
+	if account != "" {
+		accounts = slices.DeleteFunc(accounts, func(acc *team.Account) bool {
+			return !strings.EqualFold(acc.ID, account) && !strings.EqualFold(acc.Name, account)
+		})
+	}
+
+	// Roles are shared between accounts, so report the longest durations of any account
+	roles := make(map[string]*team.Role)
+
+	for _, acc := range accounts {
+		for _, role := range acc.Roles {
+			merged, ok := roles[role.Name]
+			if !ok {
+				merged = &team.Role{Name: role.Name}
+				roles[role.Name] = merged
+			}
+
+			merged.MaxDurApproval = max(merged.MaxDurApproval, role.MaxDurApproval)
+			merged.MaxDurNoApproval = max(merged.MaxDurNoApproval, role.MaxDurNoApproval)
+		}
+	}
+
+	var out []string
+
+	for _, name := range slices.Sorted(maps.Keys(roles)) {
+		role := roles[name]
+
+		out = append(out, fmt.Sprintf("%s\tmax %dh, %dh without approval", name, role.MaxDurApproval, role.MaxDurNoApproval))
+	}
+
+	return out, cobra.ShellCompDirectiveNoFileComp
+}
+
+func completePresets(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+	cfg, err := readConfig()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var out []string
+
+	for _, name := range slices.Sorted(maps.Keys(cfg.Presets)) {
+		out = append(out, name+"\t"+cfg.Presets[name].String())
+	}
+
+	return out, cobra.ShellCompDirectiveNoFileComp
+}
+
+func completeProfiles(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+	file, _, err := readConfigFile()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var out []string
+
+	for _, name := range slices.Sorted(maps.Keys(file.Profiles)) {
+		desc := "no server configured"
+
+		if cfg := file.Profiles[name]; cfg != nil && cfg.ServerConfig != nil {
+			desc = cfg.ServerConfig.Server
+		}
+
+		out = append(out, name+"\t"+desc)
+	}
+
+	return out, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeConfigKeys lists the keys which can be set for the selected profile.
+func completeConfigKeys(_ *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	out := []string{"current_profile"}
+	out = append(out, slices.DeleteFunc(scalarConfigKeys(reflect.TypeFor[Config](), ""), func(key string) bool {
+		return slices.Contains(readOnlyConfigKeys, key)
+	})...)
+	out = append(out, serverConfigKeys...)
+	out = append(out, scalarConfigKeys(reflect.TypeFor[NotificationConfig](), "notifications.")...)
+
+	cfg, err := readConfig()
+	if err != nil {
+		return out, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(cfg.Presets)) {
+		out = append(out, scalarConfigKeys(reflect.TypeFor[Preset](), "presets."+name+".")...)
+	}
+
+	return out, cobra.ShellCompDirectiveNoFileComp
+}
+
+func scalarConfigKeys(typ reflect.Type, prefix string) []string {
+	var out []string
+
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+
+		switch field.Type.Kind() {
+		case reflect.String, reflect.Bool, reflect.Int:
+			out = append(out, prefix+name)
+		default:
+		}
+	}
+
+	return out
+}
+
+// completeRequests returns a completion function listing request IDs which match the filter.
+func completeRequests(filter team.ListRequestsFilter) cobra.CompletionFunc {
+	return func(cmd *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		cfg, err := readConfig()
+		if err != nil || cfg.ServerConfig == nil || cfg.AuthToken == nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		// Completion must not prompt to authenticate, but a running daemon holds its own session
+		cfg.daemon = connectDaemon(cmd.Context(), cfg)
+
+		if time.Now().After(cfg.AuthToken.ExpiresAt) && cfg.daemon == nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		requests, err := cfg.client().ListRequests(cmd.Context(), filter)
+		if err != nil {
+			cobra.CompDebugln(fmt.Sprintf("could not list requests: %v", err), false)
+
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		var out []string
+
+		for _, req := range requests {
+			out = append(out, fmt.Sprintf("%s\t%s %s %s", req.ID, req.Email, req.AccountName, req.Role))
+		}
+
+		return out, cobra.ShellCompDirectiveNoFileComp
+	}
+}
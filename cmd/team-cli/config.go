@@ -1,16 +1,16 @@
 package main
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
 	"os"
-	"path/filepath"
 	"time"
 
-	"github.com/csnewman/team-cli/internal/team"
+	"github.com/csnewman/team-cli/team"
 )
 
 var ErrInvalidConfig = errors.New("invalid config")
@@ -20,66 +20,318 @@ type Config struct {
 	AuthToken     *team.AuthToken    `json:"auth_token"`
 	UseDeviceCode bool               `json:"use_device_code"`
 	NoBrowser     bool               `json:"no_browser"`
+	Presets       map[string]*Preset `json:"presets,omitempty"`
+
+	// AuthTokenStore names the credential store holding the token, in which case it is omitted from the file.
+	AuthTokenStore string `json:"auth_token_store,omitempty"`
+
+	// AccountCacheTTL is how long fetched accounts are reused for, as a Go duration (e.g. "30m").
+	AccountCacheTTL string `json:"account_cache_ttl,omitempty"`
+
+	Notifications *NotificationConfig `json:"notifications,omitempty"`
+
+	// daemon serves the operations of the command, if one was running when the config was read.
+	daemon *daemonClient
+
+	// storedToken is the token as last read from or written to the store, so unchanged tokens are not written again.
+	storedToken string
+}
+
+// client returns a TEAM client, using the daemon if one was running when the config was read, otherwise authenticated
+// with the token held by the config.
+func (c *Config) client() team.API {
+	if c.daemon != nil {
+		return c.daemon
+	}
+
+	return c.directClient()
+}
+
+// directClient returns a TEAM client authenticated with the token held by the config.
+func (c *Config) directClient() *team.Client {
+	return team.NewClient(c.ServerConfig, team.StaticTokenSource(c.AuthToken))
+}
+
+const (
+	// configVersion is the schema version written to the config file.
+	configVersion = 2
+
+	defaultProfile = "default"
+)
+
+// configFile is the layout of the config file, holding the settings of each TEAM server profile.
+type configFile struct {
+	Version        int                `json:"version"`
+	CurrentProfile string             `json:"current_profile,omitempty"`
+	Profiles       map[string]*Config `json:"profiles,omitempty"`
+}
+
+// profileFlag holds the value of --profile for the running command.
+var profileFlag string
+
+// profileName returns the profile selected by --profile, TEAM_CLI_PROFILE or the config, in that order.
+func (f *configFile) profileName() string {
+	return cmp.Or(profileFlag, os.Getenv("TEAM_CLI_PROFILE"), f.CurrentProfile, defaultProfile)
 }
 
-func configPath(file string) (string, error) {
-	homeDir, err := os.UserHomeDir()
+// setProfile replaces the settings of the selected profile. The first profile written becomes the current profile.
+func (f *configFile) setProfile(cfg *Config) {
+	name := f.profileName()
+
+	if f.Profiles == nil {
+		f.Profiles = make(map[string]*Config)
+	}
+
+	f.Profiles[name] = cfg
+
+	if f.CurrentProfile == "" {
+		f.CurrentProfile = name
+	}
+}
+
+// configMigrations each upgrade a raw config by a single version, indexed by the version they upgrade from.
+var configMigrations = [configVersion]func(raw map[string]any){
+	// Version 0 held the settings of a single server at the top level
+	func(raw map[string]any) {
+		profile := make(map[string]any)
+
+		for key, value := range raw {
+			profile[key] = value
+
+			delete(raw, key)
+		}
+
+		raw["current_profile"] = defaultProfile
+		raw["profiles"] = map[string]any{defaultProfile: profile}
+	},
+	// Version 1 always held tokens in the file, whereas version 2 may hold them in the store named by
+	// auth_token_store. The layout is unchanged, but older versions must not read a file missing its tokens.
+	func(map[string]any) {},
+}
+
+// migrateConfig upgrades a raw config to the current version, returning the version it was upgraded from.
+func migrateConfig(raw map[string]any) (int, error) {
+	version := 0
+
+	if value, ok := raw["version"]; ok {
+		v, ok := value.(float64)
+		if !ok || v != float64(int(v)) || v < 1 {
+			return 0, fmt.Errorf("%w: version must be a positive integer", ErrInvalidConfig)
+		}
+
+		version = int(v)
+	}
+
+	if version > configVersion {
+		return 0, fmt.Errorf(
+			"%w: version %d is not supported, team-cli must be updated to read it",
+			ErrInvalidConfig,
+			version,
+		)
+	}
+
+	delete(raw, "version")
+
+	for _, migrate := range configMigrations[version:] {
+		migrate(raw)
+	}
+
+	raw["version"] = configVersion
+
+	return version, nil
+}
+
+// parseConfigFile decodes a config file of any version, returning the version it was stored as.
+func parseConfigFile(data []byte) (*configFile, int, error) {
+	var raw map[string]any
+
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, 0, fmt.Errorf("failed to unmarshal config file: %w", err)
+	}
+
+	if raw == nil {
+		raw = make(map[string]any)
+	}
+
+	version, err := migrateConfig(raw)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	migrated, err := json.Marshal(raw)
 	if err != nil {
-		return "", fmt.Errorf("failed to get user dir: %w", err)
+		return nil, 0, fmt.Errorf("failed to marshal migrated config: %w", err)
 	}
 
-	teamPath := filepath.Join(homeDir, ".config", "team-cli")
+	var file *configFile
 
-	if err := os.MkdirAll(teamPath, 0755); err != nil {
-		return "", fmt.Errorf("failed to create team config dir: %w", err)
+	if err := json.Unmarshal(migrated, &file); err != nil {
+		return nil, 0, fmt.Errorf("failed to unmarshal config file: %w", err)
 	}
 
-	return filepath.Join(teamPath, file), nil
+	return file, version, nil
 }
 
-func readConfig() (*Config, error) {
+func readConfigFile() (*configFile, int, error) {
 	path, err := configPath("config.json")
 	if err != nil {
-		return nil, fmt.Errorf("failed to get config path: %w", err)
+		return nil, 0, fmt.Errorf("failed to get config path: %w", err)
 	}
 
 	raw, err := os.ReadFile(path)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
-			return new(Config), nil
+			return &configFile{Version: configVersion}, configVersion, nil
 		}
 
-		return nil, fmt.Errorf("failed to read config file: %w", err)
-	}
-
-	var config *Config
-
-	if err := json.Unmarshal(raw, &config); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal config file: %w", err)
+		return nil, 0, fmt.Errorf("failed to read config file: %w", err)
 	}
 
-	return config, nil
+	return parseConfigFile(raw)
 }
 
-func writeConfig(cfg *Config) error {
+// writeConfigFile replaces the config file. Files from older versions are first backed up, allowing older versions
+// of team-cli to be restored.
+func writeConfigFile(file *configFile) error {
 	path, err := configPath("config.json")
 	if err != nil {
 		return fmt.Errorf("failed to get config path: %w", err)
 	}
 
-	enc, err := json.MarshalIndent(cfg, "", "    ")
+	if raw, err := os.ReadFile(path); err == nil {
+		if _, version, err := parseConfigFile(raw); err != nil || version < configVersion {
+			backupPath := fmt.Sprintf("%s.v%d.bak", path, version)
+
+			slog.Info("Backing up config before upgrade", "path", backupPath)
+
+			if err := writePrivateFile(backupPath, raw); err != nil {
+				return fmt.Errorf("failed to back up config file: %w", err)
+			}
+		}
+	}
+
+	file.Version = configVersion
+
+	enc, err := json.MarshalIndent(storeTokens(file), "", "    ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal config file: %w", err)
 	}
 
-	if err := os.WriteFile(path, enc, 0644); err != nil {
+	if err := writePrivateFile(path, enc); err != nil {
 		return fmt.Errorf("failed to write config file: %w", err)
 	}
 
 	return nil
 }
 
-func readConfigReAuth(ctx context.Context) (*Config, error) {
+// readConfig returns the settings of the selected profile.
+func readConfig() (*Config, error) {
+	file, _, err := readConfigFile()
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg := file.Profiles[file.profileName()]; cfg != nil {
+		loadToken(file.profileName(), cfg)
+
+		return cfg, nil
+	}
+
+	return new(Config), nil
+}
+
+// writeConfig replaces the settings of the selected profile.
+func writeConfig(cfg *Config) error {
+	file, _, err := readConfigFile()
+	if err != nil {
+		return err
+	}
+
+	file.setProfile(cfg)
+
+	return writeConfigFile(file)
+}
+
+// lockConfig prevents other processes from updating the config until the returned function is called.
+func lockConfig() (func(), error) {
+	path, err := configPath("config.lock")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lock path: %w", err)
+	}
+
+	return lockPath(path)
+}
+
+// updateConfigFile applies a change to the latest config file while holding the lock, so concurrent token refreshes
+// are not lost.
+func updateConfigFile(update func(file *configFile) error) error {
+	unlock, err := lockConfig()
+	if err != nil {
+		return fmt.Errorf("could not lock config: %w", err)
+	}
+
+	defer unlock()
+
+	file, _, err := readConfigFile()
+	if err != nil {
+		return fmt.Errorf("could not read config: %w", err)
+	}
+
+	if err := update(file); err != nil {
+		return err
+	}
+
+	if err := writeConfigFile(file); err != nil {
+		return fmt.Errorf("could not write config: %w", err)
+	}
+
+	return nil
+}
+
+// updateConfig applies a change to the settings of the selected profile while holding the lock.
+func updateConfig(update func(cfg *Config) error) error {
+	return updateConfigFile(func(file *configFile) error {
+		cfg := file.Profiles[file.profileName()]
+
+		if cfg == nil {
+			cfg = new(Config)
+		}
+
+		if err := update(cfg); err != nil {
+			return err
+		}
+
+		file.setProfile(cfg)
+
+		return nil
+	})
+}
+
+func (c *Config) tokenValid() bool {
+	return c.AuthToken != nil && time.Now().Add(time.Minute*5).Before(c.AuthToken.ExpiresAt)
+}
+
+// upgradeConfig rewrites a config file from an older version, moving its tokens into the store if available.
+func upgradeConfig() error {
+	_, version, err := readConfigFile()
+	if err != nil {
+		return err
+	}
+
+	if version == configVersion {
+		return nil
+	}
+
+	return updateConfigFile(func(*configFile) error {
+		return nil
+	})
+}
+
+func readConfigReAuth(ctx context.Context, p *prompter) (*Config, error) {
+	if err := upgradeConfig(); err != nil {
+		slog.Warn("Could not upgrade config", "err", err)
+	}
+
 	cfg, err := readConfig()
 	if err != nil {
 		return nil, fmt.Errorf("could not read config: %w", err)
@@ -91,41 +343,43 @@ func readConfigReAuth(ctx context.Context) (*Config, error) {
 		return nil, ErrInvalidConfig
 	}
 
-	if cfg.AuthToken != nil && time.Now().Add(time.Minute*5).Before(cfg.AuthToken.ExpiresAt) {
-		slog.Info("Existing auth token is valid")
+	// The daemon holds its own session, so the token is only needed to identify the user. It is chosen once, so the
+	// command never falls back to an unrefreshed token.
+	if d := connectDaemon(ctx, cfg); d != nil {
+		cfg.daemon = d
 
 		return cfg, nil
 	}
 
-	if cfg.AuthToken != nil && cfg.AuthToken.RefreshToken != "" {
-		slog.Info("Existing auth token has expired, attempting to refresh")
-
-		newToken, err := team.RefreshToken(ctx, cfg.ServerConfig, cfg.AuthToken)
-		if err == nil {
-			slog.Info("Refreshed token")
-
-			cfg.AuthToken = newToken
+	if cfg.tokenValid() {
+		slog.Info("Existing auth token is valid")
 
-			if err := writeConfig(cfg); err != nil {
-				return nil, fmt.Errorf("failed to write new token: %w", err)
-			}
+		return cfg, nil
+	}
 
-			return cfg, nil
-		}
+	cfg, err = refreshConfigToken(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-		slog.Warn("Failed to refresh token", "err", err)
+	if cfg.tokenValid() {
+		return cfg, nil
 	}
 
 	slog.Info("Reauthentication required")
 
+	if p.noInput {
+		return nil, fmt.Errorf("%w: authentication has expired, run team-cli configure", ErrInputRequired)
+	}
+
 	var newToken *team.AuthToken
 
 	if cfg.UseDeviceCode {
-		newToken, err = team.FetchTokenViaDeviceCode(ctx, cfg.ServerConfig, func(_ context.Context) (string, error) {
-			return promptString("Device code? ")
+		newToken, err = team.FetchTokenViaDeviceCode(ctx, cfg.ServerConfig, p.out, func(_ context.Context) (string, error) {
+			return p.promptString("Device code? ", "")
 		})
 	} else {
-		newToken, err = team.FetchToken(ctx, cfg.ServerConfig, cfg.NoBrowser)
+		newToken, err = team.FetchToken(ctx, cfg.ServerConfig, p.out, cfg.NoBrowser)
 	}
 
 	if err != nil {
@@ -134,9 +388,65 @@ func readConfigReAuth(ctx context.Context) (*Config, error) {
 
 	cfg.AuthToken = newToken
 
-	if err := writeConfig(cfg); err != nil {
+	// Only the token is written, as the lock was not held while authenticating
+	if err := updateConfig(func(cfg *Config) error {
+		cfg.AuthToken = newToken
+
+		return nil
+	}); err != nil {
 		return nil, fmt.Errorf("failed to write new token: %w", err)
 	}
 
 	return cfg, nil
 }
+
+// refreshConfigToken refreshes the token of the config while holding the lock, unless another process already did.
+// The returned config holds an invalid token if it could not be refreshed. The lock is released before returning, so
+// other processes are not blocked while the user authenticates.
+func refreshConfigToken(ctx context.Context) (*Config, error) {
+	unlock, err := lockConfig()
+	if err != nil {
+		return nil, fmt.Errorf("could not lock config: %w", err)
+	}
+
+	defer unlock()
+
+	// Another process may have refreshed the token while we waited for the lock
+	cfg, err := readConfig()
+	if err != nil {
+		return nil, fmt.Errorf("could not read config: %w", err)
+	}
+
+	if cfg.ServerConfig == nil || cfg.ServerConfig.OAuthDomain == "" {
+		slog.Error("No server config found!")
+
+		return nil, ErrInvalidConfig
+	}
+
+	if cfg.tokenValid() {
+		slog.Info("Auth token was refreshed by another process")
+
+		return cfg, nil
+	}
+
+	if cfg.AuthToken != nil && cfg.AuthToken.RefreshToken != "" {
+		slog.Info("Existing auth token has expired, attempting to refresh")
+
+		newToken, err := team.RefreshToken(ctx, cfg.ServerConfig, cfg.AuthToken)
+		if err == nil {
+			slog.Info("Refreshed token")
+
+			cfg.AuthToken = newToken
+
+			if err := writeConfig(cfg); err != nil {
+				return nil, fmt.Errorf("failed to write new token: %w", err)
+			}
+
+			return cfg, nil
+		}
+
+		slog.Warn("Failed to refresh token", "err", err)
+	}
+
+	return cfg, nil
+}
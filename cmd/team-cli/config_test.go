@@ -0,0 +1,166 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/csnewman/team-cli/team"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConfigFile(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		input    string
+		version  int
+		expected *configFile
+		err      string
+	}{
+		{
+			name:    "unversioned",
+			input:   `{"use_device_code": true, "account_cache_ttl": "5m"}`,
+			version: 0,
+			expected: &configFile{
+				Version:        configVersion,
+				CurrentProfile: defaultProfile,
+				Profiles: map[string]*Config{
+					defaultProfile: {UseDeviceCode: true, AccountCacheTTL: "5m"},
+				},
+			},
+		},
+		{
+			name:    "tokens in file",
+			input:   `{"version": 1, "profiles": {"a": {"auth_token": {"access_token": "abc"}}}}`,
+			version: 1,
+			expected: &configFile{
+				Version: configVersion,
+				Profiles: map[string]*Config{
+					"a": {AuthToken: &team.AuthToken{AccessToken: "abc"}},
+				},
+			},
+		},
+		{
+			name:    "current",
+			input:   `{"version": 2, "current_profile": "b", "profiles": {"a": {}, "b": {"no_browser": true}}}`,
+			version: 2,
+			expected: &configFile{
+				Version:        configVersion,
+				CurrentProfile: "b",
+				Profiles: map[string]*Config{
+					"a": {},
+					"b": {NoBrowser: true},
+				},
+			},
+		},
+		{
+			name:    "null",
+			input:   `null`,
+			version: 0,
+			expected: &configFile{
+				Version:        configVersion,
+				CurrentProfile: defaultProfile,
+				Profiles:       map[string]*Config{defaultProfile: {}},
+			},
+		},
+		{
+			name:  "newer",
+			input: `{"version": 99}`,
+			err:   "version 99 is not supported",
+		},
+		{
+			name:  "bad version",
+			input: `{"version": "1"}`,
+			err:   "version must be a positive integer",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			file, version, err := parseConfigFile([]byte(tt.input))
+			if tt.err != "" {
+				require.ErrorIs(t, err, ErrInvalidConfig)
+				require.ErrorContains(t, err, tt.err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.version, version)
+			require.Equal(t, tt.expected, file)
+		})
+	}
+}
+
+func TestValidateConfigFile(t *testing.T) {
+	t.Parallel()
+
+	server := `"server_config": {
+		"server": "https://team.example.com",
+		"graphql_endpoint": "https://api.example.com/graphql",
+		"user_pool_client_id": "client",
+		"oauth_domain": "auth.example.com"
+	}`
+
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{
+			name:     "valid",
+			input:    `{"version": 1, "current_profile": "a", "profiles": {"a": {` + server + `}}}`,
+			expected: []string{},
+		},
+		{
+			name:     "valid unversioned",
+			input:    `{` + server + `, "presets": {"p": {"timezone": "Europe/London", "duration": 2}}}`,
+			expected: []string{},
+		},
+		{
+			name:  "unknown fields",
+			input: `{"colour": "red", "profiles": {"a": {` + server + `, "presets": {"p": {"acount": "x"}}}}, "version": 1}`,
+			expected: []string{
+				"colour: unknown field",
+				"profiles.a.presets.p.acount: unknown field",
+			},
+		},
+		{
+			name: "invalid values",
+			input: `{"version": 1, "current_profile": "b", "profiles": {"a": {
+				"server_config": {"server": "https://team.example.com"},
+				"account_cache_ttl": "soon",
+				"presets": {"p": {"timezone": "Mars/Olympus", "duration": -1}}
+			}}}`,
+			expected: []string{
+				`current_profile: profile "b" does not exist`,
+				"profiles.a.account_cache_ttl: \"soon\" is not a valid duration",
+				"profiles.a.presets.p.duration: must be positive",
+				"profiles.a.presets.p.timezone: unknown timezone \"Mars/Olympus\"",
+				"profiles.a.server_config.graphql_endpoint: missing, run team-cli configure",
+				"profiles.a.server_config.oauth_domain: missing, run team-cli configure",
+				"profiles.a.server_config.user_pool_client_id: missing, run team-cli configure",
+			},
+		},
+		{
+			name:     "wrong type",
+			input:    `{"version": 1, "profiles": {"a": {"use_device_code": "yes"}}}`,
+			expected: []string{"profiles.a.use_device_code: must be a bool"},
+		},
+		{
+			name:     "not json",
+			input:    `{`,
+			expected: []string{"not valid JSON: unexpected end of JSON input"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tt.expected, validateConfigFile([]byte(tt.input)))
+		})
+	}
+}
@@ -0,0 +1,655 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// unknownFields lists the keys of a decoded JSON value which do not correspond to a field of the given type.
+func unknownFields(value any, typ reflect.Type, path string) []string {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	var out []string
+
+	switch typ.Kind() {
+	case reflect.Struct:
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+
+		fields := make(map[string]reflect.Type)
+
+		for i := range typ.NumField() {
+			field := typ.Field(i)
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+
+			if name != "-" && field.IsExported() {
+				fields[cmp.Or(name, field.Name)] = field.Type
+			}
+		}
+
+		for key, child := range obj {
+			fieldType, ok := fields[key]
+			if !ok {
+				out = append(out, joinPath(path, key))
+
+				continue
+			}
+
+			out = append(out, unknownFields(child, fieldType, joinPath(path, key))...)
+		}
+	case reflect.Map:
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+
+		for key, child := range obj {
+			out = append(out, unknownFields(child, typ.Elem(), joinPath(path, key))...)
+		}
+	default:
+	}
+
+	return out
+}
+
+func joinPath(path string, key string) string {
+	if path == "" {
+		return key
+	}
+
+	return path + "." + key
+}
+
+// validateConfigFile reports every problem with a config file, which may be of an older version.
+func validateConfigFile(data []byte) []string {
+	var raw map[string]any
+
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return []string{fmt.Sprintf("not valid JSON: %v", err)}
+	}
+
+	if raw == nil {
+		raw = make(map[string]any)
+	}
+
+	if _, err := migrateConfig(raw); err != nil {
+		return []string{err.Error()}
+	}
+
+	problems := make([]string, 0)
+
+	for _, field := range unknownFields(raw, reflect.TypeFor[configFile](), "") {
+		problems = append(problems, field+": unknown field")
+	}
+
+	file, _, err := parseConfigFile(data)
+	if err != nil {
+		var typeErr *json.UnmarshalTypeError
+
+		if errors.As(err, &typeErr) {
+			return append(problems, fmt.Sprintf("%s: must be a %s", typeErr.Field, typeErr.Type))
+		}
+
+		return append(problems, err.Error())
+	}
+
+	if _, ok := file.Profiles[file.CurrentProfile]; file.CurrentProfile != "" && !ok {
+		problems = append(problems, fmt.Sprintf("current_profile: profile %q does not exist", file.CurrentProfile))
+	}
+
+	for name, cfg := range file.Profiles {
+		for _, problem := range cfg.validate() {
+			problems = append(problems, "profiles."+name+"."+problem)
+		}
+	}
+
+	slices.Sort(problems)
+
+	return problems
+}
+
+// validate reports invalid settings, prefixed by the name of the field.
+func (c *Config) validate() []string {
+	if c == nil {
+		return []string{"must be an object"}
+	}
+
+	var problems []string
+
+	if c.ServerConfig == nil {
+		problems = append(problems, "server_config: missing, run team-cli configure")
+	} else {
+		for field, value := range map[string]string{
+			"server":              c.ServerConfig.Server,
+			"graphql_endpoint":    c.ServerConfig.GraphQLEndpoint,
+			"user_pool_client_id": c.ServerConfig.UserPoolClientID,
+			"oauth_domain":        c.ServerConfig.OAuthDomain,
+		} {
+			if value == "" {
+				problems = append(problems, "server_config."+field+": missing, run team-cli configure")
+			}
+		}
+	}
+
+	if c.ServerConfig != nil && c.ServerConfig.AccessPortalURL != "" {
+		if u, err := url.Parse(c.ServerConfig.AccessPortalURL); err != nil || u.Scheme != "https" || u.Host == "" {
+			problems = append(problems, fmt.Sprintf(
+				"server_config.access_portal_url: %q must be an https URL",
+				c.ServerConfig.AccessPortalURL,
+			))
+		}
+	}
+
+	if c.AccountCacheTTL != "" {
+		if ttl, err := time.ParseDuration(c.AccountCacheTTL); err != nil || ttl < 0 {
+			problems = append(problems, fmt.Sprintf("account_cache_ttl: %q is not a valid duration", c.AccountCacheTTL))
+		}
+	}
+
+	if c.Notifications != nil && c.Notifications.Command != "" {
+		if args, err := splitCommand(c.Notifications.Command); err != nil {
+			problems = append(problems, "notifications.command: "+err.Error())
+		} else if len(args) == 0 {
+			problems = append(problems, "notifications.command: must not be blank")
+		} else if _, err := exec.LookPath(args[0]); err != nil {
+			problems = append(problems, fmt.Sprintf("notifications.command: %q not found", args[0]))
+		}
+	}
+
+	for name, preset := range c.Presets {
+		if preset == nil {
+			problems = append(problems, "presets."+name+": must be an object")
+
+			continue
+		}
+
+		if preset.Duration < 0 {
+			problems = append(problems, "presets."+name+".duration: must be positive")
+		}
+
+		if preset.Timezone != "" {
+			if _, err := time.LoadLocation(preset.Timezone); err != nil {
+				problems = append(problems, fmt.Sprintf("presets.%s.timezone: unknown timezone %q", name, preset.Timezone))
+			}
+		}
+	}
+
+	return problems
+}
+
+func configValidateCmdRun(cmd *cobra.Command, _ []string) error {
+	path, err := configPath("config.json")
+	if err != nil {
+		return fmt.Errorf("failed to get config path: %w", err)
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("%w: no config found, run team-cli configure", ErrInvalidConfig)
+		}
+
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	out := cmd.OutOrStdout()
+	problems := validateConfigFile(raw)
+
+	fmt.Fprintln(out)
+
+	if len(problems) == 0 {
+		fmt.Fprintf(out, "Config %s is valid\n", path)
+
+		return nil
+	}
+
+	fmt.Fprintf(out, "Config %s has problems:\n", path)
+
+	for _, problem := range problems {
+		fmt.Fprintf(out, "  %s\n", problem)
+	}
+
+	return fmt.Errorf("%w: %d problems found", ErrInvalidConfig, len(problems))
+}
+
+// readOnlyConfigKeys are the fields of a profile managed by team-cli itself, which cannot be changed via config set.
+var readOnlyConfigKeys = []string{"server_config", "auth_token", "auth_token_store"}
+
+// serverConfigKeys are the fields of the server config which cannot be discovered, so must be set by the user.
+var serverConfigKeys = []string{"server_config.access_portal_url", "server_config.access_portal_region"}
+
+// readOnlyConfigKey reports whether a path from configKeyPath refers to a setting managed by team-cli itself. Only
+// field names are compared, so profiles and presets can be given any name.
+func readOnlyConfigKey(path []string) bool {
+	if path[0] == "version" {
+		return true
+	}
+
+	if len(path) < 3 || path[0] != "profiles" {
+		return false
+	}
+
+	return slices.Contains(readOnlyConfigKeys, path[2]) && !slices.Contains(serverConfigKeys, strings.Join(path[2:], "."))
+}
+
+// configKeyPath splits a dotted key into a path within the config file. Keys which are not fields of the file itself
+// are relative to the selected profile.
+func configKeyPath(file *configFile, key string) ([]string, error) {
+	path := strings.Split(key, ".")
+
+	if slices.Contains(path, "") {
+		return nil, fmt.Errorf("%w: invalid key %q", ErrInvalid, key)
+	}
+
+	switch path[0] {
+	case "version", "current_profile", "profiles":
+		return path, nil
+	default:
+		return append([]string{"profiles", file.profileName()}, path...), nil
+	}
+}
+
+// lookupConfigField resolves a path of JSON field names and map keys within v. When create is set, missing map
+// entries and nil pointers are allocated so the field can be set.
+func lookupConfigField(v reflect.Value, path []string, create bool) (reflect.Value, error) {
+	for i := 0; ; i++ {
+		for v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				if !create {
+					return reflect.Value{}, fmt.Errorf("%w: %s is not set", ErrInvalid, strings.Join(path[:i], "."))
+				}
+
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+
+			v = v.Elem()
+		}
+
+		if i == len(path) {
+			return v, nil
+		}
+
+		switch v.Kind() {
+		case reflect.Struct:
+			field, ok := structFieldByJSONName(v, path[i])
+			if !ok {
+				return reflect.Value{}, fmt.Errorf("%w: unknown key %s", ErrInvalid, strings.Join(path[:i+1], "."))
+			}
+
+			v = field
+		case reflect.Map:
+			key := reflect.ValueOf(path[i])
+			elem := v.MapIndex(key)
+
+			if !elem.IsValid() {
+				if !create {
+					return reflect.Value{}, fmt.Errorf("%w: %s is not set", ErrInvalid, strings.Join(path[:i+1], "."))
+				}
+
+				if v.IsNil() {
+					v.Set(reflect.MakeMap(v.Type()))
+				}
+
+				elem = reflect.New(v.Type().Elem().Elem())
+				v.SetMapIndex(key, elem)
+			}
+
+			v = elem
+		default:
+			return reflect.Value{}, fmt.Errorf("%w: %s has no key %s", ErrInvalid, strings.Join(path[:i], "."), path[i])
+		}
+	}
+}
+
+func structFieldByJSONName(v reflect.Value, name string) (reflect.Value, bool) {
+	for i := range v.NumField() {
+		field := v.Type().Field(i)
+		tag, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+
+		if tag != "-" && field.IsExported() && cmp.Or(tag, field.Name) == name {
+			return v.Field(i), true
+		}
+	}
+
+	return reflect.Value{}, false
+}
+
+// redactedConfigFile returns a copy of the file with all tokens hidden.
+func redactedConfigFile(file *configFile) (*configFile, error) {
+	raw, err := json.Marshal(file)
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal config: %w", err)
+	}
+
+	var redacted *configFile
+
+	if err := json.Unmarshal(raw, &redacted); err != nil {
+		return nil, fmt.Errorf("could not unmarshal config: %w", err)
+	}
+
+	for _, cfg := range redacted.Profiles {
+		if cfg == nil || cfg.AuthToken == nil {
+			continue
+		}
+
+		for _, token := range []*string{&cfg.AuthToken.IdToken, &cfg.AuthToken.AccessToken, &cfg.AuthToken.RefreshToken} {
+			if *token != "" {
+				*token = "REDACTED"
+			}
+		}
+	}
+
+	return redacted, nil
+}
+
+func formatConfigValue(v reflect.Value) (string, error) {
+	switch v.Kind() {
+	case reflect.String, reflect.Bool, reflect.Int:
+		return fmt.Sprint(v.Interface()), nil
+	default:
+		enc, err := json.MarshalIndent(v.Interface(), "", "    ")
+		if err != nil {
+			return "", fmt.Errorf("could not marshal value: %w", err)
+		}
+
+		return string(enc), nil
+	}
+}
+
+func configViewCmdRun(cmd *cobra.Command, _ []string) error {
+	file, _, err := readConfigFile()
+	if err != nil {
+		return err
+	}
+
+	redacted, err := redactedConfigFile(file)
+	if err != nil {
+		return err
+	}
+
+	value, err := formatConfigValue(reflect.ValueOf(redacted).Elem())
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(cmd.OutOrStdout(), value)
+
+	return nil
+}
+
+func configGetCmdRun(cmd *cobra.Command, args []string) error {
+	file, _, err := readConfigFile()
+	if err != nil {
+		return err
+	}
+
+	redacted, err := redactedConfigFile(file)
+	if err != nil {
+		return err
+	}
+
+	path, err := configKeyPath(redacted, args[0])
+	if err != nil {
+		return err
+	}
+
+	field, err := lookupConfigField(reflect.ValueOf(redacted).Elem(), path, false)
+	if err != nil {
+		return err
+	}
+
+	value, err := formatConfigValue(field)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(cmd.OutOrStdout(), value)
+
+	return nil
+}
+
+// setConfigValue parses and stores a value at the given key, which must refer to a string, bool or integer field.
+func setConfigValue(file *configFile, key string, value string) error {
+	path, err := configKeyPath(file, key)
+	if err != nil {
+		return err
+	}
+
+	if readOnlyConfigKey(path) {
+		return fmt.Errorf("%w: %s is managed by team-cli configure", ErrInvalid, key)
+	}
+
+	field, err := lookupConfigField(reflect.ValueOf(file).Elem(), path, true)
+	if err != nil {
+		return err
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%w: %s must be true or false", ErrInvalid, key)
+		}
+
+		field.SetBool(b)
+	case reflect.Int:
+		i, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%w: %s must be an integer", ErrInvalid, key)
+		}
+
+		field.SetInt(int64(i))
+	default:
+		return fmt.Errorf("%w: %s cannot be set directly, set one of its fields or use team-cli config edit", ErrInvalid, key)
+	}
+
+	// A configured access portal URL is kept when the server config is discovered again
+	if len(path) == 4 && path[2] == "server_config" && path[3] == "access_portal_url" {
+		file.Profiles[path[1]].ServerConfig.AccessPortalURLConfigured = value != ""
+	}
+
+	return nil
+}
+
+// newConfigProblems lists the problems present in after but not in before, so unrelated existing problems do not
+// prevent changes.
+func newConfigProblems(before []byte, after []byte) []string {
+	existing := validateConfigFile(before)
+
+	return slices.DeleteFunc(validateConfigFile(after), func(problem string) bool {
+		return slices.Contains(existing, problem)
+	})
+}
+
+func configSetCmdRun(cmd *cobra.Command, args []string) error {
+	key, value := args[0], args[1]
+
+	if err := updateConfigFile(func(file *configFile) error {
+		before, err := json.Marshal(file)
+		if err != nil {
+			return fmt.Errorf("could not marshal config: %w", err)
+		}
+
+		if err := setConfigValue(file, key, value); err != nil {
+			return err
+		}
+
+		after, err := json.Marshal(file)
+		if err != nil {
+			return fmt.Errorf("could not marshal config: %w", err)
+		}
+
+		if problems := newConfigProblems(before, after); len(problems) > 0 {
+			return fmt.Errorf("%w: %s", ErrInvalidConfig, strings.Join(problems, ", "))
+		}
+
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(cmd.OutOrStdout())
+	fmt.Fprintf(cmd.OutOrStdout(), "Set %s to %q\n", key, value)
+
+	return nil
+}
+
+// editFile opens a file in the user's editor, waiting for it to exit.
+var editFile = func(cmd *cobra.Command, path string) error {
+	editor := cmp.Or(os.Getenv("VISUAL"), os.Getenv("EDITOR"))
+
+	if editor == "" {
+		editor = "vi"
+
+		if runtime.GOOS == "windows" {
+			editor = "notepad"
+		}
+	}
+
+	// Editors are commonly configured with arguments, e.g. "code --wait", or quoted paths
+	fields, err := splitCommand(editor)
+	if err != nil {
+		return fmt.Errorf("could not parse editor %q: %w", editor, err)
+	}
+
+	if len(fields) == 0 {
+		return fmt.Errorf("%w: editor %q is empty", ErrInvalid, editor)
+	}
+
+	editCmd := exec.CommandContext(cmd.Context(), fields[0], append(fields[1:], path)...)
+	editCmd.Stdin = os.Stdin
+	editCmd.Stdout = os.Stdout
+	editCmd.Stderr = os.Stderr
+
+	if err := editCmd.Run(); err != nil {
+		return fmt.Errorf("editor %q failed: %w", editor, err)
+	}
+
+	return nil
+}
+
+func configEditCmdRun(cmd *cobra.Command, _ []string) error {
+	out := cmd.OutOrStdout()
+	p := newPrompter(cmd)
+
+	file, _, err := readConfigFile()
+	if err != nil {
+		return err
+	}
+
+	original, err := json.MarshalIndent(file, "", "    ")
+	if err != nil {
+		return fmt.Errorf("could not marshal config: %w", err)
+	}
+
+	configFilePath, err := configPath("config.json")
+	if err != nil {
+		return fmt.Errorf("failed to get config path: %w", err)
+	}
+
+	// The config may hold tokens, so it is edited within the private config dir rather than the shared temp dir
+	dir, err := os.MkdirTemp(filepath.Dir(configFilePath), "edit-")
+	if err != nil {
+		return fmt.Errorf("could not create temp dir: %w", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config.json")
+
+	if err := writePrivateFile(path, original); err != nil {
+		return fmt.Errorf("could not write temp file: %w", err)
+	}
+
+	var edited []byte
+
+	for {
+		if err := editFile(cmd, path); err != nil {
+			return err
+		}
+
+		edited, err = os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("could not read edited config: %w", err)
+		}
+
+		problems := newConfigProblems(original, edited)
+		if len(problems) == 0 {
+			break
+		}
+
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "The edited config has problems:")
+
+		for _, problem := range problems {
+			fmt.Fprintf(out, "  %s\n", problem)
+		}
+
+		fmt.Fprintln(out)
+
+		retry, err := p.promptBool("Edit again (y/n)? ", "")
+		if err != nil {
+			return fmt.Errorf("could not select retry: %w", err)
+		}
+
+		if !retry {
+			return fmt.Errorf("%w: changes discarded", ErrInvalidConfig)
+		}
+	}
+
+	if bytes.Equal(bytes.TrimSpace(original), bytes.TrimSpace(edited)) {
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "No changes made")
+
+		return nil
+	}
+
+	editedFile, _, err := parseConfigFile(edited)
+	if err != nil {
+		return err
+	}
+
+	if err := updateConfigFile(func(file *configFile) error {
+		// Refuse to overwrite changes made while editing, such as a refreshed token
+		current, err := json.MarshalIndent(file, "", "    ")
+		if err != nil {
+			return fmt.Errorf("could not marshal config: %w", err)
+		}
+
+		if !bytes.Equal(current, original) {
+			return fmt.Errorf("%w: config was changed while editing, try again", ErrInvalidConfig)
+		}
+
+		*file = *editedFile
+
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Config updated")
+
+	return nil
+}
@@ -1,11 +1,12 @@
 package main
 
 import (
+	"cmp"
 	"context"
 	"fmt"
 	"log/slog"
 
-	"github.com/csnewman/team-cli/internal/team"
+	"github.com/csnewman/team-cli/team"
 	"github.com/spf13/cobra"
 )
 
@@ -20,6 +21,13 @@ func configureCmdRun(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("no-browser flag: %w", err)
 	}
 
+	accessPortalURL, err := cmd.Flags().GetString("access-portal-url")
+	if err != nil {
+		return fmt.Errorf("access-portal-url flag: %w", err)
+	}
+
+	p := newPrompter(cmd)
+
 	remoteCfg, err := team.ExtractConfig(cmd.Context(), args[0])
 	if err != nil {
 		return err
@@ -30,11 +38,11 @@ func configureCmdRun(cmd *cobra.Command, args []string) error {
 	var token *team.AuthToken
 
 	if useDeviceCode {
-		token, err = team.FetchTokenViaDeviceCode(cmd.Context(), remoteCfg, func(_ context.Context) (string, error) {
-			return promptString("Device code? ")
+		token, err = team.FetchTokenViaDeviceCode(cmd.Context(), remoteCfg, p.out, func(_ context.Context) (string, error) {
+			return p.promptString("Device code? ", "")
 		})
 	} else {
-		token, err = team.FetchToken(cmd.Context(), remoteCfg, noBrowser)
+		token, err = team.FetchToken(cmd.Context(), remoteCfg, p.out, noBrowser)
 	}
 
 	if err != nil {
@@ -43,21 +51,43 @@ func configureCmdRun(cmd *cobra.Command, args []string) error {
 
 	slog.Info("Fetched initial token")
 
-	existingCfg, err := readConfig()
-	if err != nil {
-		return fmt.Errorf("failed to read existing config: %w", err)
-	}
+	if err := updateConfig(func(existingCfg *Config) error {
+		keepAccessPortal(remoteCfg, existingCfg.ServerConfig)
+
+		if accessPortalURL != "" {
+			remoteCfg.AccessPortalURL = accessPortalURL
+			remoteCfg.AccessPortalURLConfigured = true
+		}
 
-	existingCfg.UseDeviceCode = useDeviceCode
-	existingCfg.NoBrowser = noBrowser
-	existingCfg.ServerConfig = remoteCfg
-	existingCfg.AuthToken = token
+		existingCfg.UseDeviceCode = useDeviceCode
+		existingCfg.NoBrowser = noBrowser
+		existingCfg.ServerConfig = remoteCfg
+		existingCfg.AuthToken = token
 
-	if err := writeConfig(existingCfg); err != nil {
-		return fmt.Errorf("failed to write existing config: %w", err)
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to update existing config: %w", err)
 	}
 
 	slog.Info("TEAM CLI config updated")
 
 	return nil
 }
+
+// keepAccessPortal copies the access portal settings of the existing server config, as they are not always
+// discoverable. A configured URL takes priority over a discovered one, while a previously discovered URL is only kept
+// if it can no longer be discovered.
+func keepAccessPortal(remote *team.RemoteConfig, existing *team.RemoteConfig) {
+	if existing == nil || existing.Server != remote.Server {
+		return
+	}
+
+	if existing.AccessPortalURLConfigured {
+		remote.AccessPortalURL = existing.AccessPortalURL
+		remote.AccessPortalURLConfigured = true
+	} else {
+		remote.AccessPortalURL = cmp.Or(remote.AccessPortalURL, existing.AccessPortalURL)
+	}
+
+	remote.AccessPortalRegion = cmp.Or(remote.AccessPortalRegion, existing.AccessPortalRegion)
+}
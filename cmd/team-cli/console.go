@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+
+	"github.com/csnewman/team-cli/team"
+	"github.com/spf13/cobra"
+)
+
+// openBrowser opens a URL in the browser, replaced by tests.
+var openBrowser = team.OpenBrowser
+
+// consoleURL returns the access portal link which signs in to the AWS console as the role.
+func consoleURL(startURL string, accountID string, role string) string {
+	return strings.TrimSuffix(startURL, "/") + "/#/console?" + url.Values{
+		"account_id": {accountID},
+		"role_name":  {role},
+	}.Encode()
+}
+
+func consoleCmdRun(cmd *cobra.Command, args []string) error {
+	noBrowser, err := cmd.Flags().GetBool("no-browser")
+	if err != nil {
+		return fmt.Errorf("no-browser flag: %w", err)
+	}
+
+	out := cmd.OutOrStdout()
+	p := newPrompter(cmd)
+
+	cfg, err := readConfigReAuth(cmd.Context(), p)
+	if err != nil {
+		return fmt.Errorf("could not read config and authenticate: %w", err)
+	}
+
+	startURL, err := cfg.accessPortalURL()
+	if err != nil {
+		return err
+	}
+
+	session, err := selectActiveSession(cmd, p, cfg, args)
+	if err != nil {
+		return err
+	}
+
+	link := consoleURL(startURL, session.AccountID, session.Role)
+
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Session:")
+	fmt.Fprintf(out, "  ID: %q\n", session.ID)
+	fmt.Fprintf(out, "  Account: id=%q name=%q\n", session.AccountID, session.AccountName)
+	fmt.Fprintf(out, "  Role: name=%q\n", session.Role)
+	fmt.Fprintf(out, "  Ends: %q\n", fmtDate(session.EndTime))
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Console:")
+	fmt.Fprintln(out, "  "+link)
+
+	if noBrowser || cfg.NoBrowser {
+		return nil
+	}
+
+	if err := openBrowser(link); err != nil {
+		slog.Warn("failed to open browser", "err", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,303 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/csnewman/team-cli/team"
+	"github.com/spf13/cobra"
+)
+
+// accessPortalURL returns the start URL of the access portal, which is required to use sessions.
+func (c *Config) accessPortalURL() (string, error) {
+	if c.ServerConfig.AccessPortalURL == "" {
+		return "", fmt.Errorf(
+			"%w: the access portal URL is unknown, run team-cli config set server_config.access_portal_url "+
+				"https://d-1234567890.awsapps.com/start",
+			ErrInvalidConfig,
+		)
+	}
+
+	return c.ServerConfig.AccessPortalURL, nil
+}
+
+// accessPortal returns the start URL and region of the access portal, which are required to use credentials.
+func (c *Config) accessPortal() (string, string, error) {
+	startURL, err := c.accessPortalURL()
+	if err != nil {
+		return "", "", err
+	}
+
+	region := c.ServerConfig.PortalRegion()
+	if region == "" {
+		return "", "", fmt.Errorf(
+			"%w: the access portal region is unknown, run team-cli config set server_config.access_portal_region "+
+				"<region>",
+			ErrInvalidConfig,
+		)
+	}
+
+	return startURL, region, nil
+}
+
+// selectActiveSession selects one of the user's active sessions, by ID if given. A single active session is selected
+// without prompting.
+func selectActiveSession(cmd *cobra.Command, p *prompter, cfg *Config, args []string) (*team.PermissionRequest, error) {
+	requests, err := cfg.client().ListRequests(cmd.Context(), team.ListRequestsFilterMyActive)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch requests: %w", err)
+	}
+
+	if len(requests) == 0 {
+		return nil, fmt.Errorf("%w: there are no active sessions, run team-cli request", ErrInvalid)
+	}
+
+	if len(requests) == 1 && len(args) == 0 {
+		return requests[0], nil
+	}
+
+	return selectRequest(p, requests, args)
+}
+
+// credentialProcessCommand returns the command the AWS CLI should run to fetch credentials for the role.
+func credentialProcessCommand(accountID string, role string) (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("could not find team-cli executable: %w", err)
+	}
+
+	args := []string{exe, "credentials", "--process", "--account", accountID, "--role", role}
+
+	if configDirFlag != "" {
+		args = append(args, "--config-dir", configDirFlag)
+	}
+
+	if profileFlag != "" {
+		args = append(args, "--profile", profileFlag)
+	}
+
+	for i, arg := range args {
+		if strings.ContainsAny(arg, " \t\"'") {
+			args[i] = `"` + strings.ReplaceAll(arg, `"`, `\"`) + `"`
+		}
+	}
+
+	return strings.Join(args, " "), nil
+}
+
+// writeSessionProfile writes an AWS CLI profile for the session, returning the path of the AWS CLI config. The profile
+// either signs in via the access portal, or runs team-cli as a credential process. The latter has no access portal
+// settings, so an sso-session is written alongside it to sign in with.
+func writeSessionProfile(
+	name string,
+	session *team.PermissionRequest,
+	startURL string,
+	region string,
+	useProcess bool,
+) (string, error) {
+	if !useProcess {
+		return writeAWSConfig(awsConfigSection{
+			name: "profile " + name,
+			keys: [][2]string{
+				{"sso_start_url", startURL},
+				{"sso_region", region},
+				{"sso_account_id", session.AccountID},
+				{"sso_role_name", session.Role},
+			},
+		})
+	}
+
+	process, err := credentialProcessCommand(session.AccountID, session.Role)
+	if err != nil {
+		return "", err
+	}
+
+	return writeAWSConfig(
+		awsConfigSection{
+			name: "sso-session " + awsSSOSessionName(startURL),
+			keys: [][2]string{
+				{"sso_start_url", startURL},
+				{"sso_region", region},
+				{"sso_registration_scopes", "sso:account:access"},
+			},
+		},
+		awsConfigSection{
+			name: "profile " + name,
+			keys: [][2]string{{"credential_process", process}},
+		},
+	)
+}
+
+func credentialsCmdRun(cmd *cobra.Command, args []string) error {
+	process, err := cmd.Flags().GetBool("process")
+	if err != nil {
+		return fmt.Errorf("process flag: %w", err)
+	}
+
+	if process {
+		return credentialsProcessRun(cmd, args)
+	}
+
+	awsProfile, err := cmd.Flags().GetString("aws-profile")
+	if err != nil {
+		return fmt.Errorf("aws-profile flag: %w", err)
+	}
+
+	export, err := cmd.Flags().GetBool("export")
+	if err != nil {
+		return fmt.Errorf("export flag: %w", err)
+	}
+
+	useProcess, err := cmd.Flags().GetBool("credential-process")
+	if err != nil {
+		return fmt.Errorf("credential-process flag: %w", err)
+	}
+
+	out := cmd.OutOrStdout()
+	p := newPrompter(cmd)
+
+	// Only the export is written to stdout, so it can be evaluated by the shell
+	if export {
+		p.out = cmd.ErrOrStderr()
+	}
+
+	cfg, err := readConfigReAuth(cmd.Context(), p)
+	if err != nil {
+		return fmt.Errorf("could not read config and authenticate: %w", err)
+	}
+
+	startURL, region, err := cfg.accessPortal()
+	if err != nil {
+		return err
+	}
+
+	session, err := selectActiveSession(cmd, p, cfg, args)
+	if err != nil {
+		return err
+	}
+
+	if awsProfile == "" {
+		awsProfile = awsProfileName(session.AccountName, session.Role)
+	}
+
+	path, err := writeSessionProfile(awsProfile, session, startURL, region, useProcess)
+	if err != nil {
+		return err
+	}
+
+	if export {
+		fmt.Fprintf(out, "export AWS_PROFILE=%s\n", awsProfile)
+
+		return nil
+	}
+
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Session:")
+	fmt.Fprintf(out, "  ID: %q\n", session.ID)
+	fmt.Fprintf(out, "  Account: id=%q name=%q\n", session.AccountID, session.AccountName)
+	fmt.Fprintf(out, "  Role: name=%q\n", session.Role)
+	fmt.Fprintf(out, "  Ends: %q\n", fmtDate(session.EndTime))
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "Wrote profile %q to %s\n", awsProfile, path)
+
+	if useProcess {
+		fmt.Fprintf(out, "Sign in to the access portal with: %s\n", ssoLoginCommand(awsSSOSessionName(startURL)))
+	} else {
+		fmt.Fprintf(out, "Sign in to the access portal with: aws sso login --profile %s\n", awsProfile)
+	}
+
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Use the profile with:")
+	fmt.Fprintf(out, "  export AWS_PROFILE=%s\n", awsProfile)
+
+	return nil
+}
+
+// credentialsProcessRun implements the credential_process protocol, printing credentials for the role if the user has
+// an active session for it.
+func credentialsProcessRun(cmd *cobra.Command, args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("%w: the request ID cannot be used with --process", ErrInvalid)
+	}
+
+	accountID, err := cmd.Flags().GetString("account")
+	if err != nil {
+		return fmt.Errorf("account flag: %w", err)
+	}
+
+	role, err := cmd.Flags().GetString("role")
+	if err != nil {
+		return fmt.Errorf("role flag: %w", err)
+	}
+
+	if accountID == "" || role == "" {
+		return fmt.Errorf("%w: --account and --role are required with --process", ErrInvalid)
+	}
+
+	// The AWS CLI parses stdout, so authentication must never prompt
+	p := newPrompter(cmd)
+	p.out = cmd.ErrOrStderr()
+	p.noInput = true
+
+	cfg, err := readConfigReAuth(cmd.Context(), p)
+	if err != nil {
+		return fmt.Errorf("could not read config and authenticate: %w", err)
+	}
+
+	startURL, region, err := cfg.accessPortal()
+	if err != nil {
+		return err
+	}
+
+	requests, err := cfg.client().ListRequests(cmd.Context(), team.ListRequestsFilterMyActive)
+	if err != nil {
+		return fmt.Errorf("could not fetch requests: %w", err)
+	}
+
+	var session *team.PermissionRequest
+
+	for _, req := range requests {
+		if req.AccountID == accountID && req.Role == role {
+			session = req
+
+			break
+		}
+	}
+
+	if session == nil {
+		return fmt.Errorf("%w: no active session for role %q in account %q, run team-cli request", ErrInvalid, role, accountID)
+	}
+
+	ssoSession := awsSSOSessionName(startURL)
+
+	token, err := readSSOToken(ssoSession, startURL)
+	if err != nil {
+		return fmt.Errorf("%w, sign in with: %s", err, ssoLoginCommand(ssoSession))
+	}
+
+	creds, err := fetchRoleCredentials(cmd, region, token, accountID, role)
+	if err != nil {
+		if errors.Is(err, ErrNoSSOToken) {
+			return fmt.Errorf("%w, sign in with: %s", err, ssoLoginCommand(ssoSession))
+		}
+
+		return err
+	}
+
+	// Expire the credentials with the session, so the AWS CLI checks the session again
+	if !session.EndTime.IsZero() && session.EndTime.Before(creds.Expiration) {
+		creds.Expiration = session.EndTime.UTC()
+	}
+
+	enc, err := json.MarshalIndent(creds, "", "    ")
+	if err != nil {
+		return fmt.Errorf("could not marshal credentials: %w", err)
+	}
+
+	fmt.Fprintln(cmd.OutOrStdout(), string(enc))
+
+	return nil
+}
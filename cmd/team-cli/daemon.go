@@ -0,0 +1,510 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/csnewman/team-cli/team"
+	"github.com/spf13/cobra"
+)
+
+// daemonWatcherBuffer is the number of changes buffered for each event stream. Streams which fall further behind are
+// ended, rather than delaying the others.
+const daemonWatcherBuffer = 64
+
+// daemon holds an authenticated client, the accounts of the user and a subscription to request changes, serving them
+// to other team-cli processes over a Unix socket.
+type daemon struct {
+	cfg    *Config
+	client *team.Client
+
+	mu       sync.Mutex
+	accounts map[string]*team.Account
+	watchers map[chan *team.PermissionRequest]struct{}
+
+	// ready is closed while the request subscription is active
+	ready      chan struct{}
+	subscribed bool
+}
+
+func newDaemon(cfg *Config) *daemon {
+	// Tokens are refreshed, as the daemon outlives them
+	tokens := &configTokenSource{
+		tokens: team.RefreshingTokenSource(cfg.ServerConfig, cfg.AuthToken),
+		saved:  cfg.AuthToken,
+	}
+
+	return &daemon{
+		cfg:      cfg,
+		client:   team.NewClient(cfg.ServerConfig, tokens),
+		watchers: make(map[chan *team.PermissionRequest]struct{}),
+		ready:    make(chan struct{}),
+	}
+}
+
+// configTokenSource saves refreshed tokens to the config, so commands run once the daemon stops do not need to refresh
+// or authenticate again.
+type configTokenSource struct {
+	tokens team.TokenSource
+
+	mu    sync.Mutex
+	saved *team.AuthToken
+}
+
+func (s *configTokenSource) Token(ctx context.Context) (*team.AuthToken, error) {
+	token, err := s.tokens.Token(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if token == s.saved {
+		return token, nil
+	}
+
+	s.saved = token
+
+	err = updateConfig(func(cfg *Config) error {
+		// Another process may have authenticated since
+		if cfg.AuthToken == nil || cfg.AuthToken.ExpiresAt.Before(token.ExpiresAt) {
+			cfg.AuthToken = token
+		}
+
+		return nil
+	})
+	if err != nil {
+		slog.Warn("Failed to save refreshed token", "err", err)
+	}
+
+	return token, nil
+}
+
+// watchRequests keeps the request subscription active until the context is cancelled, ending all event streams
+// whenever it fails.
+func (d *daemon) watchRequests(ctx context.Context) {
+	for {
+		err := d.client.WatchRequests(
+			ctx,
+			func(context.Context) error {
+				d.mu.Lock()
+				defer d.mu.Unlock()
+
+				close(d.ready)
+				d.subscribed = true
+
+				return nil
+			},
+			d.broadcast,
+		)
+
+		d.resetWatchers()
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		slog.Warn("Watching requests failed, retrying", "err", err, "delay", watchRetryDelay)
+
+		select {
+		case <-time.After(watchRetryDelay):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (d *daemon) broadcast(req *team.PermissionRequest) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for ch := range d.watchers {
+		select {
+		case ch <- req:
+		default:
+			slog.Warn("Ending event stream which fell behind")
+
+			delete(d.watchers, ch)
+			close(ch)
+		}
+	}
+}
+
+func (d *daemon) resetWatchers() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for ch := range d.watchers {
+		close(ch)
+	}
+
+	d.watchers = make(map[chan *team.PermissionRequest]struct{})
+
+	if d.subscribed {
+		d.ready = make(chan struct{})
+		d.subscribed = false
+	}
+}
+
+// addWatcher returns a channel receiving changes, which is closed if the subscription fails, and a channel closed once
+// the subscription is active.
+func (d *daemon) addWatcher() (chan *team.PermissionRequest, <-chan struct{}) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	ch := make(chan *team.PermissionRequest, daemonWatcherBuffer)
+	d.watchers[ch] = struct{}{}
+
+	return ch, d.ready
+}
+
+func (d *daemon) removeWatcher(ch chan *team.PermissionRequest) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.watchers, ch)
+}
+
+// watchAccounts keeps the accounts up to date with published policies until the context is cancelled. The policy is
+// requested again once the account cache TTL elapses.
+func (d *daemon) watchAccounts(ctx context.Context) {
+	ttl := d.cfg.accountCacheTTL()
+
+	for {
+		var (
+			watchCtx context.Context
+			cancel   context.CancelFunc
+		)
+
+		if ttl > 0 {
+			watchCtx, cancel = context.WithTimeout(ctx, ttl)
+		} else {
+			watchCtx, cancel = context.WithCancel(ctx)
+		}
+
+		err := d.client.WatchAccounts(watchCtx, d.setAccounts)
+
+		cancel()
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		if errors.Is(err, context.DeadlineExceeded) {
+			continue
+		}
+
+		slog.Warn("Watching accounts failed, retrying", "err", err, "delay", watchRetryDelay)
+
+		select {
+		case <-time.After(watchRetryDelay):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (d *daemon) setAccounts(accounts map[string]*team.Account) {
+	slog.Info("Accounts updated", "count", len(accounts))
+
+	d.mu.Lock()
+	d.accounts = accounts
+	d.mu.Unlock()
+
+	// Commands which read the cache directly also benefit
+	if err := cacheAccounts(d.cfg, accounts); err != nil {
+		slog.Warn("Failed to cache accounts", "err", err)
+	}
+}
+
+func (d *daemon) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/accounts", d.handleAccounts)
+	mux.HandleFunc("GET /v1/requests", d.handleListRequests)
+	mux.HandleFunc("POST /v1/requests", d.handleRequest)
+	mux.HandleFunc("GET /v1/requests/{id}", d.handleGetRequest)
+	mux.HandleFunc("POST /v1/responses", d.handleRespond)
+	mux.HandleFunc("GET /v1/events", d.handleEvents)
+
+	return mux
+}
+
+func writeDaemonJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		slog.Debug("Failed to write response", "err", err)
+	}
+}
+
+func writeDaemonError(w http.ResponseWriter, status int, err error) {
+	slog.Warn("Daemon request failed", "err", err)
+
+	writeDaemonJSON(w, status, &daemonErrorBody{Error: err.Error()})
+}
+
+func (d *daemon) handleAccounts(w http.ResponseWriter, r *http.Request) {
+	d.mu.Lock()
+	accounts := d.accounts
+	d.mu.Unlock()
+
+	// The policy may not have been received yet
+	if accounts == nil {
+		var err error
+
+		accounts, err = d.client.FetchAccounts(r.Context())
+		if err != nil {
+			writeDaemonError(w, http.StatusBadGateway, fmt.Errorf("could not fetch accounts: %w", err))
+
+			return
+		}
+
+		d.setAccounts(accounts)
+	}
+
+	writeDaemonJSON(w, http.StatusOK, newDaemonAccounts(accounts))
+}
+
+// daemonListFilters are the filters accepted by the daemon, as ListRequests panics on unknown filters.
+var daemonListFilters = []team.ListRequestsFilter{
+	team.ListRequestsFilterAll,
+	team.ListRequestsFilterRequiresMyApproval,
+	team.ListRequestsFilterMyPending,
+	team.ListRequestsFilterMyActive,
+}
+
+func (d *daemon) handleListRequests(w http.ResponseWriter, r *http.Request) {
+	filter := team.ListRequestsFilter(r.URL.Query().Get("filter"))
+
+	if !slices.Contains(daemonListFilters, filter) {
+		writeDaemonError(w, http.StatusBadRequest, fmt.Errorf("%w: unknown filter %q", ErrInvalid, filter))
+
+		return
+	}
+
+	requests, err := d.client.ListRequests(r.Context(), filter)
+	if err != nil {
+		writeDaemonError(w, http.StatusBadGateway, fmt.Errorf("could not fetch requests: %w", err))
+
+		return
+	}
+
+	writeDaemonJSON(w, http.StatusOK, requests)
+}
+
+func (d *daemon) handleGetRequest(w http.ResponseWriter, r *http.Request) {
+	req, err := d.client.GetRequest(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeDaemonError(w, http.StatusBadGateway, fmt.Errorf("could not fetch request: %w", err))
+
+		return
+	}
+
+	writeDaemonJSON(w, http.StatusOK, req)
+}
+
+func (d *daemon) handleRequest(w http.ResponseWriter, r *http.Request) {
+	var req *daemonAccessRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req == nil {
+		writeDaemonError(w, http.StatusBadRequest, fmt.Errorf("%w: invalid request body", ErrInvalid))
+
+		return
+	}
+
+	id, err := d.client.Request(r.Context(), &team.AccessRequest{
+		AccountID:     req.AccountID,
+		AccountName:   req.AccountName,
+		Role:          req.Role,
+		RoleID:        req.RoleID,
+		Duration:      req.Duration,
+		StartTime:     req.StartTime,
+		Justification: req.Justification,
+		Ticket:        req.Ticket,
+	})
+	if err != nil {
+		writeDaemonError(w, http.StatusBadGateway, fmt.Errorf("could not request role: %w", err))
+
+		return
+	}
+
+	writeDaemonJSON(w, http.StatusOK, &daemonRequestResult{ID: id})
+}
+
+func (d *daemon) handleRespond(w http.ResponseWriter, r *http.Request) {
+	var resp *daemonAccessResponse
+
+	if err := json.NewDecoder(r.Body).Decode(&resp); err != nil || resp == nil {
+		writeDaemonError(w, http.StatusBadRequest, fmt.Errorf("%w: invalid response body", ErrInvalid))
+
+		return
+	}
+
+	err := d.client.Respond(r.Context(), &team.AccessResponse{
+		ID:      resp.ID,
+		Status:  resp.Status,
+		Comment: resp.Comment,
+	})
+	if err != nil {
+		writeDaemonError(w, http.StatusBadGateway, fmt.Errorf("could not respond to request: %w", err))
+
+		return
+	}
+
+	writeDaemonJSON(w, http.StatusOK, struct{}{})
+}
+
+// handleEvents streams a ready event once subscribed, followed by every changed request, as newline delimited JSON.
+func (d *daemon) handleEvents(w http.ResponseWriter, r *http.Request) {
+	ch, ready := d.addWatcher()
+	defer d.removeWatcher(ch)
+
+	rc := http.NewResponseController(w)
+	enc := json.NewEncoder(w)
+
+	select {
+	case <-ready:
+	case <-ch:
+		// The subscription failed before becoming active
+		return
+	case <-r.Context().Done():
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+
+	event := &daemonEvent{Ready: true}
+
+	for {
+		if err := enc.Encode(event); err != nil {
+			return
+		}
+
+		if err := rc.Flush(); err != nil {
+			return
+		}
+
+		select {
+		case req, ok := <-ch:
+			if !ok {
+				return
+			}
+
+			event = &daemonEvent{Request: req}
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// listenDaemon listens on the socket, replacing any left behind by a daemon which did not exit cleanly. Starting
+// daemons hold a lock, so they cannot remove each other's sockets.
+func listenDaemon(path string) (net.Listener, error) {
+	unlock, err := lockPath(path + ".lock")
+	if err != nil {
+		return nil, fmt.Errorf("could not lock socket: %w", err)
+	}
+
+	defer unlock()
+
+	if conn, err := net.Dial("unix", path); err == nil {
+		_ = conn.Close()
+
+		return nil, fmt.Errorf("%w: a daemon is already running on %s", ErrInvalid, path)
+	}
+
+	// The socket of a daemon which did not exit cleanly is left behind
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("could not remove stale socket: %w", err)
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("could not listen: %w", err)
+	}
+
+	if err := os.Chmod(path, 0o600); err != nil {
+		_ = listener.Close()
+
+		return nil, fmt.Errorf("could not restrict socket permissions: %w", err)
+	}
+
+	return listener, nil
+}
+
+func daemonCmdRun(cmd *cobra.Command, _ []string) error {
+	out := cmd.OutOrStdout()
+	p := newPrompter(cmd)
+
+	cfg, err := readConfigReAuth(cmd.Context(), p)
+	if err != nil {
+		return fmt.Errorf("could not read config and authenticate: %w", err)
+	}
+
+	path, err := daemonSocketPath(cfg)
+	if err != nil {
+		return fmt.Errorf("could not determine socket path: %w", err)
+	}
+
+	listener, err := listenDaemon(path)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	d := newDaemon(cfg)
+
+	srv := &http.Server{
+		Handler:           d.handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext: func(net.Listener) context.Context {
+			return ctx
+		},
+	}
+
+	var wg sync.WaitGroup
+
+	wg.Go(func() {
+		d.watchRequests(ctx)
+	})
+
+	wg.Go(func() {
+		d.watchAccounts(ctx)
+	})
+
+	wg.Go(func() {
+		<-ctx.Done()
+
+		_ = srv.Close()
+	})
+
+	fmt.Fprintf(out, "Daemon listening on %s\n", path)
+
+	err = srv.Serve(listener)
+
+	stop()
+	wg.Wait()
+
+	if !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("could not serve: %w", err)
+	}
+
+	fmt.Fprintln(out, "Daemon stopped")
+
+	return nil
+}
@@ -0,0 +1,401 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/csnewman/team-cli/team"
+)
+
+var ErrDaemon = errors.New("daemon error")
+
+// daemonSocketPath returns the path of the socket of the daemon serving the server and user of the config. Daemons are
+// keyed by identity rather than profile, so profiles sharing a login share a daemon.
+func daemonSocketPath(cfg *Config) (string, error) {
+	server, userID := cfg.identity()
+	sum := sha256.Sum256([]byte(server + "\x00" + userID))
+
+	return cachePath("daemon-" + hex.EncodeToString(sum[:6]) + ".sock")
+}
+
+// daemonEvent is a line of the event stream, either marking that the stream is subscribed or holding a changed
+// request.
+type daemonEvent struct {
+	Ready   bool                    `json:"ready,omitempty"`
+	Request *team.PermissionRequest `json:"request,omitempty"`
+}
+
+// daemonAccessRequest is the body of a request submitted via the daemon. The wire format is defined separately from the
+// team types, so renaming their fields does not break clients and daemons of other versions.
+type daemonAccessRequest struct {
+	AccountID     string    `json:"accountId"`
+	AccountName   string    `json:"accountName"`
+	Role          string    `json:"role"`
+	RoleID        string    `json:"roleId"`
+	Duration      int       `json:"duration"`
+	StartTime     time.Time `json:"startTime"`
+	Justification string    `json:"justification"`
+	Ticket        string    `json:"ticket"`
+}
+
+// daemonRequestResult is returned by the daemon once a request is submitted.
+type daemonRequestResult struct {
+	ID string `json:"id"`
+}
+
+// daemonAccessResponse is the body of a response to a request submitted via the daemon.
+type daemonAccessResponse struct {
+	ID      string `json:"id"`
+	Status  string `json:"status"`
+	Comment string `json:"comment"`
+}
+
+// daemonAccount is an account returned by the daemon.
+type daemonAccount struct {
+	ID    string                 `json:"id"`
+	Name  string                 `json:"name"`
+	Roles map[string]*daemonRole `json:"roles"`
+}
+
+type daemonRole struct {
+	ID               string `json:"id"`
+	Name             string `json:"name"`
+	MaxDurNoApproval int    `json:"maxDurationNoApproval"`
+	MaxDurApproval   int    `json:"maxDurationApproval"`
+}
+
+func newDaemonAccounts(accounts map[string]*team.Account) map[string]*daemonAccount {
+	out := make(map[string]*daemonAccount, len(accounts))
+
+	for key, acc := range accounts {
+		roles := make(map[string]*daemonRole, len(acc.Roles))
+
+		for name, role := range acc.Roles {
+			roles[name] = &daemonRole{
+				ID:               role.ID,
+				Name:             role.Name,
+				MaxDurNoApproval: role.MaxDurNoApproval,
+				MaxDurApproval:   role.MaxDurApproval,
+			}
+		}
+
+		out[key] = &daemonAccount{
+			ID:    acc.ID,
+			Name:  acc.Name,
+			Roles: roles,
+		}
+	}
+
+	return out
+}
+
+func (a *daemonAccount) account() *team.Account {
+	roles := make(map[string]*team.Role, len(a.Roles))
+
+	for name, role := range a.Roles {
+		roles[name] = &team.Role{
+			ID:               role.ID,
+			Name:             role.Name,
+			MaxDurNoApproval: role.MaxDurNoApproval,
+			MaxDurApproval:   role.MaxDurApproval,
+		}
+	}
+
+	return &team.Account{
+		ID:    a.ID,
+		Name:  a.Name,
+		Roles: roles,
+	}
+}
+
+// daemonErrorBody is returned by the daemon when an operation fails.
+type daemonErrorBody struct {
+	Error string `json:"error"`
+}
+
+// daemonClient performs TEAM operations via a running daemon.
+type daemonClient struct {
+	http *http.Client
+}
+
+var _ team.API = (*daemonClient)(nil)
+
+type noDaemonKey struct{}
+
+// withoutDaemon returns a context in which commands contact TEAM directly, even if a daemon is running. This is used
+// when traffic must pass through the HTTP client of the context, such as to record it.
+func withoutDaemon(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noDaemonKey{}, true)
+}
+
+// connectDaemon returns a client of the daemon serving the config, or nil if none is running or the context disables
+// it.
+func connectDaemon(ctx context.Context, c *Config) *daemonClient {
+	if c.ServerConfig == nil || c.AuthToken == nil {
+		return nil
+	}
+
+	if disabled, _ := ctx.Value(noDaemonKey{}).(bool); disabled {
+		slog.Debug("Daemon disabled")
+
+		return nil
+	}
+
+	path, err := daemonSocketPath(c)
+	if err != nil {
+		slog.Debug("Could not determine daemon socket", "err", err)
+
+		return nil
+	}
+
+	conn, err := net.DialTimeout("unix", path, time.Second)
+	if err != nil {
+		slog.Debug("No daemon running", "socket", path, "err", err)
+
+		return nil
+	}
+
+	_ = conn.Close()
+
+	slog.Info("Using daemon", "socket", path)
+
+	return &daemonClient{
+		http: &http.Client{
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _ string, _ string) (net.Conn, error) {
+					var dialer net.Dialer
+
+					return dialer.DialContext(ctx, "unix", path)
+				},
+			},
+		},
+	}
+}
+
+// do sends a request to the daemon, decoding the response into result if given.
+func (d *daemonClient) do(ctx context.Context, method string, path string, body any, result any) error {
+	resp, err := d.send(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if result == nil {
+		return nil
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("failed to decode daemon response: %w", err)
+	}
+
+	return nil
+}
+
+// send sends a request to the daemon, returning the response if it succeeded.
+func (d *daemonClient) send(ctx context.Context, method string, path string, body any) (*http.Response, error) {
+	var reqBody io.Reader
+
+	if body != nil {
+		enc, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal daemon request: %w", err)
+		}
+
+		reqBody = bytes.NewReader(enc)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, "http://daemon"+path, reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create daemon request: %w", err)
+	}
+
+	resp, err := d.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to contact daemon: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusOK {
+		return resp, nil
+	}
+
+	defer resp.Body.Close()
+
+	var errBody daemonErrorBody
+
+	if err := json.NewDecoder(resp.Body).Decode(&errBody); err != nil || errBody.Error == "" {
+		return nil, fmt.Errorf("%w: unexpected status %d", ErrDaemon, resp.StatusCode)
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrDaemon, errBody.Error)
+}
+
+func (d *daemonClient) FetchAccounts(ctx context.Context) (map[string]*team.Account, error) {
+	var accounts map[string]*daemonAccount
+
+	if err := d.do(ctx, http.MethodGet, "/v1/accounts", nil, &accounts); err != nil {
+		return nil, err
+	}
+
+	out := make(map[string]*team.Account, len(accounts))
+
+	for key, acc := range accounts {
+		out[key] = acc.account()
+	}
+
+	return out, nil
+}
+
+func (d *daemonClient) GetRequest(ctx context.Context, id string) (*team.PermissionRequest, error) {
+	var req *team.PermissionRequest
+
+	if err := d.do(ctx, http.MethodGet, "/v1/requests/"+url.PathEscape(id), nil, &req); err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+func (d *daemonClient) ListRequests(
+	ctx context.Context,
+	filter team.ListRequestsFilter,
+) ([]*team.PermissionRequest, error) {
+	var requests []*team.PermissionRequest
+
+	path := "/v1/requests?filter=" + url.QueryEscape(string(filter))
+
+	if err := d.do(ctx, http.MethodGet, path, nil, &requests); err != nil {
+		return nil, err
+	}
+
+	return requests, nil
+}
+
+func (d *daemonClient) Request(ctx context.Context, req *team.AccessRequest) (string, error) {
+	body := &daemonAccessRequest{
+		AccountID:     req.AccountID,
+		AccountName:   req.AccountName,
+		Role:          req.Role,
+		RoleID:        req.RoleID,
+		Duration:      req.Duration,
+		StartTime:     req.StartTime,
+		Justification: req.Justification,
+		Ticket:        req.Ticket,
+	}
+
+	var result daemonRequestResult
+
+	if err := d.do(ctx, http.MethodPost, "/v1/requests", body, &result); err != nil {
+		return "", err
+	}
+
+	return result.ID, nil
+}
+
+func (d *daemonClient) Respond(ctx context.Context, resp *team.AccessResponse) error {
+	body := &daemonAccessResponse{
+		ID:      resp.ID,
+		Status:  resp.Status,
+		Comment: resp.Comment,
+	}
+
+	return d.do(ctx, http.MethodPost, "/v1/responses", body, nil)
+}
+
+// WatchRequests streams request changes from the subscription held by the daemon. The stream ends if the daemon loses
+// its subscription, so no change is silently missed.
+func (d *daemonClient) WatchRequests(
+	ctx context.Context,
+	onReady func(ctx context.Context) error,
+	fn func(req *team.PermissionRequest),
+) error {
+	resp, err := d.send(ctx, http.MethodGet, "/v1/events", nil)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	dec := json.NewDecoder(resp.Body)
+
+	for {
+		var event daemonEvent
+
+		if err := dec.Decode(&event); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			return fmt.Errorf("%w: event stream ended: %w", ErrDaemon, err)
+		}
+
+		switch {
+		case event.Ready:
+			if err := onReady(ctx); err != nil {
+				return fmt.Errorf("onReady error: %w", err)
+			}
+		case event.Request != nil:
+			fn(event.Request)
+		default:
+		}
+	}
+}
+
+func (d *daemonClient) WaitForRequest(
+	ctx context.Context,
+	id string,
+	done func(req *team.PermissionRequest) bool,
+) (*team.PermissionRequest, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		result   *team.PermissionRequest
+		finished bool
+	)
+
+	finish := func(req *team.PermissionRequest) {
+		result = req
+
+		if done(req) {
+			finished = true
+
+			cancel()
+		}
+	}
+
+	err := d.WatchRequests(
+		ctx,
+		func(ctx context.Context) error {
+			req, err := d.GetRequest(ctx, id)
+			if err != nil {
+				return err
+			}
+
+			finish(req)
+
+			return nil
+		},
+		func(req *team.PermissionRequest) {
+			if req.ID == id && !finished {
+				finish(req)
+			}
+		},
+	)
+	if finished {
+		return result, nil
+	}
+
+	return nil, fmt.Errorf("failed to wait: %w", err)
+}
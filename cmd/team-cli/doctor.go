@@ -0,0 +1,261 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+
+	"github.com/csnewman/team-cli/internal/gql"
+	"github.com/csnewman/team-cli/team"
+	"github.com/spf13/cobra"
+)
+
+type checkStatus string
+
+const (
+	checkOK   checkStatus = "ok"
+	checkWarn checkStatus = "warn"
+	checkFail checkStatus = "fail"
+)
+
+// checkResult is the outcome of a single doctor check, with a suggested fix when it did not pass.
+type checkResult struct {
+	name   string
+	status checkStatus
+	detail string
+	fix    string
+}
+
+type doctor struct {
+	ctx     context.Context
+	results []*checkResult
+}
+
+func (d *doctor) add(name string, status checkStatus, detail string, fix string) {
+	d.results = append(d.results, &checkResult{
+		name:   name,
+		status: status,
+		detail: detail,
+		fix:    fix,
+	})
+}
+
+func (d *doctor) checkFiles() {
+	path, err := configPath("config.json")
+	if err != nil {
+		d.add("Config file", checkFail, err.Error(), "")
+
+		return
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		d.add("Config file", checkFail, err.Error(), "Run team-cli configure")
+
+		return
+	}
+
+	if problems := validateConfigFile(raw); len(problems) > 0 {
+		d.add("Config file", checkFail, strings.Join(problems, ", "), "Run team-cli config edit")
+	} else {
+		d.add("Config file", checkOK, path, "")
+	}
+
+	// Windows does not use unix permissions
+	if runtime.GOOS == "windows" {
+		return
+	}
+
+	for _, p := range []string{filepath.Dir(path), path} {
+		info, err := os.Stat(p)
+		if err != nil {
+			d.add("Permissions", checkFail, err.Error(), "")
+
+			continue
+		}
+
+		if perm := info.Mode().Perm(); perm&0o077 != 0 {
+			d.add("Permissions", checkFail,
+				fmt.Sprintf("%s is accessible by other users (%v)", p, perm),
+				fmt.Sprintf("Run chmod go-rwx %s", p),
+			)
+
+			continue
+		}
+
+		d.add("Permissions", checkOK, p+" is private", "")
+	}
+}
+
+func (d *doctor) checkServer(cfg *Config) {
+	remote, err := team.ExtractConfig(d.ctx, cfg.ServerConfig.Server)
+	if err != nil {
+		d.add("Server", checkFail, err.Error(), "Check the TEAM server is reachable")
+
+		return
+	}
+
+	keepAccessPortal(remote, cfg.ServerConfig)
+
+	if !reflect.DeepEqual(remote, cfg.ServerConfig) {
+		d.add("Server", checkWarn, "the server config has changed since team-cli was configured",
+			"Run team-cli configure "+cfg.ServerConfig.Server)
+
+		return
+	}
+
+	d.add("Server", checkOK, cfg.ServerConfig.Server, "")
+}
+
+func (d *doctor) checkOAuthDomain(cfg *Config) {
+	addr := "https://" + cfg.ServerConfig.OAuthDomain + "/"
+
+	// Any response shows the domain is reachable
+	if _, err := d.probe(http.MethodGet, addr); err != nil {
+		d.add("OAuth domain", checkFail, err.Error(), "Check the Cognito domain of the TEAM deployment is reachable")
+
+		return
+	}
+
+	d.add("OAuth domain", checkOK, cfg.ServerConfig.OAuthDomain, "")
+}
+
+func (d *doctor) checkRedirect(name string, cfg *Config, redirectURI string, used bool, fix string) {
+	err := team.CheckRedirectURI(d.ctx, cfg.ServerConfig, redirectURI)
+
+	switch {
+	case err == nil:
+		d.add(name, checkOK, redirectURI+" is allowed", "")
+	case errors.Is(err, team.ErrRedirectMismatch) && !used:
+		d.add(name, checkWarn, redirectURI+" is not allowed, but is not used", fix)
+	case errors.Is(err, team.ErrRedirectMismatch):
+		d.add(name, checkFail, redirectURI+" is not allowed", fix)
+	default:
+		d.add(name, checkFail, err.Error(), "")
+	}
+}
+
+func (d *doctor) probe(method string, addr string) (int, error) {
+	req, err := http.NewRequestWithContext(d.ctx, method, addr, nil)
+	if err != nil {
+		return 0, fmt.Errorf("could not create request: %w", err)
+	}
+
+	resp, err := gql.HTTPClient(d.ctx).Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("could not send request: %w", err)
+	}
+
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	return resp.StatusCode, nil
+}
+
+func (d *doctor) checkEndpoints(cfg *Config) {
+	// Unauthenticated requests are rejected, but show the endpoint is reachable
+	status, err := d.probe(http.MethodPost, cfg.ServerConfig.GraphQLEndpoint)
+	if err != nil {
+		d.add("GraphQL endpoint", checkFail, err.Error(), "Check the AppSync endpoint is reachable")
+	} else {
+		d.add("GraphQL endpoint", checkOK, fmt.Sprintf("%s responded with %d", cfg.ServerConfig.GraphQLEndpoint, status), "")
+	}
+
+	if !cfg.tokenValid() {
+		d.add("Realtime endpoint", checkWarn, "skipped as there is no valid token", "")
+
+		return
+	}
+
+	if err := gql.CheckRealtime(d.ctx, cfg.ServerConfig.GraphQLEndpoint, cfg.AuthToken.AccessToken); err != nil {
+		d.add("Realtime endpoint", checkFail, err.Error(), "Check websocket connections to AppSync are not blocked")
+
+		return
+	}
+
+	d.add("Realtime endpoint", checkOK, "connected", "")
+}
+
+func (d *doctor) checkToken(cfg *Config) {
+	switch {
+	case cfg.AuthToken == nil:
+		d.add("Auth token", checkFail, "not authenticated", "Run team-cli configure")
+
+		return
+	case !cfg.tokenValid() && cfg.AuthToken.RefreshToken != "":
+		d.add("Auth token", checkWarn, "expired, it will be refreshed when next used", "")
+
+		return
+	case !cfg.tokenValid():
+		d.add("Auth token", checkFail, "expired", "Run team-cli configure")
+
+		return
+	}
+
+	if _, err := cfg.directClient().ListRequests(d.ctx, team.ListRequestsFilterMyPending); err != nil {
+		d.add("Auth token", checkFail, "rejected: "+err.Error(), "Run team-cli configure")
+
+		return
+	}
+
+	d.add("Auth token", checkOK, "valid until "+fmtDate(cfg.AuthToken.ExpiresAt), "")
+}
+
+func doctorCmdRun(cmd *cobra.Command, _ []string) error {
+	out := cmd.OutOrStdout()
+	d := &doctor{ctx: cmd.Context()}
+
+	d.checkFiles()
+
+	cfg, err := readConfig()
+	if err != nil {
+		d.add("Config", checkFail, err.Error(), "Run team-cli config validate")
+	} else if cfg.ServerConfig == nil || cfg.ServerConfig.Server == "" {
+		d.add("Server", checkFail, "no server configured", "Run team-cli configure [server]")
+	} else {
+		d.checkServer(cfg)
+		d.checkOAuthDomain(cfg)
+		d.checkRedirect("Localhost redirect", cfg, team.LocalhostRedirectURI, !cfg.UseDeviceCode,
+			"Add "+team.LocalhostRedirectURI+" to the allowed callback URLs of the Cognito app client")
+		d.checkRedirect("Device code redirect", cfg, cfg.ServerConfig.DeviceCodeRedirectURI(), cfg.UseDeviceCode,
+			"Deploy device_code.html to the TEAM server and add "+cfg.ServerConfig.DeviceCodeRedirectURI()+
+				" to the allowed callback URLs of the Cognito app client")
+		d.checkEndpoints(cfg)
+		d.checkToken(cfg)
+	}
+
+	failed := 0
+
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Checks:")
+
+	for _, result := range d.results {
+		fmt.Fprintf(out, "  [%s] %s: %s\n", result.status, result.name, result.detail)
+
+		if result.fix != "" {
+			fmt.Fprintf(out, "         fix: %s\n", result.fix)
+		}
+
+		if result.status == checkFail {
+			failed++
+		}
+	}
+
+	fmt.Fprintln(out)
+
+	if failed > 0 {
+		return fmt.Errorf("%w: %d checks failed", ErrInvalidConfig, failed)
+	}
+
+	fmt.Fprintln(out, "All checks passed")
+
+	return nil
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"slices"
+	"strings"
+
+	"github.com/csnewman/team-cli/team"
+	"github.com/spf13/cobra"
+)
+
+// exitError causes team-cli to exit with the given code, as returned by a command run via exec.
+type exitError struct {
+	code int
+}
+
+func (e *exitError) Error() string {
+	return fmt.Sprintf("command exited with code %d", e.code)
+}
+
+// awsCredentialVars are environment variables which take priority over AWS_PROFILE, so are removed from the
+// environment of the command.
+var awsCredentialVars = []string{"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN", "AWS_DEFAULT_PROFILE"}
+
+// sessionEnv returns the environment with AWS_PROFILE set to the profile.
+func sessionEnv(environ []string, profile string) []string {
+	env := slices.DeleteFunc(slices.Clone(environ), func(kv string) bool {
+		name, _, _ := strings.Cut(kv, "=")
+
+		return name == "AWS_PROFILE" || slices.Contains(awsCredentialVars, name)
+	})
+
+	return append(env, "AWS_PROFILE="+profile)
+}
+
+// sessionStarted reports whether the request has reached a state where it will not start later.
+func sessionStarted(req *team.PermissionRequest) bool {
+	switch req.Status {
+	case "pending", "approved", "scheduled":
+		return false
+	default:
+		return true
+	}
+}
+
+// waitForSession waits until the request has started or will not start later, notifying of status changes.
+func waitForSession(ctx context.Context, cfg *Config, n *notifiers, id string) (*team.PermissionRequest, error) {
+	// Requests are always submitted as pending
+	status := "pending"
+
+	return cfg.client().WaitForRequest(ctx, id, func(req *team.PermissionRequest) bool {
+		if req.Status != status {
+			status = req.Status
+
+			n.notify(requestUpdatedEvent(req))
+		}
+
+		return sessionStarted(req)
+	})
+}
+
+func execCmdRun(cmd *cobra.Command, args []string) error {
+	revoke, err := cmd.Flags().GetBool("revoke")
+	if err != nil {
+		return fmt.Errorf("revoke flag: %w", err)
+	}
+
+	awsProfile, err := cmd.Flags().GetString("aws-profile")
+	if err != nil {
+		return fmt.Errorf("aws-profile flag: %w", err)
+	}
+
+	useProcess, err := cmd.Flags().GetBool("credential-process")
+	if err != nil {
+		return fmt.Errorf("credential-process flag: %w", err)
+	}
+
+	// Stdout belongs to the command, so team-cli writes to stderr
+	p := newPrompter(cmd)
+	p.out = cmd.ErrOrStderr()
+	out := p.out
+
+	cfg, err := readConfigReAuth(cmd.Context(), p)
+	if err != nil {
+		return fmt.Errorf("could not read config and authenticate: %w", err)
+	}
+
+	// Check the session can be used before requesting it
+	startURL, region, err := cfg.accessPortal()
+	if err != nil {
+		return err
+	}
+
+	req, err := promptAccessRequest(cmd, p, cfg)
+	if err != nil {
+		return err
+	}
+
+	id, err := cfg.client().Request(cmd.Context(), req)
+	if err != nil {
+		return fmt.Errorf("could not request role: %w", err)
+	}
+
+	fmt.Fprintln(out, "Request submitted")
+	fmt.Fprintf(out, "Request ID: %s\n", id)
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Waiting for the session to start")
+
+	// Notifications are sent alongside the command, and waited for once it exits
+	n := newNotifiers(cmd, cfg)
+	defer n.close()
+
+	waitCtx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+
+	session, err := waitForSession(waitCtx, cfg, n, id)
+
+	interrupted := waitCtx.Err() != nil && cmd.Context().Err() == nil
+
+	stop()
+
+	if err != nil {
+		if interrupted && revoke {
+			if err := cfg.client().Respond(cmd.Context(), &team.AccessResponse{
+				ID:     id,
+				Status: "cancelled",
+			}); err != nil {
+				return fmt.Errorf("could not cancel request: %w", err)
+			}
+
+			fmt.Fprintln(out, "Cancelled")
+		} else if interrupted {
+			fmt.Fprintln(out, "The request is still pending, cancel it with: team-cli cancel "+id)
+		}
+
+		return fmt.Errorf("could not wait for session: %w", err)
+	}
+
+	if session.Status != "in progress" {
+		return fmt.Errorf("%w: request is %s", ErrInvalid, session.Status)
+	}
+
+	if awsProfile == "" {
+		awsProfile = awsProfileName(session.AccountName, session.Role)
+	}
+
+	if _, err := writeSessionProfile(awsProfile, session, startURL, region, useProcess); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Session started, ends %q\n", fmtDate(session.EndTime))
+	fmt.Fprintln(out)
+
+	runErr := runCommand(cmd, args, awsProfile)
+
+	if revoke {
+		fmt.Fprintln(out)
+
+		if err := cfg.client().Respond(cmd.Context(), &team.AccessResponse{
+			ID:     id,
+			Status: "revoked",
+		}); err != nil {
+			return fmt.Errorf("could not revoke session: %w", err)
+		}
+
+		fmt.Fprintln(out, "Session revoked")
+	}
+
+	var exitErr *exitError
+
+	if errors.As(runErr, &exitErr) {
+		// The command is expected to have reported its own failure
+		cmd.SilenceErrors = true
+	}
+
+	return runErr
+}
+
+// runCommand runs the command with the AWS profile, streaming its input and output. Interrupts are left to the
+// command, so the session can still be revoked once it exits.
+func runCommand(cmd *cobra.Command, args []string, awsProfile string) error {
+	child := exec.CommandContext(cmd.Context(), args[0], args[1:]...)
+	child.Env = sessionEnv(os.Environ(), awsProfile)
+	child.Stdin = cmd.InOrStdin()
+	child.Stdout = cmd.OutOrStdout()
+	child.Stderr = cmd.ErrOrStderr()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+
+	defer signal.Stop(signals)
+
+	err := child.Run()
+
+	var exitErr *exec.ExitError
+
+	if errors.As(err, &exitErr) {
+		return &exitError{code: exitErr.ExitCode()}
+	}
+
+	if err != nil {
+		return fmt.Errorf("could not run command: %w", err)
+	}
+
+	return nil
+}
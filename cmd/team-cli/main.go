@@ -9,16 +9,24 @@ import (
 	"log/slog"
 	"net/http"
 	"os"
+	"path/filepath"
 	"runtime/debug"
 	"strings"
 	"time"
 
+	"github.com/csnewman/team-cli/internal/cassette"
+	"github.com/csnewman/team-cli/internal/gql"
+	"github.com/csnewman/team-cli/team"
 	"github.com/spf13/cobra"
 	"golang.org/x/mod/semver"
 )
 
 var Version = "(unknown version)"
 
+// noHeaderAnnotation marks commands whose output is intended to be parsed, so must not be preceded by the header. The
+// value is either "true", or a comma separated list of flags which enable parsable output.
+const noHeaderAnnotation = "team-cli/no-header"
+
 func init() {
 	if info, ok := debug.ReadBuildInfo(); ok {
 		Version = info.Main.Version
@@ -26,6 +34,19 @@ func init() {
 }
 
 func main() {
+	if err := newRootCmd().Execute(); err != nil {
+		var exitErr *exitError
+
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.code)
+		}
+
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
+
+func newRootCmd() *cobra.Command {
 	rootCmd := &cobra.Command{
 		Use:               "team-cli",
 		Short:             "AWS TEAM CLI interface",
@@ -35,6 +56,15 @@ func main() {
 	}
 
 	rootCmd.PersistentFlags().CountP("verbose", "v", "increase verbosity")
+	rootCmd.PersistentFlags().Bool("no-input", false, "never prompt, failing if a value is missing (default when stdin is not a terminal)")
+	rootCmd.PersistentFlags().String("config-dir", "", "directory holding the config and cache (env TEAM_CLI_CONFIG_DIR)")
+	rootCmd.PersistentFlags().String("profile", "", "TEAM server profile to use (env TEAM_CLI_PROFILE)")
+	rootCmd.PersistentFlags().String("record", "", "record all traffic to a cassette in the given directory")
+	rootCmd.PersistentFlags().String("replay", "", "replay traffic from a cassette instead of the network")
+
+	rootCmd.MarkFlagsMutuallyExclusive("record", "replay")
+
+	_ = rootCmd.RegisterFlagCompletionFunc("profile", completeProfiles)
 
 	configureCmd := &cobra.Command{
 		Use:   "configure [server]",
@@ -46,6 +76,7 @@ func main() {
 
 	configureCmd.Flags().BoolP("no-browser", "b", false, "Do not open the browser automatically")
 	configureCmd.Flags().BoolP("device-code", "d", false, "Use the device code flow. Implies --no-browser")
+	configureCmd.Flags().String("access-portal-url", "", "Start URL of the IAM Identity Center access portal, if not discovered")
 
 	listAccountsCmd := &cobra.Command{
 		Use:   "list-accounts",
@@ -55,6 +86,151 @@ func main() {
 		RunE:  listAccountsCmdRun,
 	}
 
+	listAccountsCmd.Flags().Bool("offline", false, "List cached accounts without contacting TEAM, even if stale")
+
+	cacheCmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Manage the account cache",
+		Long: `Manage the cache of accounts and roles you can request.
+
+The cache is reused by request for the duration of account_cache_ttl in the config (default 1h).`,
+	}
+
+	cacheClearCmd := &cobra.Command{
+		Use:   "clear",
+		Short: "Remove the account cache",
+		Args:  cobra.ExactArgs(0),
+		RunE:  cacheClearCmdRun,
+	}
+
+	cacheRefreshCmd := &cobra.Command{
+		Use:   "refresh",
+		Short: "Fetch accounts and update the cache",
+		Args:  cobra.ExactArgs(0),
+		RunE:  cacheRefreshCmdRun,
+	}
+
+	cacheCmd.AddCommand(cacheClearCmd)
+	cacheCmd.AddCommand(cacheRefreshCmd)
+
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "Manage the config",
+		Long:  `Inspect and manage the team-cli config file.`,
+	}
+
+	configValidateCmd := &cobra.Command{
+		Use:   "validate",
+		Short: "Validate the config",
+		Long:  `Report any unknown or invalid fields within the config file.`,
+		Args:  cobra.ExactArgs(0),
+		RunE:  configValidateCmdRun,
+	}
+
+	configViewCmd := &cobra.Command{
+		Use:         "view",
+		Short:       "Print the config",
+		Long:        `Print the config of every profile, with tokens redacted.`,
+		Args:        cobra.ExactArgs(0),
+		Annotations: map[string]string{noHeaderAnnotation: "true"},
+		RunE:        configViewCmdRun,
+	}
+
+	configGetCmd := &cobra.Command{
+		Use:   "get [key]",
+		Short: "Print a config value",
+		Long: `Print a single config value, with tokens redacted.
+
+Keys are relative to the selected profile (e.g. use_device_code or presets.ops.role), unless they start with
+current_profile or profiles.`,
+		Args:              cobra.ExactArgs(1),
+		Annotations:       map[string]string{noHeaderAnnotation: "true"},
+		ValidArgsFunction: completeConfigKeys,
+		RunE:              configGetCmdRun,
+	}
+
+	configSetCmd := &cobra.Command{
+		Use:   "set [key] [value]",
+		Short: "Change a config value",
+		Long: `Change a single config value, without needing to reauthenticate.
+
+Keys are relative to the selected profile (e.g. use_device_code or presets.ops.role), unless they start with
+current_profile or profiles.`,
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: completeConfigKeys,
+		RunE:              configSetCmdRun,
+	}
+
+	configEditCmd := &cobra.Command{
+		Use:   "edit",
+		Short: "Edit the config",
+		Long:  `Open the config in $VISUAL or $EDITOR, validating the changes before saving.`,
+		Args:  cobra.ExactArgs(0),
+		RunE:  configEditCmdRun,
+	}
+
+	configCmd.AddCommand(configValidateCmd)
+	configCmd.AddCommand(configViewCmd)
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configEditCmd)
+
+	credentialsCmd := &cobra.Command{
+		Use:   "credentials [request-id]",
+		Short: "Use an active session with the AWS CLI",
+		Long: `Write an AWS CLI profile for one of your active sessions, signing in via the IAM Identity Center access
+portal.
+
+With --credential-process, the profile instead runs team-cli to fetch credentials, which fails once the session has
+ended. Exclude the request ID to perform interactive selection.`,
+		Args:              cobra.MaximumNArgs(1),
+		Annotations:       map[string]string{noHeaderAnnotation: "export,process"},
+		ValidArgsFunction: completeRequests(team.ListRequestsFilterMyActive),
+		RunE:              credentialsCmdRun,
+	}
+
+	credentialsCmd.Flags().String("aws-profile", "", "Name of the AWS CLI profile (default team-{account}-{role})")
+	credentialsCmd.Flags().Bool("export", false, "Only print the export of AWS_PROFILE, for use with eval")
+	credentialsCmd.Flags().Bool("credential-process", false, "Fetch credentials via team-cli rather than AWS SSO")
+	credentialsCmd.Flags().Bool("process", false, "Print credentials in the credential_process format")
+	credentialsCmd.Flags().String("account", "", "AWS account ID, used with --process")
+	credentialsCmd.Flags().String("role", "", "AWS role name, used with --process")
+	credentialsCmd.MarkFlagsMutuallyExclusive("process", "export")
+	credentialsCmd.MarkFlagsMutuallyExclusive("process", "credential-process")
+	credentialsCmd.MarkFlagsMutuallyExclusive("process", "aws-profile")
+
+	consoleCmd := &cobra.Command{
+		Use:   "console [request-id]",
+		Short: "Open the AWS console for an active session",
+		Long: `Open the AWS console as the role of one of your active sessions, via the IAM Identity Center access portal.
+
+Exclude the request ID to perform interactive selection.`,
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: completeRequests(team.ListRequestsFilterMyActive),
+		RunE:              consoleCmdRun,
+	}
+
+	consoleCmd.Flags().BoolP("no-browser", "b", false, "Only print the link, without opening the browser")
+
+	doctorCmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Diagnose setup problems",
+		Long: `Check the config, TEAM server, Cognito app client and AppSync endpoints are set up correctly, suggesting
+fixes for any problems found.`,
+		Args: cobra.ExactArgs(0),
+		RunE: doctorCmdRun,
+	}
+
+	daemonCmd := &cobra.Command{
+		Use:   "daemon",
+		Short: "Run a daemon serving other commands",
+		Long: `Run in the foreground, keeping an authenticated session, the accounts you can request and subscriptions to
+request changes. Other commands use the daemon while it is running, over a Unix socket in the cache directory, and
+fall back to contacting TEAM directly otherwise.`,
+		Args: cobra.ExactArgs(0),
+		RunE: daemonCmdRun,
+	}
+
 	requestCmd := &cobra.Command{
 		Use:   "request",
 		Short: "Request elevated access",
@@ -65,34 +241,146 @@ Exclude flags to perform interactive selection.`,
 		RunE: requestCmdRun,
 	}
 
-	requestCmd.Flags().StringP("account", "a", "", "AWS account ID or name")
-	requestCmd.Flags().StringP("role", "r", "", "AWS role ID or name")
-	requestCmd.Flags().StringP("start", "s", "", "Start date and time")
-	requestCmd.Flags().IntP("duration", "d", 0, "Duration of elevation")
-	requestCmd.Flags().StringP("ticket", "t", "", "Ticket ID")
-	requestCmd.Flags().StringP("reason", "j", "", "Justification reason")
-	requestCmd.Flags().BoolP("confirm", "y", false, "Automatically confirm")
+	addRequestFlags(requestCmd)
+	requestCmd.Flags().StringP("from-file", "f", "", "Submit every request listed in a YAML or JSON file")
+	requestCmd.Flags().BoolP("wait", "w", false, "Wait for the session to start, sending notifications as the request changes")
+	requestCmd.MarkFlagsMutuallyExclusive("from-file", "wait")
+
+	execCmd := &cobra.Command{
+		Use:   "exec [flags] -- command [args...]",
+		Short: "Run a command with elevated access",
+		Long: `Request temporary elevated access to a AWS account, wait for the session to start and run a command with
+AWS_PROFILE set to a profile for the session.
+
+The command's output is written to stdout, with all other output written to stderr. Exclude flags to perform
+interactive selection.`,
+		Args:        cobra.MinimumNArgs(1),
+		Annotations: map[string]string{noHeaderAnnotation: "true"},
+		RunE:        execCmdRun,
+	}
+
+	// Flags of the command must not be parsed
+	execCmd.Flags().SetInterspersed(false)
+	addRequestFlags(execCmd)
+	execCmd.Flags().Bool("revoke", false, "Revoke the session once the command exits, or cancel the request if interrupted")
+	execCmd.Flags().String("aws-profile", "", "Name of the AWS CLI profile (default team-{account}-{role})")
+	execCmd.Flags().Bool("credential-process", false, "Fetch credentials via team-cli rather than AWS SSO")
 
 	approveCmd := &cobra.Command{
-		Use:   "approve",
+		Use:   "approve [request-id]",
 		Short: "Approve elevated access",
 		Long: `Approve temporary elevated access to a AWS account.
 
-Exclude flags to perform interactive selection.`,
-		Args: cobra.ExactArgs(0),
-		RunE: approveCmdRun,
+Exclude the request ID to perform interactive selection. With --watch, the pending requests are kept up to date as
+they are created or handled by other approvers, until interrupted.`,
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: completeRequests(team.ListRequestsFilterRequiresMyApproval),
+		RunE:              approveCmdRun,
+	}
+
+	approveCmd.Flags().Bool("approve", false, "Approve the request")
+	approveCmd.Flags().Bool("reject", false, "Reject the request")
+	approveCmd.Flags().StringP("comment", "c", "", "Response comment")
+	approveCmd.Flags().BoolP("confirm", "y", false, "Automatically confirm")
+	approveCmd.Flags().BoolP("watch", "w", false, "Watch for requests, responding to them as they arrive")
+	approveCmd.MarkFlagsMutuallyExclusive("approve", "reject")
+	approveCmd.MarkFlagsMutuallyExclusive("watch", "approve")
+	approveCmd.MarkFlagsMutuallyExclusive("watch", "reject")
+	approveCmd.MarkFlagsMutuallyExclusive("watch", "comment")
+
+	cancelCmd := &cobra.Command{
+		Use:   "cancel [request-id]",
+		Short: "Cancel a pending request",
+		Long: `Cancel one of your own requests which is still pending approval.
+
+Exclude the request ID to perform interactive selection.`,
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: completeRequests(team.ListRequestsFilterMyPending),
+		RunE:              cancelCmdRun,
+	}
+
+	cancelCmd.Flags().BoolP("confirm", "y", false, "Automatically confirm")
+
+	presetCmd := &cobra.Command{
+		Use:   "preset",
+		Short: "Manage request presets",
+		Long:  `Manage named presets of default values for the request command.`,
+	}
+
+	presetSaveCmd := &cobra.Command{
+		Use:   "save [name]",
+		Short: "Save a request preset",
+		Long: `Save a named preset of default values for the request command.
+
+Use with: team-cli request --preset [name]`,
+		Args: cobra.ExactArgs(1),
+		RunE: presetSaveCmdRun,
+	}
+
+	presetSaveCmd.Flags().StringP("account", "a", "", "AWS account ID or name")
+	presetSaveCmd.Flags().StringP("role", "r", "", "AWS role ID or name")
+	presetSaveCmd.Flags().StringP("start", "s", "", "Start time, absolute or relative (e.g. "+startTimeExamples+")")
+	presetSaveCmd.Flags().String("timezone", "", "Timezone of the start time")
+	presetSaveCmd.Flags().IntP("duration", "d", 0, "Duration of elevation")
+	presetSaveCmd.Flags().StringP("ticket", "t", "", "Ticket ID")
+	presetSaveCmd.Flags().String("ticket-prefix", "", "Prefix added to ticket IDs (e.g. OPS-)")
+	presetSaveCmd.Flags().StringP("reason", "j", "", "Justification reason")
+
+	_ = presetSaveCmd.RegisterFlagCompletionFunc("account", completeAccounts)
+	_ = presetSaveCmd.RegisterFlagCompletionFunc("role", completeRoles)
+
+	presetListCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List request presets",
+		Args:  cobra.ExactArgs(0),
+		RunE:  presetListCmdRun,
+	}
+
+	presetDeleteCmd := &cobra.Command{
+		Use:               "delete [name]",
+		Short:             "Delete a request preset",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completePresets,
+		RunE:              presetDeleteCmdRun,
 	}
 
+	presetCmd.AddCommand(presetSaveCmd)
+	presetCmd.AddCommand(presetListCmd)
+	presetCmd.AddCommand(presetDeleteCmd)
+
 	rootCmd.AddCommand(configureCmd)
 	rootCmd.AddCommand(listAccountsCmd)
+	rootCmd.AddCommand(cacheCmd)
+	rootCmd.AddCommand(configCmd)
+	rootCmd.AddCommand(doctorCmd)
+	rootCmd.AddCommand(daemonCmd)
+	rootCmd.AddCommand(credentialsCmd)
+	rootCmd.AddCommand(consoleCmd)
 	rootCmd.AddCommand(requestCmd)
+	rootCmd.AddCommand(execCmd)
 	rootCmd.AddCommand(approveCmd)
+	rootCmd.AddCommand(cancelCmd)
+	rootCmd.AddCommand(presetCmd)
 	rootCmd.SilenceUsage = true
 
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err)
-		os.Exit(1)
-	}
+	return rootCmd
+}
+
+// addRequestFlags adds the flags describing a request, as used by request and exec.
+func addRequestFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP("account", "a", "", "AWS account ID or name")
+	cmd.Flags().StringP("role", "r", "", "AWS role ID or name")
+	cmd.Flags().StringP("start", "s", "", "Start time, absolute or relative (e.g. "+startTimeExamples+")")
+	cmd.Flags().String("timezone", "", "Timezone of the start time (default local)")
+	cmd.Flags().IntP("duration", "d", 0, "Duration of elevation")
+	cmd.Flags().StringP("ticket", "t", "", "Ticket ID")
+	cmd.Flags().StringP("reason", "j", "", "Justification reason")
+	cmd.Flags().BoolP("confirm", "y", false, "Automatically confirm")
+	cmd.Flags().StringP("preset", "p", "", "Saved preset to take default values from")
+
+	_ = cmd.RegisterFlagCompletionFunc("account", completeAccounts)
+	_ = cmd.RegisterFlagCompletionFunc("role", completeRoles)
+	_ = cmd.RegisterFlagCompletionFunc("preset", completePresets)
 }
 
 func rootCmdPersistentPre(cmd *cobra.Command, _ []string) error {
@@ -109,13 +397,35 @@ func rootCmdPersistentPre(cmd *cobra.Command, _ []string) error {
 		level = slog.LevelInfo
 	}
 
-	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+	slog.SetDefault(slog.New(slog.NewTextHandler(cmd.ErrOrStderr(), &slog.HandlerOptions{
 		AddSource:   false,
 		Level:       level,
 		ReplaceAttr: nil,
 	})))
 
-	fmt.Println("Team-CLI - " + Version)
+	configDirFlag, err = cmd.Flags().GetString("config-dir")
+	if err != nil {
+		return fmt.Errorf("could not get config-dir flag: %w", err)
+	}
+
+	profileFlag, err = cmd.Flags().GetString("profile")
+	if err != nil {
+		return fmt.Errorf("could not get profile flag: %w", err)
+	}
+
+	if err := setupCassette(cmd); err != nil {
+		return err
+	}
+
+	// Shell completions and other parsed output must not be preceded by the header
+	if cmd.Name() == cobra.ShellCompRequestCmd || cmd.Name() == cobra.ShellCompNoDescRequestCmd ||
+		(cmd.HasParent() && cmd.Parent().Name() == "completion") || parsableOutput(cmd) {
+		return nil
+	}
+
+	out := cmd.OutOrStdout()
+
+	fmt.Fprintln(out, "Team-CLI - "+Version)
 
 	if strings.HasPrefix(Version, "v") {
 		latestVersion, err := getLatestVersion(cmd.Context())
@@ -124,15 +434,76 @@ func rootCmdPersistentPre(cmd *cobra.Command, _ []string) error {
 		} else if !strings.HasPrefix(latestVersion, "v") {
 			slog.Warn("Failed to check for updates", "version", latestVersion, "err", "unknown format")
 		} else if semver.Compare(latestVersion, Version) > 0 {
-			fmt.Println()
-			fmt.Println("---- Update available! ----")
-			fmt.Println("A new release is available. Please install with: go install github.com/csnewman/team-cli/cmd/team-cli@" + latestVersion)
+			fmt.Fprintln(out)
+			fmt.Fprintln(out, "---- Update available! ----")
+			fmt.Fprintln(out, "A new release is available. Please install with: go install github.com/csnewman/team-cli/cmd/team-cli@"+latestVersion)
 		}
 	}
 
 	return nil
 }
 
+// parsableOutput reports whether the output of the command is intended to be parsed, as set by noHeaderAnnotation.
+func parsableOutput(cmd *cobra.Command) bool {
+	value := cmd.Annotations[noHeaderAnnotation]
+
+	if value == "true" {
+		return true
+	}
+
+	for flag := range strings.SplitSeq(value, ",") {
+		if enabled, err := cmd.Flags().GetBool(flag); err == nil && enabled {
+			return true
+		}
+	}
+
+	return false
+}
+
+// setupCassette routes all TEAM traffic via a recorder or replayer when requested.
+func setupCassette(cmd *cobra.Command) error {
+	recordDir, err := cmd.Flags().GetString("record")
+	if err != nil {
+		return fmt.Errorf("could not get record flag: %w", err)
+	}
+
+	replayPath, err := cmd.Flags().GetString("replay")
+	if err != nil {
+		return fmt.Errorf("could not get replay flag: %w", err)
+	}
+
+	ctx := cmd.Context()
+
+	switch {
+	case recordDir != "":
+		path := filepath.Join(recordDir, "team-cli-"+time.Now().UTC().Format("20060102T150405Z")+".json")
+
+		rec := cassette.NewRecorder(path, gql.HTTPClient(ctx))
+
+		slog.Info("Recording traffic", "path", path)
+
+		ctx = gql.WithHTTPClient(ctx, rec.Client())
+		ctx = gql.WithDialer(ctx, rec.Dial)
+	case replayPath != "":
+		c, err := cassette.Load(replayPath)
+		if err != nil {
+			return err
+		}
+
+		rep := cassette.NewReplayer(c)
+
+		ctx = gql.WithHTTPClient(ctx, rep.Client())
+		ctx = gql.WithDialer(ctx, rep.Dial)
+	default:
+		return nil
+	}
+
+	// Traffic sent via a daemon would bypass the cassette
+	cmd.SetContext(withoutDaemon(ctx))
+
+	return nil
+}
+
 const latestURL = "https://api.github.com/repos/csnewman/team-cli/releases/latest"
 
 var ErrUnexpected = errors.New("unexpected error")
@@ -0,0 +1,1986 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/csnewman/team-cli/internal/teamtest"
+	"github.com/csnewman/team-cli/team"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+var randomParamRegex = regexp.MustCompile(`(state|code_challenge)=[\w-]+`)
+
+func TestMain(m *testing.M) {
+	// exec tests run the test binary as their command
+	if os.Getenv("TEAM_CLI_TEST_EXEC") != "" {
+		fmt.Println("AWS_PROFILE=" + os.Getenv("AWS_PROFILE"))
+		fmt.Println("AWS_ACCESS_KEY_ID=" + os.Getenv("AWS_ACCESS_KEY_ID"))
+		os.Exit(3)
+	}
+
+	// notification tests run the test binary as their command, appending each event to a file
+	if path := os.Getenv("TEAM_CLI_TEST_NOTIFY"); path != "" {
+		event, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			panic(err)
+		}
+
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+		if err != nil {
+			panic(err)
+		}
+
+		if _, err := f.Write(append(event, '\n')); err != nil {
+			panic(err)
+		}
+
+		if err := f.Close(); err != nil {
+			panic(err)
+		}
+
+		os.Exit(0)
+	}
+
+	// token store tests run the test binary as secret-tool or security, holding each secret in a file named after its
+	// account
+	if dir := os.Getenv("TEAM_CLI_TEST_SECRETS"); dir != "" {
+		if filepath.Base(os.Args[0]) == "security" {
+			os.Exit(fakeSecurity(dir, os.Args[1:]))
+		}
+
+		os.Exit(fakeSecretTool(dir, os.Args[1:]))
+	}
+
+	time.Local = time.UTC
+	Version = "(test)"
+	timeNow = func() time.Time {
+		return time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	}
+
+	os.Exit(m.Run())
+}
+
+// fakeSecretTool implements the store and lookup commands of secret-tool.
+func fakeSecretTool(dir string, args []string) int {
+	sum := sha1.Sum([]byte(args[len(args)-1]))
+	path := filepath.Join(dir, hex.EncodeToString(sum[:]))
+
+	switch args[0] {
+	case "store":
+		secret, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			panic(err)
+		}
+
+		if err := os.WriteFile(path, secret, 0o600); err != nil {
+			panic(err)
+		}
+	case "lookup":
+		secret, err := os.ReadFile(path)
+		if err != nil {
+			return 1
+		}
+
+		fmt.Print(string(secret))
+	default:
+		return 2
+	}
+
+	return 0
+}
+
+// fakeSecurity implements the add-generic-password and find-generic-password commands of the macOS security tool.
+func fakeSecurity(dir string, args []string) int {
+	flags := make(map[string]string)
+
+	for i := 1; i < len(args); i++ {
+		if args[i] == "-U" {
+			continue
+		}
+
+		if args[i] == "-w" && i == len(args)-1 {
+			flags["-w"] = ""
+
+			continue
+		}
+
+		flags[args[i]] = args[i+1]
+		i++
+	}
+
+	sum := sha1.Sum([]byte(flags["-a"]))
+	path := filepath.Join(dir, hex.EncodeToString(sum[:]))
+
+	switch args[0] {
+	case "add-generic-password":
+		if err := os.WriteFile(path, []byte(flags["-w"]), 0o600); err != nil {
+			panic(err)
+		}
+	case "find-generic-password":
+		secret, err := os.ReadFile(path)
+		if err != nil {
+			// The item could not be found
+			return 44
+		}
+
+		fmt.Println(string(secret))
+	default:
+		return 2
+	}
+
+	return 0
+}
+
+type cliTest struct {
+	t    *testing.T
+	srv  *teamtest.Server
+	user *teamtest.User
+}
+
+// newCLITest creates a fake TEAM server and an empty home directory. As the home directory is set via the
+// environment, CLI tests cannot run in parallel.
+func newCLITest(t *testing.T) *cliTest {
+	t.Helper()
+
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("XDG_CACHE_HOME", "")
+	t.Setenv("TEAM_CLI_CONFIG_DIR", "")
+	t.Setenv("TEAM_CLI_PROFILE", "")
+	// Tokens must never be written to the store of the user running the tests
+	t.Setenv("TEAM_CLI_TOKEN_STORE", "file")
+
+	configDirFlag = ""
+	profileFlag = ""
+
+	srv := teamtest.New(t)
+	srv.Now = func() time.Time {
+		return time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	}
+	srv.Approvers = []string{"carol@example.com"}
+
+	user := srv.AddUser(&teamtest.User{
+		ID:       "user-1",
+		Username: "alice",
+		Email:    "alice@example.com",
+		GroupIDs: []string{"group-1"},
+		Policy: []*teamtest.Entitlement{
+			{
+				Accounts: []*teamtest.Account{
+					{Name: "prod", ID: "111111111111"},
+					{Name: "dev", ID: "222222222222"},
+				},
+				Permissions: []*teamtest.Permission{{Name: "ReadOnlyAccess", ID: "perm-ro"}},
+				Duration:    2,
+			},
+			{
+				Accounts: []*teamtest.Account{{Name: "prod", ID: "111111111111"}},
+				Permissions: []*teamtest.Permission{
+					{Name: "ReadOnlyAccess", ID: "perm-ro"},
+					{Name: "AdministratorAccess", ID: "perm-admin"},
+				},
+				ApprovalRequired: true,
+				Duration:         8,
+			},
+		},
+	})
+
+	return &cliTest{
+		t:    t,
+		srv:  srv,
+		user: user,
+	}
+}
+
+// login writes a config containing a valid token for the user, as if configure had been run.
+func (c *cliTest) login(u *teamtest.User) {
+	c.t.Helper()
+
+	require.NoError(c.t, writeConfig(&Config{
+		ServerConfig: c.srv.RemoteConfig(),
+		AuthToken:    c.srv.Token(u),
+	}))
+}
+
+// run executes the CLI with the given scripted input, returning the normalised output.
+func (c *cliTest) run(input string, args ...string) (string, error) {
+	c.t.Helper()
+
+	// Notifications may be written in the background
+	var out syncBuffer
+
+	cmd := newRootCmd()
+	cmd.SetArgs(args)
+	cmd.SetIn(strings.NewReader(input))
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+
+	err := cmd.ExecuteContext(c.srv.Context(context.Background()))
+
+	return c.normalise(out.String()), err
+}
+
+// normalise replaces details of the fake server which vary between runs.
+func (c *cliTest) normalise(output string) string {
+	output = strings.ReplaceAll(output, url.QueryEscape(c.srv.URL), url.QueryEscape("https://team.example.com"))
+	output = strings.ReplaceAll(output, c.srv.URL, "https://team.example.com")
+	output = strings.ReplaceAll(output, strings.TrimPrefix(c.srv.URL, "https://"), "auth.example.com")
+
+	return randomParamRegex.ReplaceAllString(output, "$1=RANDOM")
+}
+
+// syncBuffer is a buffer which can be read while a command writes to it.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.String()
+}
+
+// start executes the CLI in the background with input from the reader, for commands which run until their input ends.
+func (c *cliTest) start(in io.Reader, args ...string) (*syncBuffer, <-chan error) {
+	c.t.Helper()
+
+	return c.startContext(context.Background(), in, args...)
+}
+
+// startContext executes the CLI in the background, for commands which run until the context is cancelled.
+func (c *cliTest) startContext(ctx context.Context, in io.Reader, args ...string) (*syncBuffer, <-chan error) {
+	c.t.Helper()
+
+	out := &syncBuffer{}
+	done := make(chan error, 1)
+
+	cmd := newRootCmd()
+	cmd.SetArgs(args)
+	cmd.SetIn(in)
+	cmd.SetOut(out)
+	cmd.SetErr(out)
+
+	go func() {
+		done <- cmd.ExecuteContext(c.srv.Context(ctx))
+	}()
+
+	return out, done
+}
+
+// waitFor waits until the output of a command started in the background contains the text.
+func (c *cliTest) waitFor(out *syncBuffer, text string) {
+	c.t.Helper()
+
+	require.Eventually(c.t, func() bool {
+		return strings.Contains(out.String(), text)
+	}, 5*time.Second, 5*time.Millisecond, "waiting for %q", text)
+}
+
+func (c *cliTest) golden(name string, output string) {
+	c.t.Helper()
+
+	path := filepath.Join("testdata", name+".golden")
+
+	if *update {
+		require.NoError(c.t, os.WriteFile(path, []byte(output), 0644))
+
+		return
+	}
+
+	expected, err := os.ReadFile(path)
+	require.NoError(c.t, err)
+	require.Equal(c.t, string(expected), output)
+}
+
+func TestConfigureDeviceCode(t *testing.T) {
+	c := newCLITest(t)
+
+	code := c.srv.IssueCode(c.user, c.srv.URL+"/device_code/")
+
+	out, err := c.run(code+"\n", "configure", c.srv.URL, "--device-code")
+	require.NoError(t, err)
+	c.golden("configure_device_code", out)
+
+	cfg, err := readConfig()
+	require.NoError(t, err)
+	require.Equal(t, c.srv.RemoteConfig(), cfg.ServerConfig)
+	require.True(t, cfg.UseDeviceCode)
+	require.NotEmpty(t, cfg.AuthToken.AccessToken)
+
+	// The access portal can be given when it is not discovered, and is kept when reconfiguring
+	for _, args := range [][]string{{"--access-portal-url", "https://d-1234567890.awsapps.com/start"}, nil} {
+		code = c.srv.IssueCode(c.user, c.srv.URL+"/device_code/")
+
+		_, err = c.run(code+"\n", append([]string{"configure", c.srv.URL, "--device-code"}, args...)...)
+		require.NoError(t, err)
+
+		cfg, err = readConfig()
+		require.NoError(t, err)
+		require.Equal(t, "https://d-1234567890.awsapps.com/start", cfg.ServerConfig.AccessPortalURL)
+	}
+
+	// A configured access portal takes priority over a discovered one
+	c.srv.AccessPortalURL = "https://d-2222222222.awsapps.com/start"
+
+	reconfigure := func() string {
+		code := c.srv.IssueCode(c.user, c.srv.URL+"/device_code/")
+
+		_, err := c.run(code+"\n", "configure", c.srv.URL, "--device-code")
+		require.NoError(t, err)
+
+		cfg, err := readConfig()
+		require.NoError(t, err)
+
+		return cfg.ServerConfig.AccessPortalURL
+	}
+
+	require.Equal(t, "https://d-1234567890.awsapps.com/start", reconfigure())
+
+	// Discovered access portals are updated when they change, and kept when no longer discovered
+	_, err = c.run("", "config", "set", "server_config.access_portal_url", "")
+	require.NoError(t, err)
+
+	require.Equal(t, "https://d-2222222222.awsapps.com/start", reconfigure())
+
+	c.srv.AccessPortalURL = "https://d-3333333333.awsapps.com/start"
+	require.Equal(t, "https://d-3333333333.awsapps.com/start", reconfigure())
+
+	c.srv.AccessPortalURL = ""
+	require.Equal(t, "https://d-3333333333.awsapps.com/start", reconfigure())
+}
+
+func TestListAccounts(t *testing.T) {
+	c := newCLITest(t)
+	c.login(c.user)
+
+	out, err := c.run("", "list-accounts")
+	require.NoError(t, err)
+	c.golden("list_accounts", out)
+
+	cache, ok, err := getAccountsCache()
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Len(t, cache.Accounts, 2)
+}
+
+func TestRequestInteractive(t *testing.T) {
+	c := newCLITest(t)
+	c.login(c.user)
+
+	out, err := c.run(
+		strings.Join([]string{"2", "2", "", "9", "3", "bad ticket", "INC-1", "Investigating incident", "y"}, "\n")+"\n",
+		"request",
+	)
+	require.NoError(t, err)
+	c.golden("request_interactive", out)
+
+	reqs := c.srv.Requests()
+	require.Len(t, reqs, 1)
+	require.Equal(t, "111111111111", reqs[0].AccountID)
+	require.Equal(t, "perm-ro", reqs[0].RoleID)
+	require.Equal(t, "3", reqs[0].Duration)
+	require.Equal(t, "INC-1", reqs[0].TicketNo)
+	require.Equal(t, "Investigating incident", reqs[0].Justification)
+}
+
+func TestRequestFlags(t *testing.T) {
+	c := newCLITest(t)
+	c.login(c.user)
+
+	args := []string{
+		"request", "--account", "PROD", "--role", "administratoraccess", "--start", "2030-01-02 05:00:00",
+		"--duration", "4", "--ticket", "INC-2", "--reason", "Deploy", "-y",
+	}
+
+	out, err := c.run("", args...)
+	require.NoError(t, err)
+	c.golden("request_flags", out)
+
+	out, err = c.run("", args...)
+	require.NoError(t, err)
+	c.golden("request_flags_cached", out)
+
+	reqs := c.srv.Requests()
+	require.Len(t, reqs, 2)
+	require.Equal(t, "perm-admin", reqs[1].RoleID)
+	require.Equal(t, "2030-01-02T05:00:00Z", reqs[1].StartTime)
+}
+
+func TestRequestRelativeStart(t *testing.T) {
+	c := newCLITest(t)
+	c.login(c.user)
+
+	out, err := c.run(
+		"",
+		"request", "--account", "dev", "--role", "ReadOnlyAccess", "--duration", "1", "--ticket", "INC-4",
+		"--reason", "Check", "--start", "tomorrow 09:30", "--timezone", "Europe/Paris", "-y",
+	)
+	require.NoError(t, err)
+	c.golden("request_relative_start", out)
+
+	reqs := c.srv.Requests()
+	require.Len(t, reqs, 1)
+	require.Equal(t, "2030-01-03T08:30:00Z", reqs[0].StartTime)
+
+	_, err = c.run(
+		"",
+		"request", "--account", "dev", "--role", "ReadOnlyAccess", "--duration", "1", "--ticket", "INC-4",
+		"--reason", "Check", "--start", "2030-01-01 09:00:00", "-y",
+	)
+	require.ErrorIs(t, err, ErrInvalid)
+	require.Len(t, c.srv.Requests(), 1)
+}
+
+func TestPreset(t *testing.T) {
+	c := newCLITest(t)
+	c.login(c.user)
+
+	out, err := c.run(
+		"",
+		"preset", "save", "prod-admin", "--account", "prod", "--role", "AdministratorAccess", "--duration", "4",
+		"--ticket-prefix", "OPS-",
+	)
+	require.NoError(t, err)
+
+	out2, err := c.run("", "preset", "list")
+	require.NoError(t, err)
+	c.golden("preset_save", out+out2)
+
+	out, err = c.run("", "request", "--preset", "missing")
+	require.ErrorIs(t, err, ErrInvalid)
+	require.Contains(t, out, `preset "missing" not found`)
+
+	out, err = c.run(
+		strings.Join([]string{"", "42", "y"}, "\n")+"\n",
+		"request", "--preset", "prod-admin", "--duration", "2", "--reason", "Deploy",
+	)
+	require.NoError(t, err)
+	c.golden("request_preset", out)
+
+	reqs := c.srv.Requests()
+	require.Len(t, reqs, 1)
+	require.Equal(t, "perm-admin", reqs[0].RoleID)
+	require.Equal(t, "2", reqs[0].Duration)
+	require.Equal(t, "OPS-42", reqs[0].TicketNo)
+
+	_, err = c.run("", "preset", "delete", "prod-admin")
+	require.NoError(t, err)
+
+	cfg, err := readConfig()
+	require.NoError(t, err)
+	require.Empty(t, cfg.Presets)
+}
+
+func TestRequestFromFile(t *testing.T) {
+	c := newCLITest(t)
+	c.login(c.user)
+
+	dir := t.TempDir()
+
+	invalid := filepath.Join(dir, "invalid.json")
+	require.NoError(t, os.WriteFile(invalid, []byte(`[
+		{"account": "prod", "role": "Unknown", "duration": 1, "ticket": "INC-5", "justification": "Incident"},
+		{"account": "dev", "role": "ReadOnlyAccess", "duration": 9, "ticket": "INC-5", "justification": "Incident"}
+	]`), 0600))
+
+	out, err := c.run("", "request", "--from-file", invalid)
+	require.ErrorIs(t, err, ErrInvalid)
+	c.golden("request_from_file_invalid", out)
+	require.Empty(t, c.srv.Requests())
+
+	plan := filepath.Join(dir, "plan.yaml")
+	require.NoError(t, os.WriteFile(plan, []byte(`
+- account: prod
+  role: AdministratorAccess
+  duration: 4
+  ticket: INC-5
+  justification: Incident
+- account: "222222222222"
+  role: readonlyaccess
+  start_time: "+1h"
+  duration: 1
+  ticket: INC-5
+  justification: Incident
+`), 0600))
+
+	out, err = c.run("y\n", "request", "--from-file", plan)
+	require.NoError(t, err)
+	c.golden("request_from_file", out)
+
+	reqs := c.srv.Requests()
+	require.Len(t, reqs, 2)
+	require.Equal(t, "perm-admin", reqs[0].RoleID)
+	require.Equal(t, "222222222222", reqs[1].AccountID)
+	require.Equal(t, "2030-01-02T04:04:00Z", reqs[1].StartTime)
+
+	_, err = c.run("", "request", "--from-file", plan, "--account", "prod")
+	require.ErrorIs(t, err, ErrInvalid)
+}
+
+func TestRequestRejected(t *testing.T) {
+	c := newCLITest(t)
+	c.login(c.user)
+
+	out, err := c.run(
+		"n\n",
+		"request", "--account", "dev", "--role", "ReadOnlyAccess", "--duration", "1", "--ticket", "INC-3",
+		"--reason", "Check", "--start", "now",
+	)
+	require.ErrorIs(t, err, ErrInvalid)
+	c.golden("request_rejected", out)
+	require.Empty(t, c.srv.Requests())
+}
+
+func TestApprove(t *testing.T) {
+	c := newCLITest(t)
+
+	approver := c.srv.AddUser(&teamtest.User{
+		ID:       "user-3",
+		Username: "carol",
+		Email:    "carol@example.com",
+	})
+	c.login(approver)
+
+	req := c.srv.AddRequest(&teamtest.Request{
+		Email:         c.user.Email,
+		AccountID:     "111111111111",
+		AccountName:   "prod",
+		Role:          "AdministratorAccess",
+		RoleID:        "perm-admin",
+		StartTime:     "2030-01-02T04:00:00Z",
+		Duration:      "4",
+		Status:        "pending",
+		Approvers:     []string{approver.Email},
+		TicketNo:      "INC-2",
+		Justification: "Deploy",
+	})
+
+	out, err := c.run(strings.Join([]string{"1", "1", "Looks good", "y"}, "\n")+"\n", "approve")
+	require.NoError(t, err)
+	c.golden("approve", out)
+
+	reqs := c.srv.Requests()
+	require.Equal(t, req.ID, reqs[0].ID)
+	require.Equal(t, "approved", reqs[0].Status)
+	require.Equal(t, "Looks good", reqs[0].Comment)
+
+	out, err = c.run("", "approve")
+	require.NoError(t, err)
+	c.golden("approve_none", out)
+}
+
+func TestRecordReplay(t *testing.T) {
+	c := newCLITest(t)
+	c.login(c.user)
+
+	dir := t.TempDir()
+
+	recorded, err := c.run("", "list-accounts", "--record", dir)
+	require.NoError(t, err)
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	raw, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	require.Contains(t, string(raw), "onPublishPolicy")
+
+	cfg, err := readConfig()
+	require.NoError(t, err)
+	require.NotContains(t, string(raw), cfg.AuthToken.AccessToken)
+
+	c.srv.Close()
+
+	replayed, err := c.run("", "list-accounts", "--replay", files[0])
+	require.NoError(t, err)
+	require.Equal(t, recorded, replayed)
+}
+
+func TestRecordReplayDaemon(t *testing.T) {
+	c := newCLITest(t)
+	c.login(c.user)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	daemonOut, done := c.startContext(ctx, nil, "daemon")
+
+	c.waitFor(daemonOut, "Daemon listening on ")
+
+	// Traffic is recorded from TEAM directly, rather than sent via the daemon
+	dir := t.TempDir()
+
+	recorded, err := c.run("", "list-accounts", "--record", dir)
+	require.NoError(t, err)
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	raw, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	require.Contains(t, string(raw), "onPublishPolicy")
+
+	// Replayed commands do not reach TEAM via the daemon
+	c.srv.PublishPolicy(&teamtest.User{
+		ID:       c.user.ID,
+		Username: c.user.Username,
+		Policy: []*teamtest.Entitlement{{
+			Accounts:    []*teamtest.Account{{Name: "sandbox", ID: "333333333333"}},
+			Permissions: []*teamtest.Permission{{Name: "AdministratorAccess", ID: "perm-admin"}},
+			Duration:    4,
+		}},
+	})
+
+	replayed, err := c.run("", "list-accounts", "--replay", files[0])
+	require.NoError(t, err)
+	require.Equal(t, recorded, replayed)
+
+	cancel()
+	require.NoError(t, <-done)
+}
+
+func TestCompletion(t *testing.T) {
+	c := newCLITest(t)
+	c.login(c.user)
+
+	_, err := c.run("", "list-accounts")
+	require.NoError(t, err)
+
+	req := c.srv.AddRequest(&teamtest.Request{
+		Email:       "bob@example.com",
+		AccountID:   "111111111111",
+		AccountName: "prod",
+		Role:        "AdministratorAccess",
+		RoleID:      "perm-admin",
+		StartTime:   "2030-01-02T04:00:00Z",
+		Duration:    "4",
+		Status:      "pending",
+		Approvers:   []string{c.user.Email},
+	})
+
+	var outputs []string
+
+	for _, args := range [][]string{
+		{"__complete", "request", "--account", ""},
+		{"__complete", "request", "--account", "dev", "--role", ""},
+		{"__complete", "request", "--role", ""},
+		{"__complete", "approve", ""},
+		{"__complete", "approve", req.ID, ""},
+	} {
+		out, err := c.run("", args...)
+		require.NoError(t, err)
+
+		outputs = append(outputs, "$ "+strings.Join(args, " ")+"\n"+out)
+	}
+
+	c.golden("completion", strings.Join(outputs, "\n"))
+
+	out, err := c.run("", "completion", "bash")
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(out, "# bash completion"))
+}
+
+func TestCancel(t *testing.T) {
+	c := newCLITest(t)
+	c.login(c.user)
+
+	out, err := c.run("", "cancel")
+	require.NoError(t, err)
+	require.Contains(t, out, "There are no pending requests to cancel")
+
+	req := c.srv.AddRequest(&teamtest.Request{
+		Email:       c.user.Email,
+		AccountID:   "111111111111",
+		AccountName: "prod",
+		Role:        "AdministratorAccess",
+		RoleID:      "perm-admin",
+		StartTime:   "2030-01-02T04:00:00Z",
+		Duration:    "4",
+		Status:      "pending",
+		TicketNo:    "INC-6",
+	})
+
+	_, err = c.run("", "cancel", "unknown", "-y")
+	require.ErrorIs(t, err, ErrInvalid)
+
+	out, err = c.run("y\n", "cancel", req.ID)
+	require.NoError(t, err)
+	c.golden("cancel", out)
+
+	require.Equal(t, "cancelled", c.srv.Requests()[0].Status)
+}
+
+func TestNoInput(t *testing.T) {
+	c := newCLITest(t)
+	c.login(c.user)
+
+	_, err := c.run("", "request", "--no-input", "--account", "prod")
+	require.ErrorIs(t, err, ErrInputRequired)
+	require.ErrorContains(t, err, "input is disabled: role option required, pass --role")
+
+	_, err = c.run("", "request", "--account", "prod")
+	require.ErrorIs(t, err, ErrInputRequired)
+	require.ErrorContains(t, err, "end of input: role option required, pass --role")
+
+	// Input from a file, rather than a terminal, disables prompts unless explicitly enabled
+	devNull, err := os.Open(os.DevNull)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = devNull.Close()
+	})
+
+	cmd := newRootCmd()
+	cmd.SetArgs([]string{"request", "--account", "prod", "--role", "ReadOnlyAccess", "--duration", "1"})
+	cmd.SetIn(devNull)
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+
+	err = cmd.ExecuteContext(c.srv.Context(context.Background()))
+	require.ErrorIs(t, err, ErrInputRequired)
+	require.ErrorContains(t, err, "input is disabled: ticket required, pass --ticket")
+
+	require.Empty(t, c.srv.Requests())
+
+	// The start time is optional, so defaults to now rather than being required
+	out, err := c.run("",
+		"request", "--no-input", "--account", "prod", "--role", "ReadOnlyAccess", "--duration", "1",
+		"--ticket", "INC-1", "--reason", "Deploy", "-y",
+	)
+	require.NoError(t, err)
+	require.Contains(t, out, "  Start: now\n")
+
+	reqs := c.srv.Requests()
+	require.Len(t, reqs, 1)
+	require.Equal(t, "INC-1", reqs[0].TicketNo)
+
+	req := c.srv.AddRequest(&teamtest.Request{
+		Email:     "bob@example.com",
+		StartTime: "2030-01-02T04:00:00Z",
+		Status:    "pending",
+		Approvers: []string{c.user.Email},
+	})
+
+	_, err = c.run("", "approve", req.ID, "--no-input")
+	require.ErrorContains(t, err, "pass --approve or --reject")
+
+	_, err = c.run("", "approve", req.ID, "--no-input", "--reject", "--comment", "Not needed", "-y")
+	require.NoError(t, err)
+	require.Equal(t, "rejected", c.srv.Requests()[1].Status)
+	require.Equal(t, "Not needed", c.srv.Requests()[1].Comment)
+}
+
+func TestAccountCache(t *testing.T) {
+	c := newCLITest(t)
+	c.login(c.user)
+
+	_, err := c.run("", "list-accounts", "--offline")
+	require.ErrorIs(t, err, ErrInvalid)
+
+	out, err := c.run("", "cache", "refresh")
+	require.NoError(t, err)
+	require.Contains(t, out, "Cached 2 accounts")
+
+	cache, ok, err := getAccountsCache()
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, accountCacheVersion, cache.Version)
+	require.Equal(t, timeNow(), cache.FetchedAt)
+	require.Equal(t, c.srv.URL, cache.Server)
+	require.Equal(t, c.user.ID, cache.UserID)
+
+	args := []string{
+		"request", "--account", "dev", "--role", "ReadOnlyAccess", "--duration", "1", "--ticket", "INC-7",
+		"--reason", "Check", "--start", "now", "-y",
+	}
+
+	out, err = c.run("", args...)
+	require.NoError(t, err)
+	require.Contains(t, out, "found in cache")
+
+	// Expired caches are refetched
+	cfg, err := readConfig()
+	require.NoError(t, err)
+
+	cfg.AccountCacheTTL = "0s"
+	require.NoError(t, writeConfig(cfg))
+
+	out, err = c.run("", args...)
+	require.NoError(t, err)
+	require.Contains(t, out, "Fetching AWS accounts")
+
+	cfg.AccountCacheTTL = ""
+	require.NoError(t, writeConfig(cfg))
+
+	// Caches of other users are ignored
+	other := c.srv.AddUser(&teamtest.User{
+		ID:       "user-2",
+		Username: "bob",
+		Email:    "bob@example.com",
+		Policy:   c.user.Policy,
+	})
+	c.login(other)
+
+	out, err = c.run("", args...)
+	require.NoError(t, err)
+	require.Contains(t, out, "Fetching AWS accounts")
+
+	c.srv.Close()
+
+	out, err = c.run("", "list-accounts", "--offline")
+	require.NoError(t, err)
+	c.golden("list_accounts_offline", out)
+
+	_, err = c.run("", "cache", "clear")
+	require.NoError(t, err)
+
+	_, ok, err = getAccountsCache()
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestConfigDirs(t *testing.T) {
+	c := newCLITest(t)
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+
+	// Files in the legacy location are migrated to the XDG dirs
+	c.login(c.user)
+
+	_, err = c.run("", "cache", "refresh")
+	require.NoError(t, err)
+
+	require.FileExists(t, filepath.Join(home, ".config", "team-cli", "config.json"))
+	require.FileExists(t, filepath.Join(home, ".cache", "team-cli", "accounts.json"))
+
+	require.NoError(t, os.Rename(
+		filepath.Join(home, ".cache", "team-cli", "accounts.json"),
+		filepath.Join(home, ".config", "team-cli", "accounts.json"),
+	))
+
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, "xdg-config"))
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(home, "xdg-cache"))
+
+	out, err := c.run("", "list-accounts", "--offline")
+	require.NoError(t, err)
+	require.Contains(t, out, "prod")
+
+	_, err = readConfig()
+	require.NoError(t, err)
+
+	for _, path := range []string{
+		filepath.Join(home, "xdg-config", "team-cli", "config.json"),
+		filepath.Join(home, "xdg-cache", "team-cli", "accounts.json"),
+	} {
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(0600), info.Mode().Perm(), path)
+	}
+
+	info, err := os.Stat(filepath.Join(home, "xdg-config", "team-cli"))
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0700), info.Mode().Perm())
+
+	require.NoFileExists(t, filepath.Join(home, ".config", "team-cli", "config.json"))
+	require.NoFileExists(t, filepath.Join(home, ".config", "team-cli", "accounts.json"))
+
+	// An explicit dir holds both the config and cache, and is never migrated into
+	explicitDir := filepath.Join(home, "explicit")
+	t.Setenv("TEAM_CLI_CONFIG_DIR", explicitDir)
+
+	_, err = c.run("", "list-accounts", "--offline")
+	require.ErrorIs(t, err, ErrInvalid)
+
+	c.login(c.user)
+
+	_, err = c.run("", "cache", "refresh")
+	require.NoError(t, err)
+
+	require.FileExists(t, filepath.Join(explicitDir, "config.json"))
+	require.FileExists(t, filepath.Join(explicitDir, "accounts.json"))
+
+	// The flag takes precedence over the environment
+	flagDir := filepath.Join(home, "flag")
+
+	_, err = c.run("", "--config-dir", flagDir, "cache", "clear")
+	require.NoError(t, err)
+
+	require.DirExists(t, flagDir)
+	require.FileExists(t, filepath.Join(explicitDir, "accounts.json"))
+}
+
+func TestConcurrentRefresh(t *testing.T) {
+	c := newCLITest(t)
+
+	token := c.srv.Token(c.user)
+	token.ExpiresAt = time.Now().Add(-time.Minute)
+
+	require.NoError(t, writeConfig(&Config{
+		ServerConfig: c.srv.RemoteConfig(),
+		AuthToken:    token,
+	}))
+
+	ctx := c.srv.Context(context.Background())
+	results := make(chan *Config)
+	errs := make(chan error)
+
+	for range 5 {
+		go func() {
+			cfg, err := readConfigReAuth(ctx, &prompter{out: io.Discard, noInput: true})
+			if err != nil {
+				errs <- err
+
+				return
+			}
+
+			results <- cfg
+		}()
+	}
+
+	// Only the first process to take the lock refreshes, the rest reuse its token
+	var accessToken string
+
+	for range 5 {
+		select {
+		case err := <-errs:
+			require.NoError(t, err)
+		case cfg := <-results:
+			require.NotEqual(t, token.AccessToken, cfg.AuthToken.AccessToken)
+
+			if accessToken == "" {
+				accessToken = cfg.AuthToken.AccessToken
+			}
+
+			require.Equal(t, accessToken, cfg.AuthToken.AccessToken)
+			require.Equal(t, token.RefreshToken, cfg.AuthToken.RefreshToken)
+		}
+	}
+
+	cfg, err := readConfig()
+	require.NoError(t, err)
+	require.Equal(t, accessToken, cfg.AuthToken.AccessToken)
+
+	dir, _, err := configDir()
+	require.NoError(t, err)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+
+	names := make([]string, 0, len(entries))
+
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+
+	require.ElementsMatch(t, []string{"config.json", "config.lock"}, names)
+}
+
+func TestReauthUnlocked(t *testing.T) {
+	c := newCLITest(t)
+
+	token := c.srv.Token(c.user)
+	token.ExpiresAt = time.Now().Add(-time.Minute)
+	token.RefreshToken = ""
+
+	require.NoError(t, writeConfig(&Config{
+		ServerConfig:  c.srv.RemoteConfig(),
+		AuthToken:     token,
+		UseDeviceCode: true,
+	}))
+
+	in, input := io.Pipe()
+	defer input.Close()
+
+	out, done := c.start(in, "list-accounts")
+
+	c.waitFor(out, "Device code? ")
+
+	// Other processes can update the config while the user authenticates
+	updated := make(chan error, 1)
+
+	go func() {
+		_, err := c.run("", "config", "set", "no_browser", "true")
+		updated <- err
+	}()
+
+	select {
+	case err := <-updated:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "config update blocked while authenticating")
+	}
+
+	code := c.srv.IssueCode(c.user, c.srv.URL+"/device_code/")
+
+	_, err := io.WriteString(input, code+"\n")
+	require.NoError(t, err)
+	require.NoError(t, <-done)
+	require.Contains(t, out.String(), `name="prod"`)
+
+	cfg, err := readConfig()
+	require.NoError(t, err)
+	require.True(t, cfg.NoBrowser)
+	require.NotEqual(t, token.AccessToken, cfg.AuthToken.AccessToken)
+}
+
+func TestProfiles(t *testing.T) {
+	c := newCLITest(t)
+
+	// Configs from older versions are upgraded, keeping a backup
+	legacy, err := json.Marshal(map[string]any{
+		"server_config":   c.srv.RemoteConfig(),
+		"auth_token":      c.srv.Token(c.user),
+		"use_device_code": false,
+		"no_browser":      true,
+	})
+	require.NoError(t, err)
+
+	path, err := configPath("config.json")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, legacy, 0600))
+
+	out, err := c.run("", "list-accounts")
+	require.NoError(t, err)
+	require.Contains(t, out, "prod")
+
+	_, err = c.run("", "preset", "save", "ro", "--role", "ReadOnlyAccess")
+	require.NoError(t, err)
+
+	backup, err := os.ReadFile(path + ".v0.bak")
+	require.NoError(t, err)
+	require.Equal(t, legacy, backup)
+
+	file, version, err := readConfigFile()
+	require.NoError(t, err)
+	require.Equal(t, configVersion, version)
+	require.Equal(t, defaultProfile, file.CurrentProfile)
+	require.True(t, file.Profiles[defaultProfile].NoBrowser)
+	require.Contains(t, file.Profiles[defaultProfile].Presets, "ro")
+
+	out, err = c.run("", "config", "validate")
+	require.NoError(t, err)
+	require.Contains(t, out, "is valid")
+
+	// Other profiles are configured separately
+	_, err = c.run("", "--profile", "staging", "list-accounts")
+	require.ErrorIs(t, err, ErrInvalidConfig)
+
+	t.Setenv("TEAM_CLI_PROFILE", "staging")
+	c.login(c.user)
+
+	out, err = c.run("", "preset", "list")
+	require.NoError(t, err)
+	require.Contains(t, out, "No presets saved")
+
+	out, err = c.run("", "--profile", defaultProfile, "preset", "list")
+	require.NoError(t, err)
+	require.Contains(t, out, "ro: ")
+
+	out, err = c.run("", "__complete", "--profile", "")
+	require.NoError(t, err)
+	require.Contains(t, out, "default\thttps://team.example.com")
+	require.Contains(t, out, "staging\thttps://team.example.com")
+
+	file, _, err = readConfigFile()
+	require.NoError(t, err)
+	require.Equal(t, defaultProfile, file.CurrentProfile)
+
+	// Invalid fields are reported
+	file.Profiles["staging"].AccountCacheTTL = "soon"
+	require.NoError(t, writeConfigFile(file))
+
+	out, err = c.run("", "config", "validate")
+	require.ErrorIs(t, err, ErrInvalidConfig)
+	require.Contains(t, out, `profiles.staging.account_cache_ttl: "soon" is not a valid duration`)
+}
+
+func TestTokenStore(t *testing.T) {
+	if runtime.GOOS == "darwin" || runtime.GOOS == "windows" {
+		t.Skip("the token store is only faked for secret-tool")
+	}
+
+	c := newCLITest(t)
+
+	token := c.srv.Token(c.user)
+
+	// Tokens written to the config file by older versions are moved into the store
+	legacy, err := json.Marshal(map[string]any{
+		"version": 1,
+		"profiles": map[string]any{
+			defaultProfile: &Config{ServerConfig: c.srv.RemoteConfig(), AuthToken: token},
+		},
+	})
+	require.NoError(t, err)
+
+	path, err := configPath("config.json")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, legacy, 0600))
+
+	bin := t.TempDir()
+	require.NoError(t, os.Symlink(os.Args[0], filepath.Join(bin, "secret-tool")))
+
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("TEAM_CLI_TEST_SECRETS", t.TempDir())
+	t.Setenv("TEAM_CLI_TOKEN_STORE", "")
+
+	out, err := c.run("", "list-accounts")
+	require.NoError(t, err)
+	require.Contains(t, out, "prod")
+
+	raw, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NotContains(t, string(raw), token.AccessToken)
+	require.NotContains(t, string(raw), token.RefreshToken)
+	require.Contains(t, string(raw), `"auth_token_store": "secret-service"`)
+
+	// Older versions refuse to read a config without its tokens, rather than asking to authenticate
+	_, version, err := parseConfigFile(raw)
+	require.NoError(t, err)
+	require.Equal(t, 2, version)
+
+	cfg, err := readConfig()
+	require.NoError(t, err)
+	require.Equal(t, token.AccessToken, cfg.AuthToken.AccessToken)
+	require.Equal(t, token.RefreshToken, cfg.AuthToken.RefreshToken)
+
+	// Other changes keep the token in the store
+	_, err = c.run("", "config", "set", "no_browser", "true")
+	require.NoError(t, err)
+
+	raw, err = os.ReadFile(path)
+	require.NoError(t, err)
+	require.NotContains(t, string(raw), token.RefreshToken)
+
+	out, err = c.run("", "list-accounts")
+	require.NoError(t, err)
+	require.Contains(t, out, "prod")
+
+	// Without the store, the user must authenticate again
+	t.Setenv("TEAM_CLI_TOKEN_STORE", "file")
+
+	_, err = c.run("", "list-accounts", "--no-input")
+	require.ErrorIs(t, err, ErrInputRequired)
+}
+
+func TestConfigCommands(t *testing.T) {
+	c := newCLITest(t)
+	c.login(c.user)
+
+	_, err := c.run("", "preset", "save", "ops", "--role", "ReadOnlyAccess")
+	require.NoError(t, err)
+
+	out, err := c.run("", "config", "set", "use_device_code", "true")
+	require.NoError(t, err)
+	require.Contains(t, out, `Set use_device_code to "true"`)
+
+	_, err = c.run("", "config", "set", "presets.ops.duration", "2")
+	require.NoError(t, err)
+
+	// Presets and profiles may share names with read only fields
+	_, err = c.run("", "config", "set", "presets.auth_token.duration", "3")
+	require.NoError(t, err)
+
+	_, err = c.run("", "config", "set", "profiles.default.presets.version.duration", "4")
+	require.NoError(t, err)
+
+	cfg, err := readConfig()
+	require.NoError(t, err)
+	require.True(t, cfg.UseDeviceCode)
+	require.Equal(t, 2, cfg.Presets["ops"].Duration)
+	require.Equal(t, 3, cfg.Presets["auth_token"].Duration)
+	require.Equal(t, 4, cfg.Presets["version"].Duration)
+
+	out, err = c.run("", "config", "get", "presets.ops.duration")
+	require.NoError(t, err)
+	require.Equal(t, "2\n", out)
+
+	out, err = c.run("", "config", "get", "profiles.default.auth_token.refresh_token")
+	require.NoError(t, err)
+	require.Equal(t, "REDACTED\n", out)
+
+	out, err = c.run("", "config", "view")
+	require.NoError(t, err)
+	require.NotContains(t, out, cfg.AuthToken.AccessToken)
+	require.Contains(t, out, `"use_device_code": true`)
+
+	for _, tt := range []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"account_cache_ttl", "soon"}, `account_cache_ttl: "soon" is not a valid duration`},
+		{[]string{"no_browser", "maybe"}, "no_browser must be true or false"},
+		{[]string{"auth_token.access_token", "x"}, "managed by team-cli configure"},
+		{[]string{"version", "3"}, "version is managed by team-cli configure"},
+		{[]string{"presets", "x"}, "cannot be set directly"},
+		{[]string{"colour", "red"}, "unknown key profiles.default.colour"},
+		{[]string{"current_profile", "missing"}, `profile "missing" does not exist`},
+	} {
+		_, err = c.run("", append([]string{"config", "set"}, tt.args...)...)
+		require.ErrorContains(t, err, tt.err)
+	}
+
+	_, err = c.run("", "config", "get", "presets.missing")
+	require.ErrorContains(t, err, "presets.missing is not set")
+
+	// Invalid edits can be retried or discarded
+	edits := []string{`{"version": 1, "profiles": {"default": {"no_browsr": true}}}`}
+
+	original := editFile
+
+	t.Cleanup(func() {
+		editFile = original
+	})
+
+	configFilePath, err := configPath("config.json")
+	require.NoError(t, err)
+
+	editFile = func(_ *cobra.Command, path string) error {
+		require.Equal(t, filepath.Dir(configFilePath), filepath.Dir(filepath.Dir(path)))
+
+		raw, err := os.ReadFile(path)
+		require.NoError(t, err)
+
+		edit := edits[0]
+		edits = edits[1:]
+
+		if edit == "" {
+			require.Contains(t, string(raw), "no_browsr")
+
+			file, _, err := readConfigFile()
+			require.NoError(t, err)
+
+			file.Profiles[defaultProfile].NoBrowser = true
+
+			enc, err := json.Marshal(file)
+			require.NoError(t, err)
+
+			edit = string(enc)
+		}
+
+		require.NoError(t, os.WriteFile(path, []byte(edit), 0600))
+
+		return nil
+	}
+
+	out, err = c.run("n\n", "config", "edit")
+	require.ErrorIs(t, err, ErrInvalidConfig)
+	require.Contains(t, out, "profiles.default.no_browsr: unknown field")
+
+	// Edits made on retry start from the invalid edit
+	edits = []string{`{"version": 1, "profiles": {"default": {"no_browsr": true}}}`, ""}
+
+	out, err = c.run("y\n", "config", "edit")
+	require.NoError(t, err)
+	require.Contains(t, out, "Config updated")
+
+	cfg, err = readConfig()
+	require.NoError(t, err)
+	require.True(t, cfg.NoBrowser)
+	require.True(t, cfg.UseDeviceCode)
+
+	out, err = c.run("", "__complete", "config", "set", "")
+	require.NoError(t, err)
+	require.Contains(t, out, "no_browser\n")
+	require.Contains(t, out, "presets.ops.ticket_prefix\n")
+}
+
+func TestDoctor(t *testing.T) {
+	c := newCLITest(t)
+
+	out, err := c.run("", "doctor")
+	require.ErrorIs(t, err, ErrInvalidConfig)
+	require.Contains(t, out, "[fail] Server: no server configured")
+
+	c.login(c.user)
+
+	out, err = c.run("", "doctor")
+	require.NoError(t, err)
+	c.golden("doctor", strings.ReplaceAll(out, os.Getenv("HOME"), "/home/user"))
+
+	// Unused redirect URIs are only warned about
+	c.srv.RedirectURIs = []string{teamtest.LocalhostRedirect}
+
+	out, err = c.run("", "doctor")
+	require.NoError(t, err)
+	require.Contains(t, out, "[warn] Device code redirect")
+
+	_, err = c.run("", "config", "set", "use_device_code", "true")
+	require.NoError(t, err)
+
+	out, err = c.run("", "doctor")
+	require.ErrorIs(t, err, ErrInvalidConfig)
+	require.Contains(t, out, "[fail] Device code redirect: https://team.example.com/device_code/ is not allowed")
+
+	// Problems with the token and files are reported
+	cfg, err := readConfig()
+	require.NoError(t, err)
+
+	cfg.AuthToken.AccessToken = "invalid"
+	require.NoError(t, writeConfig(cfg))
+
+	path, err := configPath("config.json")
+	require.NoError(t, err)
+	require.NoError(t, os.Chmod(path, 0644))
+
+	out, err = c.run("", "doctor")
+	require.ErrorIs(t, err, ErrInvalidConfig)
+	require.Contains(t, out, "[fail] Auth token: rejected")
+	require.Contains(t, out, "[fail] Realtime endpoint")
+	require.Contains(t, out, "is accessible by other users (-rw-r--r--)")
+}
+
+func TestCredentials(t *testing.T) {
+	c := newCLITest(t)
+	c.login(c.user)
+
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(home, "aws-config"))
+
+	_, err = c.run("", "credentials")
+	require.ErrorIs(t, err, ErrInvalidConfig)
+
+	_, err = c.run("", "config", "set", "server_config.access_portal_url", "https://d-1234567890.awsapps.com/start")
+	require.NoError(t, err)
+
+	_, err = c.run("", "credentials")
+	require.ErrorContains(t, err, "access portal region is unknown")
+
+	_, err = c.run("", "config", "set", "server_config.access_portal_region", "eu-west-2")
+	require.NoError(t, err)
+
+	_, err = c.run("", "credentials")
+	require.ErrorContains(t, err, "there are no active sessions")
+
+	session := c.srv.AddRequest(&teamtest.Request{
+		Email:       c.user.Email,
+		AccountID:   "111111111111",
+		AccountName: "prod",
+		Role:        "AdministratorAccess",
+		RoleID:      "perm-admin",
+		StartTime:   "2030-01-02T03:00:00Z",
+		EndTime:     "2030-01-02T04:00:00Z",
+		Duration:    "1",
+		Status:      "in progress",
+		TicketNo:    "INC-1",
+	})
+
+	require.NoError(t, os.WriteFile(os.Getenv("AWS_CONFIG_FILE"), []byte("[default]\nregion = eu-west-2\n"), 0600))
+
+	out, err := c.run("", "credentials")
+	require.NoError(t, err)
+	c.golden("credentials", strings.ReplaceAll(out, home, "/home/user"))
+
+	raw, err := os.ReadFile(os.Getenv("AWS_CONFIG_FILE"))
+	require.NoError(t, err)
+	require.Equal(t, "[default]\nregion = eu-west-2\n\n[profile team-prod-AdministratorAccess]\n"+
+		"sso_start_url = https://d-1234567890.awsapps.com/start\nsso_region = eu-west-2\n"+
+		"sso_account_id = 111111111111\nsso_role_name = AdministratorAccess\n", string(raw))
+
+	// Only the export is printed, and the profile is replaced
+	out, err = c.run("", "credentials", session.ID, "--export", "--credential-process", "--aws-profile", "admin")
+	require.NoError(t, err)
+	require.Equal(t, "export AWS_PROFILE=admin\n", out)
+
+	exe, err := os.Executable()
+	require.NoError(t, err)
+
+	raw, err = os.ReadFile(os.Getenv("AWS_CONFIG_FILE"))
+	require.NoError(t, err)
+	require.Contains(t, string(raw), "[profile admin]\ncredential_process = "+exe+
+		" credentials --process --account 111111111111 --role AdministratorAccess\n")
+
+	// The profile has no access portal settings, so an sso-session is written to sign in with
+	require.Contains(t, string(raw), "[sso-session team-d-1234567890.awsapps.com]\n"+
+		"sso_start_url = https://d-1234567890.awsapps.com/start\nsso_region = eu-west-2\n"+
+		"sso_registration_scopes = sso:account:access\n")
+
+	// The credential process prints credentials from the access portal, expiring with the session
+	portal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("x-amz-sso_bearer_token") != "sso-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		require.Equal(t, "/federation/credentials", r.URL.Path)
+		require.Equal(t, "111111111111", r.URL.Query().Get("account_id"))
+		require.Equal(t, "AdministratorAccess", r.URL.Query().Get("role_name"))
+
+		_, _ = w.Write([]byte(`{"roleCredentials": {"accessKeyId": "AKIA", "secretAccessKey": "secret",` +
+			`"sessionToken": "session", "expiration": 1893560645000}}`))
+	}))
+	t.Cleanup(portal.Close)
+
+	t.Setenv("AWS_ENDPOINT_URL_SSO", portal.URL)
+
+	args := []string{"credentials", "--process", "--account", "111111111111", "--role", "AdministratorAccess"}
+
+	_, err = c.run("", args...)
+	require.ErrorIs(t, err, ErrNoSSOToken)
+	require.ErrorContains(t, err, "sign in with: aws sso login --sso-session team-d-1234567890.awsapps.com")
+
+	cacheDir := filepath.Join(home, ".aws", "sso", "cache")
+	require.NoError(t, os.MkdirAll(cacheDir, 0700))
+
+	// Tokens are found whether signed in via the sso-session, or a profile with the start URL
+	for _, key := range []string{"team-d-1234567890.awsapps.com", "https://d-1234567890.awsapps.com/start"} {
+		hash := sha1.Sum([]byte(key))
+		cachePath := filepath.Join(cacheDir, hex.EncodeToString(hash[:])+".json")
+
+		require.NoError(t, os.WriteFile(cachePath, []byte(`{"accessToken": "sso-token", "expiresAt": "2030-01-02T05:00:00Z"}`), 0600))
+
+		out, err = c.run("", args...)
+		require.NoError(t, err)
+		require.JSONEq(t, `{
+			"Version": 1,
+			"AccessKeyId": "AKIA",
+			"SecretAccessKey": "secret",
+			"SessionToken": "session",
+			"Expiration": "2030-01-02T04:00:00Z"
+		}`, out)
+
+		require.NoError(t, os.Remove(cachePath))
+	}
+
+	_, err = c.run("", "credentials", "--process", "--account", "111111111111", "--role", "ReadOnlyAccess")
+	require.ErrorContains(t, err, "no active session")
+}
+
+func TestExec(t *testing.T) {
+	c := newCLITest(t)
+	c.login(c.user)
+
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(home, "aws-config"))
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIA")
+	t.Setenv("TEAM_CLI_TEST_EXEC", "1")
+
+	args := []string{
+		"exec", "--account", "prod", "--role", "ReadOnlyAccess", "--duration", "1", "--ticket", "INC-1",
+		"--reason", "Deploy", "--start", "now", "-y", "--revoke", "--", os.Args[0], "-test.run=^$",
+	}
+
+	// The access portal is required to use the session, so is checked before requesting
+	_, err = c.run("", args...)
+	require.ErrorIs(t, err, ErrInvalidConfig)
+	require.Empty(t, c.srv.Requests())
+
+	_, err = c.run("", "config", "set", "server_config.access_portal_url", "https://d-1234567890.awsapps.com/start")
+	require.NoError(t, err)
+
+	_, err = c.run("", "config", "set", "server_config.access_portal_region", "eu-west-2")
+	require.NoError(t, err)
+
+	// Start the session once it has been requested
+	go func() {
+		for {
+			reqs := c.srv.Requests()
+
+			if len(reqs) == 0 {
+				time.Sleep(10 * time.Millisecond)
+
+				continue
+			}
+
+			c.srv.UpdateRequest(reqs[0].ID, func(req *teamtest.Request) {
+				req.Status = "in progress"
+				req.EndTime = "2030-01-02T04:04:00Z"
+			})
+
+			return
+		}
+	}()
+
+	out, err := c.run("", args...)
+
+	var exitErr *exitError
+
+	require.ErrorAs(t, err, &exitErr)
+	require.Equal(t, 3, exitErr.code)
+	c.golden("exec", out)
+
+	reqs := c.srv.Requests()
+	require.Len(t, reqs, 1)
+	require.Equal(t, "revoked", reqs[0].Status)
+	require.Equal(t, "INC-1", reqs[0].TicketNo)
+
+	raw, err := os.ReadFile(os.Getenv("AWS_CONFIG_FILE"))
+	require.NoError(t, err)
+	require.Contains(t, string(raw), "[profile team-prod-ReadOnlyAccess]\nsso_start_url = ")
+
+	// Requests which do not start are reported, without running the command
+	go func() {
+		for {
+			reqs := c.srv.Requests()
+
+			if len(reqs) < 2 {
+				time.Sleep(10 * time.Millisecond)
+
+				continue
+			}
+
+			c.srv.UpdateRequest(reqs[1].ID, func(req *teamtest.Request) {
+				req.Status = "rejected"
+			})
+
+			return
+		}
+	}()
+
+	out, err = c.run("", args...)
+	require.ErrorIs(t, err, ErrInvalid)
+	require.ErrorContains(t, err, "request is rejected")
+	require.NotContains(t, out, "AWS_PROFILE")
+}
+
+func TestConsole(t *testing.T) {
+	c := newCLITest(t)
+	c.login(c.user)
+
+	var opened []string
+
+	original := openBrowser
+
+	t.Cleanup(func() {
+		openBrowser = original
+	})
+
+	openBrowser = func(url string) error {
+		opened = append(opened, url)
+
+		return nil
+	}
+
+	_, err := c.run("", "console")
+	require.ErrorIs(t, err, ErrInvalidConfig)
+
+	_, err = c.run("", "config", "set", "server_config.access_portal_url", "https://d-1234567890.awsapps.com/start/")
+	require.NoError(t, err)
+
+	_, err = c.run("", "console")
+	require.ErrorContains(t, err, "there are no active sessions")
+
+	c.srv.AddRequest(&teamtest.Request{
+		Email:       c.user.Email,
+		AccountID:   "111111111111",
+		AccountName: "prod",
+		Role:        "AdministratorAccess",
+		RoleID:      "perm-admin",
+		StartTime:   "2030-01-02T03:00:00Z",
+		EndTime:     "2030-01-02T04:00:00Z",
+		Duration:    "1",
+		Status:      "in progress",
+		TicketNo:    "INC-1",
+	})
+
+	out, err := c.run("", "console")
+	require.NoError(t, err)
+	c.golden("console", out)
+	require.Equal(t, []string{
+		"https://d-1234567890.awsapps.com/start/#/console?account_id=111111111111&role_name=AdministratorAccess",
+	}, opened)
+
+	out, err = c.run("", "console", "--no-browser")
+	require.NoError(t, err)
+	require.Contains(t, out, "/#/console?account_id=111111111111&role_name=AdministratorAccess")
+	require.Len(t, opened, 1)
+}
+
+func TestApproveWatch(t *testing.T) {
+	c := newCLITest(t)
+	c.srv.Approvers = []string{"carol@example.com", c.user.Email}
+	c.login(c.user)
+
+	bob := c.srv.AddUser(&teamtest.User{
+		ID:       "user-2",
+		Username: "bob",
+		Email:    "bob@example.com",
+	})
+
+	first := c.srv.AddRequest(&teamtest.Request{
+		Email:       bob.Email,
+		AccountID:   "111111111111",
+		AccountName: "prod",
+		Role:        "AdministratorAccess",
+		RoleID:      "perm-admin",
+		StartTime:   "2030-01-02T05:00:00Z",
+		Duration:    "2",
+		Status:      "pending",
+		Approvers:   c.srv.Approvers,
+		TicketNo:    "INC-1",
+	})
+
+	in, input := io.Pipe()
+	out, done := c.start(in, "approve", "--watch")
+
+	c.waitFor(out, "Request option, or q to quit? ")
+
+	// Requests are added as they are created
+	client := team.NewClient(c.srv.RemoteConfig(), team.StaticTokenSource(c.srv.Token(bob)))
+
+	_, err := client.Request(c.srv.Context(context.Background()), &team.AccessRequest{
+		AccountID:     "222222222222",
+		AccountName:   "dev",
+		Role:          "ReadOnlyAccess",
+		RoleID:        "perm-ro",
+		Duration:      1,
+		StartTime:     time.Date(2030, 1, 2, 6, 0, 0, 0, time.UTC),
+		Justification: "Debugging",
+		Ticket:        "INC-2",
+	})
+	require.NoError(t, err)
+
+	c.waitFor(out, "New request from bob@example.com")
+
+	// And removed once handled by other approvers
+	c.srv.UpdateRequest(first.ID, func(req *teamtest.Request) {
+		req.Status = "approved"
+		req.Approver = "carol@example.com"
+	})
+
+	c.waitFor(out, "is now approved by carol@example.com")
+
+	_, err = io.WriteString(input, "1\n")
+	require.NoError(t, err)
+
+	c.waitFor(out, "Response option? ")
+
+	_, err = io.WriteString(input, "3\n")
+	require.NoError(t, err)
+
+	c.waitFor(out, "Comment? ")
+
+	_, err = io.WriteString(input, "Use read only\n")
+	require.NoError(t, err)
+
+	c.waitFor(out, "Enter q to quit: ")
+
+	require.NoError(t, input.Close())
+	require.NoError(t, <-done)
+
+	c.golden("approve_watch", c.normalise(out.String()))
+
+	reqs := c.srv.Requests()
+	require.Len(t, reqs, 2)
+	require.Equal(t, "rejected", reqs[1].Status)
+	require.Equal(t, "Use read only", reqs[1].Comment)
+}
+
+func TestNotifications(t *testing.T) {
+	c := newCLITest(t)
+	c.srv.Approvers = []string{"carol@example.com", c.user.Email}
+	c.login(c.user)
+
+	events := filepath.Join(t.TempDir(), "events")
+	t.Setenv("TEAM_CLI_TEST_NOTIFY", events)
+
+	// The command is quoted, as the path may contain spaces
+	_, err := c.run("", "config", "set", "notifications.command", `"`+os.Args[0]+`" -test.run=^$`)
+	require.NoError(t, err)
+
+	_, err = c.run("", "config", "set", "notifications.bell", "true")
+	require.NoError(t, err)
+
+	readEvents := func() []*notificationEvent {
+		raw, err := os.ReadFile(events)
+		require.NoError(t, err)
+
+		var out []*notificationEvent
+
+		for line := range strings.Lines(string(raw)) {
+			var event notificationEvent
+
+			require.NoError(t, json.Unmarshal([]byte(line), &event))
+
+			out = append(out, &event)
+		}
+
+		return out
+	}
+
+	// Every change to the status of the request is notified while waiting
+	go func() {
+		for {
+			reqs := c.srv.Requests()
+
+			if len(reqs) == 0 {
+				time.Sleep(10 * time.Millisecond)
+
+				continue
+			}
+
+			c.srv.UpdateRequest(reqs[0].ID, func(req *teamtest.Request) {
+				req.Status = "approved"
+				req.Approver = "carol@example.com"
+			})
+
+			// Only start the session once the approval has been seen
+			for {
+				if _, err := os.Stat(events); err == nil {
+					break
+				}
+
+				time.Sleep(10 * time.Millisecond)
+			}
+
+			c.srv.UpdateRequest(reqs[0].ID, func(req *teamtest.Request) {
+				req.Status = "in progress"
+				req.EndTime = "2030-01-02T04:04:00Z"
+			})
+
+			return
+		}
+	}()
+
+	out, err := c.run("",
+		"request", "--account", "prod", "--role", "ReadOnlyAccess", "--duration", "1", "--ticket", "INC-1",
+		"--reason", "Deploy", "--start", "now", "-y", "--wait",
+	)
+	require.NoError(t, err)
+
+	// Notifications are sent in the background, so the bells are not ordered with the output
+	require.Equal(t, 2, strings.Count(out, "\a"))
+	c.golden("request_wait", strings.ReplaceAll(out, "\a", ""))
+
+	got := readEvents()
+	require.Len(t, got, 2)
+	require.Equal(t, eventRequestUpdated, got[0].Type)
+	require.Equal(t, "Request approved", got[0].Title)
+	require.Equal(t, `Request for role "ReadOnlyAccess" in account "prod" is approved by carol@example.com`, got[0].Message)
+	require.Equal(t, "approved", got[0].Request.Status)
+	require.Equal(t, "Session started", got[1].Title)
+	require.Equal(t, "in progress", got[1].Request.Status)
+
+	// New requests requiring approval are notified while watching
+	bob := c.srv.AddUser(&teamtest.User{
+		ID:       "user-2",
+		Username: "bob",
+		Email:    "bob@example.com",
+	})
+
+	in, input := io.Pipe()
+	watchOut, done := c.start(in, "approve", "--watch")
+
+	c.waitFor(watchOut, "Enter q to quit: ")
+
+	client := team.NewClient(c.srv.RemoteConfig(), team.StaticTokenSource(c.srv.Token(bob)))
+
+	_, err = client.Request(c.srv.Context(context.Background()), &team.AccessRequest{
+		AccountID:     "222222222222",
+		AccountName:   "dev",
+		Role:          "ReadOnlyAccess",
+		RoleID:        "perm-ro",
+		Duration:      1,
+		StartTime:     time.Date(2030, 1, 2, 6, 0, 0, 0, time.UTC),
+		Justification: "Debugging",
+		Ticket:        "INC-2",
+	})
+	require.NoError(t, err)
+
+	c.waitFor(watchOut, "New request from bob@example.com")
+
+	require.NoError(t, input.Close())
+	require.NoError(t, <-done)
+
+	got = readEvents()
+	require.Len(t, got, 3)
+	require.Equal(t, eventApprovalRequired, got[2].Type)
+	require.Equal(t, `bob@example.com requested role "ReadOnlyAccess" in account "dev"`, got[2].Message)
+	require.Contains(t, watchOut.String(), "\a")
+}
+
+func TestDaemon(t *testing.T) {
+	c := newCLITest(t)
+	c.login(c.user)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	daemonOut, done := c.startContext(ctx, nil, "daemon")
+
+	c.waitFor(daemonOut, "Daemon listening on ")
+
+	_, err := c.run("", "daemon")
+	require.ErrorIs(t, err, ErrInvalid)
+	require.ErrorContains(t, err, "a daemon is already running")
+
+	cfg, err := readConfig()
+	require.NoError(t, err)
+
+	client := connectDaemon(ctx, cfg)
+	require.NotNil(t, client)
+
+	_, err = client.ListRequests(ctx, "unknown")
+	require.ErrorIs(t, err, ErrDaemon)
+	require.ErrorContains(t, err, `unknown filter "unknown"`)
+
+	// The daemon holds its own session, so commands work without a valid token
+	require.NoError(t, updateConfig(func(cfg *Config) error {
+		cfg.AuthToken.AccessToken = "expired"
+		cfg.AuthToken.RefreshToken = ""
+		cfg.AuthToken.ExpiresAt = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+		return nil
+	}))
+
+	out, err := c.run("", "list-accounts", "--no-input")
+	require.NoError(t, err)
+	require.Contains(t, out, `id="111111111111" name="prod"`)
+
+	// Accounts are updated as the policy is published
+	c.srv.PublishPolicy(&teamtest.User{
+		ID:       c.user.ID,
+		Username: c.user.Username,
+		Policy: []*teamtest.Entitlement{{
+			Accounts:    []*teamtest.Account{{Name: "sandbox", ID: "333333333333"}},
+			Permissions: []*teamtest.Permission{{Name: "AdministratorAccess", ID: "perm-admin"}},
+			Duration:    4,
+		}},
+	})
+
+	require.Eventually(t, func() bool {
+		cache, ok, err := getAccountsCache()
+
+		return err == nil && ok && len(cache.Accounts) == 1 && cache.Accounts["333333333333"] != nil
+	}, 5*time.Second, 5*time.Millisecond)
+
+	_, err = c.run("", "cache", "clear")
+	require.NoError(t, err)
+
+	out, err = c.run("", "list-accounts", "--no-input")
+	require.NoError(t, err)
+	require.Contains(t, out, `id="333333333333" name="sandbox"`)
+	require.NotContains(t, out, "prod")
+
+	// Requests are submitted and waited for via the daemon
+	go func() {
+		for {
+			reqs := c.srv.Requests()
+
+			if len(reqs) == 0 {
+				time.Sleep(10 * time.Millisecond)
+
+				continue
+			}
+
+			c.srv.UpdateRequest(reqs[0].ID, func(req *teamtest.Request) {
+				req.Status = "in progress"
+				req.EndTime = "2030-01-02T05:04:00Z"
+			})
+
+			return
+		}
+	}()
+
+	out, err = c.run("",
+		"request", "--account", "sandbox", "--role", "AdministratorAccess", "--duration", "1", "--ticket", "INC-1",
+		"--reason", "Deploy", "--start", "now", "-y", "--wait", "--no-input",
+	)
+	require.NoError(t, err)
+	require.Contains(t, out, "Session started")
+
+	reqs := c.srv.Requests()
+	require.Len(t, reqs, 1)
+	require.Equal(t, "333333333333", reqs[0].AccountID)
+
+	c.srv.UpdateRequest(reqs[0].ID, func(req *teamtest.Request) {
+		req.Status = "pending"
+	})
+
+	_, err = c.run("", "cancel", reqs[0].ID, "-y", "--no-input")
+	require.NoError(t, err)
+	require.Equal(t, "cancelled", c.srv.Requests()[0].Status)
+
+	cancel()
+	require.NoError(t, <-done)
+	require.Contains(t, daemonOut.String(), "Daemon stopped")
+
+	// Commands fall back to contacting TEAM directly once the daemon stops
+	_, err = c.run("", "cancel", "--no-input")
+	require.ErrorIs(t, err, ErrInputRequired)
+}
+
+func TestDaemonSavesRefreshedToken(t *testing.T) {
+	c := newCLITest(t)
+	c.login(c.user)
+
+	require.NoError(t, updateConfig(func(cfg *Config) error {
+		cfg.AuthToken.ExpiresAt = time.Now().Add(-time.Minute)
+
+		return nil
+	}))
+
+	cfg, err := readConfig()
+	require.NoError(t, err)
+
+	expired := *cfg.AuthToken
+
+	tokens := &configTokenSource{
+		tokens: team.RefreshingTokenSource(cfg.ServerConfig, &expired),
+		saved:  &expired,
+	}
+
+	token, err := tokens.Token(c.srv.Context(context.Background()))
+	require.NoError(t, err)
+	require.NotEqual(t, expired.AccessToken, token.AccessToken)
+
+	cfg, err = readConfig()
+	require.NoError(t, err)
+	require.Equal(t, token.AccessToken, cfg.AuthToken.AccessToken)
+	require.Equal(t, expired.RefreshToken, cfg.AuthToken.RefreshToken)
+
+	// Tokens obtained by other processes since are kept
+	newer := c.srv.Token(c.user)
+	newer.ExpiresAt = token.ExpiresAt.Add(time.Hour)
+
+	require.NoError(t, updateConfig(func(cfg *Config) error {
+		cfg.AuthToken = newer
+
+		return nil
+	}))
+
+	tokens.saved = nil
+
+	_, err = tokens.Token(c.srv.Context(context.Background()))
+	require.NoError(t, err)
+
+	cfg, err = readConfig()
+	require.NoError(t, err)
+	require.Equal(t, newer.AccessToken, cfg.AuthToken.AccessToken)
+}
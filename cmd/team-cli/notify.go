@@ -0,0 +1,280 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/csnewman/team-cli/team"
+	"github.com/spf13/cobra"
+)
+
+// NotificationConfig selects how the user is notified of request events while team-cli waits for or watches requests.
+type NotificationConfig struct {
+	// Command is run with the event as JSON on stdin. It is split into arguments as a shell would, honouring quotes, but
+	// run without a shell.
+	Command string `json:"command,omitempty"`
+
+	// Desktop sends desktop notifications via notify-send.
+	Desktop bool `json:"desktop,omitempty"`
+
+	// Bell rings the terminal bell.
+	Bell bool `json:"bell,omitempty"`
+}
+
+const (
+	// eventRequestUpdated is sent when the status of one of the user's requests changes.
+	eventRequestUpdated = "request_updated"
+
+	// eventApprovalRequired is sent when a new request requires approval by the user.
+	eventApprovalRequired = "approval_required"
+)
+
+// notificationEvent describes a request event, and is the JSON passed to notification commands.
+type notificationEvent struct {
+	Type    string                  `json:"type"`
+	Title   string                  `json:"title"`
+	Message string                  `json:"message"`
+	Request *team.PermissionRequest `json:"request"`
+}
+
+// notifyTimeout limits how long a single notification can take, including when waiting for notifications to be sent
+// as the command exits.
+const notifyTimeout = 30 * time.Second
+
+// notifyQueueSize is the number of events which can wait to be sent. Further events are dropped, rather than delaying
+// the command.
+const notifyQueueSize = 16
+
+type notifier interface {
+	notify(ctx context.Context, event *notificationEvent) error
+}
+
+// commandNotifier runs a command with the event as JSON on stdin.
+type commandNotifier struct {
+	args []string
+	out  io.Writer
+}
+
+func (n *commandNotifier) notify(ctx context.Context, event *notificationEvent) error {
+	enc, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("could not marshal event: %w", err)
+	}
+
+	cmd := exec.CommandContext(ctx, n.args[0], n.args[1:]...)
+	cmd.Stdin = bytes.NewReader(enc)
+	cmd.Stdout = n.out
+	cmd.Stderr = n.out
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("could not run notification command: %w", err)
+	}
+
+	return nil
+}
+
+// desktopNotifier sends a desktop notification via notify-send.
+type desktopNotifier struct{}
+
+func (n *desktopNotifier) notify(ctx context.Context, event *notificationEvent) error {
+	if err := exec.CommandContext(ctx, "notify-send", "--app-name=team-cli", event.Title, event.Message).Run(); err != nil {
+		return fmt.Errorf("could not run notify-send: %w", err)
+	}
+
+	return nil
+}
+
+// bellNotifier rings the terminal bell.
+type bellNotifier struct {
+	out io.Writer
+}
+
+func (n *bellNotifier) notify(context.Context, *notificationEvent) error {
+	if _, err := fmt.Fprint(n.out, "\a"); err != nil {
+		return fmt.Errorf("could not ring bell: %w", err)
+	}
+
+	return nil
+}
+
+// splitCommand splits a command into arguments as a shell would, honouring single quotes, double quotes and
+// backslashes, so paths quoted as by credentialProcessCommand can contain spaces.
+func splitCommand(command string) ([]string, error) {
+	var (
+		args  []string
+		arg   strings.Builder
+		inArg bool
+		quote rune
+	)
+
+	runes := []rune(command)
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+
+		switch {
+		case quote == '\'' && r != '\'':
+			arg.WriteRune(r)
+		case r == '\\' && (quote == 0 || i+1 < len(runes) && (runes[i+1] == '"' || runes[i+1] == '\\')):
+			// Within double quotes, backslashes only escape quotes and backslashes, keeping Windows paths intact
+			if i+1 == len(runes) {
+				return nil, fmt.Errorf("%w: trailing backslash in %q", ErrInvalid, command)
+			}
+
+			i++
+
+			arg.WriteRune(runes[i])
+
+			inArg = true
+		case quote == '"' && r != '"':
+			arg.WriteRune(r)
+		case r == '\'' || r == '"':
+			if quote == 0 {
+				quote = r
+			} else {
+				quote = 0
+			}
+
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+
+				inArg = false
+			}
+		default:
+			arg.WriteRune(r)
+
+			inArg = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("%w: unterminated quote in %q", ErrInvalid, command)
+	}
+
+	if inArg {
+		args = append(args, arg.String())
+	}
+
+	return args, nil
+}
+
+// notifiers delivers events to every configured sink in the background, so slow sinks never delay the command.
+// Failures are logged, so never interrupt the command. A nil notifiers discards events.
+type notifiers struct {
+	sinks  []notifier
+	events chan *notificationEvent
+	done   chan struct{}
+}
+
+// newNotifiers starts delivering events to the configured sinks, until closed.
+func newNotifiers(cmd *cobra.Command, cfg *Config) *notifiers {
+	if cfg.Notifications == nil {
+		return nil
+	}
+
+	var sinks []notifier
+
+	// Output of the command must not be mixed with parsable output
+	if args, err := splitCommand(cfg.Notifications.Command); err != nil {
+		slog.Warn("Ignoring notification command", "err", err)
+	} else if len(args) > 0 {
+		sinks = append(sinks, &commandNotifier{args: args, out: cmd.ErrOrStderr()})
+	}
+
+	if cfg.Notifications.Desktop {
+		sinks = append(sinks, &desktopNotifier{})
+	}
+
+	if cfg.Notifications.Bell {
+		sinks = append(sinks, &bellNotifier{out: cmd.ErrOrStderr()})
+	}
+
+	if len(sinks) == 0 {
+		return nil
+	}
+
+	n := &notifiers{
+		sinks:  sinks,
+		events: make(chan *notificationEvent, notifyQueueSize),
+		done:   make(chan struct{}),
+	}
+
+	go n.run()
+
+	return n
+}
+
+func (n *notifiers) run() {
+	defer close(n.done)
+
+	for event := range n.events {
+		ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+
+		for _, sink := range n.sinks {
+			if err := sink.notify(ctx, event); err != nil {
+				slog.Warn("Failed to send notification", "err", err)
+			}
+		}
+
+		cancel()
+	}
+}
+
+// notify queues the event to be sent.
+func (n *notifiers) notify(event *notificationEvent) {
+	if n == nil {
+		return
+	}
+
+	select {
+	case n.events <- event:
+	default:
+		slog.Warn("Dropping notification, as earlier notifications are still being sent", "title", event.Title)
+	}
+}
+
+// close waits for queued events to be sent.
+func (n *notifiers) close() {
+	if n == nil {
+		return
+	}
+
+	close(n.events)
+	<-n.done
+}
+
+// requestUpdatedEvent describes the status of one of the user's requests.
+func requestUpdatedEvent(req *team.PermissionRequest) *notificationEvent {
+	title := "Request " + req.Status
+
+	if req.Status == "in progress" {
+		title = "Session started"
+	}
+
+	return &notificationEvent{
+		Type:    eventRequestUpdated,
+		Title:   title,
+		Message: fmt.Sprintf("Request for role %q in account %q is %s", req.Role, req.AccountName, describeStatus(req)),
+		Request: req,
+	}
+}
+
+// approvalRequiredEvent describes a new request requiring approval by the user.
+func approvalRequiredEvent(req *team.PermissionRequest) *notificationEvent {
+	return &notificationEvent{
+		Type:    eventApprovalRequired,
+		Title:   "Approval required",
+		Message: fmt.Sprintf("%s requested role %q in account %q", req.Email, req.Role, req.AccountName),
+		Request: req,
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitCommand(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{
+			name:     "blank",
+			input:    "  ",
+			expected: nil,
+		},
+		{
+			name:     "spaces",
+			input:    " /path/to/hook  --flag\tvalue ",
+			expected: []string{"/path/to/hook", "--flag", "value"},
+		},
+		{
+			name:     "double quotes",
+			input:    `"/path/to/my hook" --title "say \"hi\""`,
+			expected: []string{"/path/to/my hook", "--title", `say "hi"`},
+		},
+		{
+			name:     "single quotes",
+			input:    `hook 'it'"'"'s \ here' ''`,
+			expected: []string{"hook", `it's \ here`, ""},
+		},
+		{
+			name:     "backslashes",
+			input:    `my\ hook "C:\Program Files\hook.exe"`,
+			expected: []string{"my hook", `C:\Program Files\hook.exe`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			args, err := splitCommand(tt.input)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, args)
+		})
+	}
+
+	_, err := splitCommand(`hook "unterminated`)
+	require.ErrorIs(t, err, ErrInvalid)
+
+	_, err = splitCommand(`hook \`)
+	require.ErrorIs(t, err, ErrInvalid)
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+)
+
+const appDirName = "team-cli"
+
+// configDirFlag holds the value of --config-dir for the running command.
+var configDirFlag string
+
+// legacyConfigDir is where all files were stored before XDG base directories were honoured.
+func legacyConfigDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user dir: %w", err)
+	}
+
+	return filepath.Join(homeDir, ".config", appDirName), nil
+}
+
+// configDir returns the directory holding the config, and whether it was explicitly chosen via --config-dir or
+// TEAM_CLI_CONFIG_DIR.
+func configDir() (string, bool, error) {
+	if configDirFlag != "" {
+		return configDirFlag, true, nil
+	}
+
+	if dir := os.Getenv("TEAM_CLI_CONFIG_DIR"); dir != "" {
+		return dir, true, nil
+	}
+
+	// Relative paths are invalid per the XDG base directory spec and must be ignored
+	if dir := os.Getenv("XDG_CONFIG_HOME"); filepath.IsAbs(dir) {
+		return filepath.Join(dir, appDirName), false, nil
+	}
+
+	dir, err := legacyConfigDir()
+
+	return dir, false, err
+}
+
+// cacheDir returns the directory holding cached data. An explicitly chosen config dir holds the cache too.
+func cacheDir() (string, error) {
+	dir, explicit, err := configDir()
+	if err != nil || explicit {
+		return dir, err
+	}
+
+	if dir := os.Getenv("XDG_CACHE_HOME"); filepath.IsAbs(dir) {
+		return filepath.Join(dir, appDirName), nil
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user dir: %w", err)
+	}
+
+	return filepath.Join(homeDir, ".cache", appDirName), nil
+}
+
+// configPath returns the path of a file within the config dir, creating the dir if needed.
+func configPath(file string) (string, error) {
+	dir, explicit, err := configDir()
+	if err != nil {
+		return "", err
+	}
+
+	return preparePath(dir, file, !explicit)
+}
+
+// cachePath returns the path of a file within the cache dir, creating the dir if needed.
+func cachePath(file string) (string, error) {
+	dir, explicit, err := configDir()
+	if err != nil {
+		return "", err
+	}
+
+	if !explicit {
+		dir, err = cacheDir()
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return preparePath(dir, file, !explicit)
+}
+
+func preparePath(dir string, file string, migrate bool) (string, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create team dir: %w", err)
+	}
+
+	path := filepath.Join(dir, file)
+
+	if migrate {
+		if err := migrateLegacyFile(file, path); err != nil {
+			return "", fmt.Errorf("failed to migrate %s: %w", file, err)
+		}
+	}
+
+	return path, nil
+}
+
+// migrateLegacyFile moves a file from the legacy config dir to its new path, unless the new path already exists.
+func migrateLegacyFile(file string, path string) error {
+	legacyDir, err := legacyConfigDir()
+	if err != nil {
+		return err
+	}
+
+	legacyPath := filepath.Join(legacyDir, file)
+
+	if legacyPath == path {
+		return nil
+	}
+
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	if _, err := os.Stat(legacyPath); err != nil {
+		return nil
+	}
+
+	slog.Info("Migrating file from legacy location", "from", legacyPath, "to", path)
+
+	// Rename fails across filesystems, in which case fall back to copying
+	if err := os.Rename(legacyPath, path); err == nil {
+		return os.Chmod(path, 0600)
+	}
+
+	if err := copyFile(legacyPath, path); err != nil {
+		return err
+	}
+
+	return os.Remove(legacyPath)
+}
+
+func copyFile(src string, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("could not open: %w", err)
+	}
+
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return fmt.Errorf("could not create: %w", err)
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+
+		return fmt.Errorf("could not copy: %w", err)
+	}
+
+	return out.Close()
+}
+
+// writePrivateFile atomically replaces a file with one readable only by the current user, so concurrent readers
+// never observe a partial write.
+func writePrivateFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("could not create temp file: %w", err)
+	}
+
+	// Removing fails harmlessly once the temp file has been renamed
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+
+		return fmt.Errorf("could not write temp file: %w", err)
+	}
+
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+
+		return fmt.Errorf("could not sync temp file: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("could not close temp file: %w", err)
+	}
+
+	if err := os.Chmod(tmp.Name(), 0600); err != nil {
+		return fmt.Errorf("could not set permissions: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("could not replace file: %w", err)
+	}
+
+	return nil
+}
+
+// lockPath takes an exclusive lock on a file, blocking until any other process releases it. The returned function
+// releases the lock.
+func lockPath(path string) (func(), error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("could not open lock file: %w", err)
+	}
+
+	if err := lockFile(f); err != nil {
+		_ = f.Close()
+
+		return nil, fmt.Errorf("could not lock: %w", err)
+	}
+
+	return func() {
+		if err := unlockFile(f); err != nil {
+			slog.Warn("Failed to release lock", "path", path, "err", err)
+		}
+
+		_ = f.Close()
+	}, nil
+}
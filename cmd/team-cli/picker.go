@@ -0,0 +1,256 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var ErrInterrupted = errors.New("interrupted")
+
+// pickerHeight is the maximum number of items shown by the picker at once.
+const pickerHeight = 10
+
+// fuzzyScore reports whether every rune of the query appears in order within the text, ignoring case. Lower scores
+// are better matches, favouring matches which are contiguous and start early.
+func fuzzyScore(query string, text string) (int, bool) {
+	if query == "" {
+		return 0, true
+	}
+
+	query = strings.ToLower(query)
+	text = strings.ToLower(text)
+
+	score := 0
+	last := -1
+
+	for _, r := range query {
+		idx := strings.IndexRune(text[last+1:], r)
+		if idx < 0 {
+			return 0, false
+		}
+
+		if last < 0 {
+			score += idx
+		} else {
+			score += idx * 2
+		}
+
+		last += idx + utf8.RuneLen(r)
+	}
+
+	return score, true
+}
+
+// picker is an interactive list, filtered as the user types. It expects its input to be a terminal in raw mode.
+type picker struct {
+	in    *bufio.Reader
+	out   io.Writer
+	title string
+	items []string
+
+	// width is the number of columns of the terminal, or zero if unknown. Rows are cut to fit, as wrapped rows would
+	// break redrawing in place.
+	width int
+
+	query    string
+	matches  []int
+	cursor   int
+	rendered int
+}
+
+// summary returns an item on a single line, joining its lines and collapsing runs of whitespace, so every field can be
+// searched. Rows are cut to the width of the terminal when shown.
+func summary(item string) string {
+	return strings.Join(strings.Fields(item), " ")
+}
+
+func (p *picker) filter() {
+	type match struct {
+		idx   int
+		score int
+	}
+
+	var matches []match
+
+	for i, item := range p.items {
+		if score, ok := fuzzyScore(p.query, summary(item)); ok {
+			matches = append(matches, match{idx: i, score: score})
+		}
+	}
+
+	slices.SortStableFunc(matches, func(a match, b match) int {
+		return a.score - b.score
+	})
+
+	p.matches = p.matches[:0]
+
+	for _, m := range matches {
+		p.matches = append(p.matches, m.idx)
+	}
+
+	p.cursor = min(p.cursor, max(len(p.matches)-1, 0))
+}
+
+// render redraws the picker in place, using \r\n as the terminal is in raw mode.
+func (p *picker) render() {
+	var b strings.Builder
+
+	if p.rendered > 0 {
+		fmt.Fprintf(&b, "\x1b[%dA", p.rendered)
+	}
+
+	b.WriteString("\r\x1b[J")
+	b.WriteString(p.fit(p.title + " (type to filter, arrows to move, enter to select)"))
+	b.WriteString("\r\n")
+	b.WriteString(p.fit("> " + p.query))
+	b.WriteString("\r\n")
+
+	lines := 2
+
+	// Scroll to keep the cursor visible
+	start := max(p.cursor-pickerHeight+1, 0)
+	end := min(start+pickerHeight, len(p.matches))
+
+	for i := start; i < end; i++ {
+		if i == p.cursor {
+			fmt.Fprintf(&b, "\x1b[7m%s\x1b[0m\r\n", p.fit("> "+summary(p.items[p.matches[i]])))
+		} else {
+			fmt.Fprintf(&b, "%s\r\n", p.fit("  "+summary(p.items[p.matches[i]])))
+		}
+
+		lines++
+	}
+
+	if len(p.matches) == 0 {
+		b.WriteString("  (no matches)\r\n")
+
+		lines++
+	} else if len(p.matches) > end-start {
+		fmt.Fprintf(&b, "  (%d of %d)\r\n", end-start, len(p.matches))
+
+		lines++
+	}
+
+	p.rendered = lines
+
+	_, _ = io.WriteString(p.out, b.String())
+}
+
+// fit cuts a row so it does not wrap. The last column is left empty, as some terminals wrap once it is written.
+func (p *picker) fit(row string) string {
+	if p.width <= 1 || utf8.RuneCountInString(row) < p.width {
+		return row
+	}
+
+	runes := []rune(row)
+
+	return string(runes[:p.width-2]) + "…"
+}
+
+func (p *picker) clear() {
+	if p.rendered > 0 {
+		_, _ = fmt.Fprintf(p.out, "\x1b[%dA\r\x1b[J", p.rendered)
+	}
+
+	p.rendered = 0
+}
+
+// run returns the index of the selected item.
+func (p *picker) run() (int, error) {
+	p.filter()
+
+	for {
+		p.render()
+
+		r, _, err := p.in.ReadRune()
+		if err != nil {
+			return 0, err
+		}
+
+		switch r {
+		case 3, 4: // Ctrl-C, Ctrl-D
+			p.clear()
+
+			return 0, ErrInterrupted
+		case '\r', '\n':
+			if len(p.matches) == 0 {
+				continue
+			}
+
+			idx := p.matches[p.cursor]
+
+			p.clear()
+
+			return idx, nil
+		case 127, 8: // Backspace
+			if p.query != "" {
+				_, size := utf8.DecodeLastRuneInString(p.query)
+				p.query = p.query[:len(p.query)-size]
+			}
+		case 21: // Ctrl-U
+			p.query = ""
+		case 16: // Ctrl-P
+			p.move(-1)
+		case 14: // Ctrl-N
+			p.move(1)
+		case 27: // Escape, or an escape sequence
+			if !p.readEscape() {
+				p.clear()
+
+				return 0, ErrInterrupted
+			}
+
+			continue
+		default:
+			if unicode.IsPrint(r) {
+				p.query += string(r)
+				p.cursor = 0
+			}
+		}
+
+		p.filter()
+	}
+}
+
+func (p *picker) move(delta int) {
+	if len(p.matches) == 0 {
+		return
+	}
+
+	p.cursor = (p.cursor + delta + len(p.matches)) % len(p.matches)
+}
+
+// readEscape handles the sequence sent by a key following an escape, returning false if the escape key itself was
+// pressed. Terminals send sequences in a single write, so the escape key is detected by no further input being
+// buffered, rather than waiting for the next key.
+func (p *picker) readEscape() bool {
+	if p.in.Buffered() == 0 {
+		return false
+	}
+
+	if next, err := p.in.Peek(1); err != nil || (next[0] != '[' && next[0] != 'O') {
+		return false
+	}
+
+	_, _ = p.in.ReadByte()
+
+	b, err := p.in.ReadByte()
+	if err != nil {
+		return true
+	}
+
+	switch b {
+	case 'A':
+		p.move(-1)
+	case 'B':
+		p.move(1)
+	}
+
+	return true
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFuzzyScore(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		query string
+		text  string
+		score int
+		ok    bool
+	}{
+		{query: "", text: "anything", score: 0, ok: true},
+		{query: "prod", text: `id="111" name="prod"`, score: 15, ok: true},
+		{query: "PRD", text: "production", score: 2, ok: true},
+		{query: "111", text: `id="111111111111"`, score: 4, ok: true},
+		{query: "dorp", text: "prod", ok: false},
+		{query: "é", text: "café", score: 3, ok: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query+"/"+tt.text, func(t *testing.T) {
+			t.Parallel()
+
+			score, ok := fuzzyScore(tt.query, tt.text)
+			require.Equal(t, tt.ok, ok)
+
+			if ok {
+				require.Equal(t, tt.score, score)
+			}
+		})
+	}
+}
+
+func TestPicker(t *testing.T) {
+	t.Parallel()
+
+	items := []string{
+		`id="111111111111" name="prod"`,
+		`id="222222222222" name="dev"`,
+		`id="333333333333" name="prod-eu"`,
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		idx   int
+		err   error
+	}{
+		{name: "enter selects first", input: "\r", idx: 0},
+		{name: "filter", input: "dev\r", idx: 1},
+		{name: "filter by id", input: "333\r", idx: 2},
+		{name: "ranked matches", input: "prodeu\r", idx: 2},
+		{name: "arrow down", input: "\x1b[B\x1b[B\r", idx: 2},
+		{name: "arrow up wraps", input: "\x1b[A\r", idx: 2},
+		{name: "filtered arrows", input: "prod\x1b[B\r", idx: 2},
+		{name: "no matches ignores enter", input: "zzz\r\x7f\x7f\x7fdev\r", idx: 1},
+		{name: "clear query", input: "dev\x15\r", idx: 0},
+		{name: "interrupt", input: "pr\x03", err: ErrInterrupted},
+		{name: "escape", input: "pr\x1b", err: ErrInterrupted},
+		{name: "escape before key", input: "\x1bdev\r", err: ErrInterrupted},
+		{name: "eof", input: "pr", err: io.EOF},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var out strings.Builder
+
+			p := &picker{
+				in:    bufio.NewReader(strings.NewReader(tt.input)),
+				out:   &out,
+				title: "Please select the account",
+				items: items,
+			}
+
+			idx, err := p.run()
+			if tt.err != nil {
+				require.ErrorIs(t, err, tt.err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.idx, idx)
+			require.Contains(t, out.String(), "Please select the account")
+		})
+	}
+}
+
+func TestPickerWidth(t *testing.T) {
+	t.Parallel()
+
+	items := []string{
+		"Request:\n  ID: 1\n  Account: prod (111111111111)\n  Role: Admin\n  Justification: " +
+			strings.Repeat("investigate ", 20),
+		"Request:\n  ID: 2\n  Account: dev (222222222222)\n  Role: ReadOnly",
+	}
+
+	var out strings.Builder
+
+	p := &picker{
+		in:    bufio.NewReader(strings.NewReader("in" + "\x7f\x7f" + "\x1b[B\r")),
+		out:   &out,
+		title: "Please select the request",
+		items: items,
+		width: 30,
+	}
+
+	idx, err := p.run()
+	require.NoError(t, err)
+	require.Equal(t, 1, idx)
+
+	escapes := regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]`)
+
+	for _, row := range strings.Split(escapes.ReplaceAllString(out.String(), ""), "\r\n") {
+		require.Less(t, len([]rune(strings.TrimPrefix(row, "\r"))), 30, row)
+	}
+}
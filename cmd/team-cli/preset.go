@@ -0,0 +1,166 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// Preset holds default values for the flags of the request command.
+type Preset struct {
+	Account      string `json:"account,omitempty"`
+	Role         string `json:"role,omitempty"`
+	Start        string `json:"start,omitempty"`
+	Timezone     string `json:"timezone,omitempty"`
+	Duration     int    `json:"duration,omitempty"`
+	Ticket       string `json:"ticket,omitempty"`
+	TicketPrefix string `json:"ticket_prefix,omitempty"`
+	Reason       string `json:"reason,omitempty"`
+}
+
+func presetSaveCmdRun(cmd *cobra.Command, args []string) error {
+	preset := &Preset{}
+
+	for flag, value := range map[string]*string{
+		"account":       &preset.Account,
+		"role":          &preset.Role,
+		"start":         &preset.Start,
+		"timezone":      &preset.Timezone,
+		"ticket":        &preset.Ticket,
+		"ticket-prefix": &preset.TicketPrefix,
+		"reason":        &preset.Reason,
+	} {
+		var err error
+
+		*value, err = cmd.Flags().GetString(flag)
+		if err != nil {
+			return fmt.Errorf("%s flag: %w", flag, err)
+		}
+	}
+
+	duration, err := cmd.Flags().GetInt("duration")
+	if err != nil {
+		return fmt.Errorf("duration flag: %w", err)
+	}
+
+	if duration < 0 {
+		return fmt.Errorf("%w: duration must be positive", ErrInvalid)
+	}
+
+	preset.Duration = duration
+
+	if *preset == (Preset{}) {
+		return fmt.Errorf("%w: at least one value must be provided", ErrInvalid)
+	}
+
+	name := args[0]
+	replaced := false
+
+	if err := updateConfig(func(cfg *Config) error {
+		if cfg.Presets == nil {
+			cfg.Presets = make(map[string]*Preset)
+		}
+
+		_, replaced = cfg.Presets[name]
+		cfg.Presets[name] = preset
+
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+
+	fmt.Fprintln(out)
+
+	if replaced {
+		fmt.Fprintf(out, "Preset %q updated\n", name)
+	} else {
+		fmt.Fprintf(out, "Preset %q saved\n", name)
+	}
+
+	return nil
+}
+
+func presetListCmdRun(cmd *cobra.Command, _ []string) error {
+	cfg, err := readConfig()
+	if err != nil {
+		return fmt.Errorf("could not read config: %w", err)
+	}
+
+	out := cmd.OutOrStdout()
+
+	fmt.Fprintln(out)
+
+	if len(cfg.Presets) == 0 {
+		fmt.Fprintln(out, "No presets saved")
+
+		return nil
+	}
+
+	fmt.Fprintln(out, "Presets:")
+
+	for _, name := range slices.Sorted(maps.Keys(cfg.Presets)) {
+		fmt.Fprintf(out, "  %s: %s\n", name, cfg.Presets[name])
+	}
+
+	return nil
+}
+
+func presetDeleteCmdRun(cmd *cobra.Command, args []string) error {
+	name := args[0]
+
+	if err := updateConfig(func(cfg *Config) error {
+		if _, ok := cfg.Presets[name]; !ok {
+			return fmt.Errorf("%w: preset %q not found", ErrInvalid, name)
+		}
+
+		delete(cfg.Presets, name)
+
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(cmd.OutOrStdout())
+	fmt.Fprintf(cmd.OutOrStdout(), "Preset %q deleted\n", name)
+
+	return nil
+}
+
+func (p *Preset) String() string {
+	var fields []string
+
+	add := func(name string, value string) {
+		if value != "" {
+			fields = append(fields, fmt.Sprintf("%s=%q", name, value))
+		}
+	}
+
+	add("account", p.Account)
+	add("role", p.Role)
+	add("start", p.Start)
+	add("timezone", p.Timezone)
+
+	if p.Duration != 0 {
+		fields = append(fields, fmt.Sprintf("duration=%d", p.Duration))
+	}
+
+	add("ticket", p.Ticket)
+	add("ticket_prefix", p.TicketPrefix)
+	add("reason", p.Reason)
+
+	return strings.Join(fields, " ")
+}
+
+// withTicketPrefix prepends the prefix to a ticket, unless it is already present.
+func withTicketPrefix(prefix string, ticket string) string {
+	if ticket == "" || strings.HasPrefix(ticket, prefix) {
+		return ticket
+	}
+
+	return prefix + ticket
+}
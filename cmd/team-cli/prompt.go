@@ -2,16 +2,116 @@ package main
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
 )
 
-func promptBool(msg string) (bool, error) {
+var ErrInputRequired = errors.New("input required")
+
+// prompter reads interactive input for a single command invocation.
+type prompter struct {
+	in  *bufio.Reader
+	out io.Writer
+
+	// tty is set when both input and output are a terminal, enabling the interactive picker.
+	tty *os.File
+
+	// noInput causes every prompt to fail, rather than waiting for input which will never arrive.
+	noInput bool
+}
+
+func newPrompter(cmd *cobra.Command) *prompter {
+	p := &prompter{
+		in:  bufio.NewReader(cmd.InOrStdin()),
+		out: cmd.OutOrStdout(),
+	}
+
+	in, inOk := cmd.InOrStdin().(*os.File)
+	out, outOk := cmd.OutOrStdout().(*os.File)
+
+	if inOk && outOk && term.IsTerminal(int(in.Fd())) && term.IsTerminal(int(out.Fd())) {
+		p.tty = in
+	}
+
+	// Input is disabled by default when stdin is a file or pipe, unless explicitly enabled
+	if cmd.Flags().Changed("no-input") {
+		p.noInput, _ = cmd.Flags().GetBool("no-input")
+	} else if inOk && !term.IsTerminal(int(in.Fd())) {
+		p.noInput = true
+	}
+
+	return p
+}
+
+// pick selects one of the items, returning its index. A fuzzy search picker is used on terminals, otherwise the items
+// are listed and selected by number.
+func (p *prompter) pick(title string, msg string, flag string, items []string) (int, error) {
+	if p.noInput {
+		return 0, inputRequired(msg, flag, "input is disabled")
+	}
+
+	if p.tty != nil {
+		idx, err := p.pickInteractive(title, items)
+		if err != nil {
+			return 0, err
+		}
+
+		fmt.Fprintf(p.out, "%s%s\n", msg, summary(items[idx]))
+
+		return idx, nil
+	}
+
+	fmt.Fprintln(p.out, title+":")
+
+	for i, item := range items {
+		fmt.Fprintf(p.out, "  [%d] %s\n", i+1, item)
+	}
+
+	fmt.Fprintln(p.out)
+
+	idx, err := p.promptSelection(msg, flag, 1, len(items))
+	if err != nil {
+		return 0, err
+	}
+
+	return idx - 1, nil
+}
+
+func (p *prompter) pickInteractive(title string, items []string) (int, error) {
+	state, err := term.MakeRaw(int(p.tty.Fd()))
+	if err != nil {
+		return 0, fmt.Errorf("could not enable raw mode: %w", err)
+	}
+
+	defer func() {
+		_ = term.Restore(int(p.tty.Fd()), state)
+	}()
+
+	pk := &picker{
+		in:    p.in,
+		out:   p.out,
+		title: title,
+		items: items,
+	}
+
+	if width, _, err := term.GetSize(int(p.tty.Fd())); err == nil {
+		pk.width = width
+	}
+
+	return pk.run()
+}
+
+func (p *prompter) promptBool(msg string, flag string) (bool, error) {
 	for {
-		line, err := prompt(msg)
+		line, err := p.prompt(msg, flag)
 		if err != nil {
 			return false, err
 		}
@@ -25,9 +125,9 @@ func promptBool(msg string) (bool, error) {
 	}
 }
 
-func promptSelection(msg string, min int, max int) (int, error) {
+func (p *prompter) promptSelection(msg string, flag string, min int, max int) (int, error) {
 	for {
-		line, err := prompt(msg)
+		line, err := p.prompt(msg, flag)
 		if err != nil {
 			return 0, err
 		}
@@ -45,19 +145,17 @@ func promptSelection(msg string, min int, max int) (int, error) {
 	}
 }
 
-func promptTime(msg string) (time.Time, error) {
+func (p *prompter) promptTime(msg string, flag string, loc *time.Location) (time.Time, error) {
 	for {
-		line, err := prompt(msg)
+		line, err := p.prompt(msg, flag)
 		if err != nil {
 			return time.Time{}, err
 		}
 
-		if strings.EqualFold(line, "now") || line == "" {
-			return time.Time{}, nil
-		}
-
-		val, err := time.ParseInLocation(time.DateTime, line, time.Local)
+		val, err := parseStartTime(line, timeNow(), loc)
 		if err != nil {
+			fmt.Fprintln(p.out, err)
+
 			continue
 		}
 
@@ -65,9 +163,9 @@ func promptTime(msg string) (time.Time, error) {
 	}
 }
 
-func promptString(msg string) (string, error) {
+func (p *prompter) promptString(msg string, flag string) (string, error) {
 	for {
-		line, err := prompt(msg)
+		line, err := p.prompt(msg, flag)
 		if err != nil {
 			return "", err
 		}
@@ -80,17 +178,28 @@ func promptString(msg string) (string, error) {
 	}
 }
 
-var ioReader *bufio.Reader
+// prompt reads a line of input. The flag names the command line flag which can be used instead, if any.
+func (p *prompter) prompt(msg string, flag string) (string, error) {
+	if p.noInput {
+		return "", inputRequired(msg, flag, "input is disabled")
+	}
+
+	_, _ = fmt.Fprint(p.out, msg)
 
-func prompt(msg string) (string, error) {
-	fmt.Print(msg)
+	input, err := p.in.ReadString('\n')
 
-	if ioReader == nil {
-		ioReader = bufio.NewReader(os.Stdin)
+	// Accept a final line without a trailing newline
+	if errors.Is(err, io.EOF) && strings.TrimSpace(input) != "" {
+		err = nil
 	}
 
-	input, err := ioReader.ReadString('\n')
 	if err != nil {
+		fmt.Fprintln(p.out)
+
+		if errors.Is(err, io.EOF) {
+			return "", inputRequired(msg, flag, "end of input")
+		}
+
 		return "", err
 	}
 
@@ -98,3 +207,18 @@ func prompt(msg string) (string, error) {
 
 	return input, nil
 }
+
+// inputRequired describes a value which could not be prompted for, naming the flag to provide it with.
+func inputRequired(msg string, flag string, reason string) error {
+	field := strings.TrimSpace(msg)
+
+	if idx := strings.IndexAny(field, "?:("); idx > 0 {
+		field = strings.TrimSpace(field[:idx])
+	}
+
+	if flag == "" {
+		return fmt.Errorf("%w: %s: %s required", ErrInputRequired, reason, strings.ToLower(field))
+	}
+
+	return fmt.Errorf("%w: %s: %s required, pass %s", ErrInputRequired, reason, strings.ToLower(field), flag)
+}
@@ -1,58 +1,198 @@
 package main
 
 import (
+	"cmp"
 	"errors"
 	"fmt"
 	"maps"
+	"os"
+	"os/signal"
 	"slices"
 	"strings"
 	"time"
 
-	"github.com/csnewman/team-cli/internal/team"
+	"github.com/csnewman/team-cli/team"
 	"github.com/spf13/cobra"
 )
 
 var ErrInvalid = errors.New("invalid")
 
-func requestCmdRun(cmd *cobra.Command, args []string) error {
+func requestCmdRun(cmd *cobra.Command, _ []string) error {
+	fromFile, err := cmd.Flags().GetString("from-file")
+	if err != nil {
+		return fmt.Errorf("from-file flag: %w", err)
+	}
+
+	wait, err := cmd.Flags().GetBool("wait")
+	if err != nil {
+		return fmt.Errorf("wait flag: %w", err)
+	}
+
+	out := cmd.OutOrStdout()
+	p := newPrompter(cmd)
+
+	if fromFile != "" {
+		for _, flag := range []string{"account", "role", "start", "duration", "ticket", "reason", "preset"} {
+			if cmd.Flags().Changed(flag) {
+				return fmt.Errorf("%w: --%s cannot be used with --from-file", ErrInvalid, flag)
+			}
+		}
+
+		timezone, err := cmd.Flags().GetString("timezone")
+		if err != nil {
+			return fmt.Errorf("timezone flag: %w", err)
+		}
+
+		autoConfirm, err := cmd.Flags().GetBool("confirm")
+		if err != nil {
+			return fmt.Errorf("confirm flag: %w", err)
+		}
+
+		loc, err := loadLocation(timezone)
+		if err != nil {
+			return err
+		}
+
+		cfg, err := readConfigReAuth(cmd.Context(), p)
+		if err != nil {
+			return fmt.Errorf("could not read config and authenticate: %w", err)
+		}
+
+		return requestFromFileRun(cmd, cfg, fromFile, loc, autoConfirm)
+	}
+
+	cfg, err := readConfigReAuth(cmd.Context(), p)
+	if err != nil {
+		return fmt.Errorf("could not read config and authenticate: %w", err)
+	}
+
+	req, err := promptAccessRequest(cmd, p, cfg)
+	if err != nil {
+		return err
+	}
+
+	id, err := cfg.client().Request(cmd.Context(), req)
+	if err != nil {
+		return fmt.Errorf("could not request role: %w", err)
+	}
+
+	fmt.Fprintln(out, "Request submitted")
+	fmt.Fprintf(out, "Request ID: %s\n", id)
+
+	if !wait {
+		return nil
+	}
+
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Waiting for the session to start")
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+	defer stop()
+
+	n := newNotifiers(cmd, cfg)
+	defer n.close()
+
+	session, err := waitForSession(ctx, cfg, n, id)
+	if err != nil {
+		return fmt.Errorf("could not wait for session: %w", err)
+	}
+
+	if session.Status != "in progress" {
+		return fmt.Errorf("%w: request is %s", ErrInvalid, describeStatus(session))
+	}
+
+	fmt.Fprintf(out, "Session started, ends %q\n", fmtDate(session.EndTime))
+
+	return nil
+}
+
+// loadLocation returns the named timezone, defaulting to local time.
+func loadLocation(timezone string) (*time.Location, error) {
+	if timezone == "" {
+		return time.Local, nil
+	}
+
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unknown timezone %q: %w", ErrInvalid, timezone, err)
+	}
+
+	return loc, nil
+}
+
+// promptAccessRequest builds a request from the request flags, prompting for any missing values and confirmation.
+func promptAccessRequest(cmd *cobra.Command, p *prompter, cfg *Config) (*team.AccessRequest, error) {
 	account, err := cmd.Flags().GetString("account")
 	if err != nil {
-		return fmt.Errorf("account flag: %w", err)
+		return nil, fmt.Errorf("account flag: %w", err)
 	}
 
 	role, err := cmd.Flags().GetString("role")
 	if err != nil {
-		return fmt.Errorf("role flag: %w", err)
+		return nil, fmt.Errorf("role flag: %w", err)
 	}
 
 	start, err := cmd.Flags().GetString("start")
 	if err != nil {
-		return fmt.Errorf("start flag: %w", err)
+		return nil, fmt.Errorf("start flag: %w", err)
+	}
+
+	timezone, err := cmd.Flags().GetString("timezone")
+	if err != nil {
+		return nil, fmt.Errorf("timezone flag: %w", err)
 	}
 
 	duration, err := cmd.Flags().GetInt("duration")
 	if err != nil {
-		return fmt.Errorf("duration flag: %w", err)
+		return nil, fmt.Errorf("duration flag: %w", err)
 	}
 
 	ticket, err := cmd.Flags().GetString("ticket")
 	if err != nil {
-		return fmt.Errorf("ticket flag: %w", err)
+		return nil, fmt.Errorf("ticket flag: %w", err)
 	}
 
 	reason, err := cmd.Flags().GetString("reason")
 	if err != nil {
-		return fmt.Errorf("reason flag: %w", err)
+		return nil, fmt.Errorf("reason flag: %w", err)
 	}
 
 	autoConfirm, err := cmd.Flags().GetBool("confirm")
 	if err != nil {
-		return fmt.Errorf("confirm flag: %w", err)
+		return nil, fmt.Errorf("confirm flag: %w", err)
 	}
 
-	cfg, err := readConfigReAuth(cmd.Context())
+	presetName, err := cmd.Flags().GetString("preset")
 	if err != nil {
-		return fmt.Errorf("could not read config and authenticate: %w", err)
+		return nil, fmt.Errorf("preset flag: %w", err)
+	}
+
+	out := p.out
+
+	var ticketPrefix string
+
+	// Explicit flags take priority over the preset
+	if presetName != "" {
+		preset, ok := cfg.Presets[presetName]
+		if !ok {
+			return nil, fmt.Errorf("%w: preset %q not found", ErrInvalid, presetName)
+		}
+
+		account = cmp.Or(account, preset.Account)
+		role = cmp.Or(role, preset.Role)
+		start = cmp.Or(start, preset.Start)
+		timezone = cmp.Or(timezone, preset.Timezone)
+		duration = cmp.Or(duration, preset.Duration)
+		ticket = cmp.Or(ticket, preset.Ticket)
+		reason = cmp.Or(reason, preset.Reason)
+		ticketPrefix = preset.TicketPrefix
+	}
+
+	ticket = withTicketPrefix(ticketPrefix, ticket)
+
+	loc, err := loadLocation(timezone)
+	if err != nil {
+		return nil, err
 	}
 
 	var (
@@ -62,48 +202,34 @@ func requestCmdRun(cmd *cobra.Command, args []string) error {
 
 	// If account & role are pre-provided, try the cache first
 	if account != "" && role != "" {
-		cache, ok, err := getAccountsCache()
+		cache, ok, err := getFreshAccountsCache(cfg)
 		if err != nil {
-			return fmt.Errorf("could not get accounts cache: %w", err)
+			return nil, fmt.Errorf("could not get accounts cache: %w", err)
 		}
 
 		if ok {
-			for _, acc := range cache.Accounts {
-				if !strings.EqualFold(acc.ID, account) && !strings.EqualFold(acc.Name, account) {
-					continue
-				}
+			selectedAccount = findAccount(cache.Accounts, account)
 
-				selectedAccount = acc
-
-				for _, perm := range acc.Roles {
-					if !strings.EqualFold(perm.ID, role) && !strings.EqualFold(perm.Name, role) {
-						continue
-					}
-
-					selectedRole = perm
-
-					break
-				}
-
-				break
+			if selectedAccount != nil {
+				selectedRole = findRole(selectedAccount, role)
 			}
 		}
 	}
 
 	if selectedAccount != nil && selectedRole != nil {
-		fmt.Println()
-		fmt.Println("AWS account & role found in cache")
-		fmt.Println()
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "AWS account & role found in cache")
+		fmt.Fprintln(out)
 	} else {
-		fmt.Println()
-		fmt.Println("Fetching AWS accounts")
-		accounts, err := team.FetchAccounts(cmd.Context(), cfg.ServerConfig, cfg.AuthToken)
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "Fetching AWS accounts")
+		accounts, err := cfg.client().FetchAccounts(cmd.Context())
 		if err != nil {
-			return fmt.Errorf("could not fetch accounts: %w", err)
+			return nil, fmt.Errorf("could not fetch accounts: %w", err)
 		}
 
-		if err := cacheAccounts(accounts); err != nil {
-			return fmt.Errorf("could not cache accounts: %w", err)
+		if err := cacheAccounts(cfg, accounts); err != nil {
+			return nil, fmt.Errorf("could not cache accounts: %w", err)
 		}
 
 		sorted := slices.SortedFunc(maps.Values(accounts), func(a *team.Account, b *team.Account) int {
@@ -112,35 +238,27 @@ func requestCmdRun(cmd *cobra.Command, args []string) error {
 
 		// Select account
 		if len(sorted) == 0 {
-			return fmt.Errorf("%w: no accounts found", ErrInvalid)
+			return nil, fmt.Errorf("%w: no accounts found", ErrInvalid)
 		}
 
 		if account == "" {
-			fmt.Println()
-			fmt.Println("Please select the account:")
-			for i, acc := range sorted {
-				fmt.Printf("  [%d] id=%q name=%q\n", i+1, acc.ID, acc.Name)
+			items := make([]string, 0, len(sorted))
+			for _, acc := range sorted {
+				items = append(items, fmt.Sprintf("id=%q name=%q", acc.ID, acc.Name))
 			}
 
-			fmt.Println()
+			fmt.Fprintln(out)
 
-			idx, err := promptSelection("Account option? ", 1, len(sorted))
+			idx, err := p.pick("Please select the account", "Account option? ", "--account", items)
 			if err != nil {
-				return fmt.Errorf("could not select account: %w", err)
+				return nil, fmt.Errorf("could not select account: %w", err)
 			}
 
-			selectedAccount = sorted[idx-1]
+			selectedAccount = sorted[idx]
 		} else {
-			for _, acc := range accounts {
-				if strings.EqualFold(acc.ID, account) || strings.EqualFold(acc.Name, account) {
-					selectedAccount = acc
-
-					break
-				}
-			}
-
+			selectedAccount = findAccount(accounts, account)
 			if selectedAccount == nil {
-				return fmt.Errorf("%w: account %q not found", ErrInvalid, account)
+				return nil, fmt.Errorf("%w: account %q not found", ErrInvalid, account)
 			}
 		}
 
@@ -150,122 +268,117 @@ func requestCmdRun(cmd *cobra.Command, args []string) error {
 		})
 
 		if role == "" {
-			fmt.Println()
-			fmt.Println("Please select the role:")
-			for i, r := range allowedRoles {
-				fmt.Printf(
-					"  [%d] name=%q max_duration_with_approval=%d max_duration_without_approval=%d\n",
-					i+1,
+			items := make([]string, 0, len(allowedRoles))
+			for _, r := range allowedRoles {
+				items = append(items, fmt.Sprintf(
+					"name=%q max_duration_with_approval=%d max_duration_without_approval=%d",
 					r.Name,
 					r.MaxDurApproval,
 					r.MaxDurNoApproval,
-				)
+				))
 			}
 
-			fmt.Println()
+			fmt.Fprintln(out)
 
-			idx, err := promptSelection("Role option? ", 1, len(sorted))
+			idx, err := p.pick("Please select the role", "Role option? ", "--role", items)
 			if err != nil {
-				return fmt.Errorf("could not select role: %w", err)
+				return nil, fmt.Errorf("could not select role: %w", err)
 			}
 
-			selectedRole = allowedRoles[idx-1]
+			selectedRole = allowedRoles[idx]
 		} else {
-			for _, perm := range allowedRoles {
-				if strings.EqualFold(perm.ID, role) || strings.EqualFold(perm.Name, role) {
-					selectedRole = perm
-
-					break
-				}
-			}
-
+			selectedRole = findRole(selectedAccount, role)
 			if selectedRole == nil {
-				return fmt.Errorf("%w: role %q not found", ErrInvalid, role)
+				return nil, fmt.Errorf("%w: role %q not found", ErrInvalid, role)
 			}
 		}
 	}
 
 	var startTime time.Time
 
-	if start == "" {
-		startTime, err = promptTime("Start time (e.g. 2006-01-02 15:04:05)? [now] ")
+	// The start is optional, so defaults to now when it cannot be prompted for
+	if start == "" && !p.noInput {
+		startTime, err = p.promptTime("Start time (e.g. "+startTimeExamples+")? [now] ", "--start", loc)
 		if err != nil {
-			return fmt.Errorf("could not select time: %w", err)
+			return nil, fmt.Errorf("could not select time: %w", err)
 		}
-	} else if !strings.EqualFold(start, "now") {
-		startTime, err = time.ParseInLocation(time.DateTime, start, time.Local)
+	} else {
+		startTime, err = parseStartTime(start, timeNow(), loc)
 		if err != nil {
-			return fmt.Errorf("could not parse start time: %w", err)
+			return nil, fmt.Errorf("could not parse start time: %w", err)
 		}
 	}
 
 	if duration == 0 {
-		duration, err = promptSelection(
+		duration, err = p.promptSelection(
 			fmt.Sprintf("Duration (1-%d hours)? ", selectedRole.MaxDurApproval),
+			"--duration",
 			1, selectedRole.MaxDurApproval,
 		)
 		if err != nil {
-			return fmt.Errorf("could not select duration: %w", err)
+			return nil, fmt.Errorf("could not select duration: %w", err)
 		}
 	} else if duration < 1 || duration > selectedRole.MaxDurApproval {
-		return fmt.Errorf("%w: duration must be between 1 and %d", ErrInvalid, duration)
+		return nil, fmt.Errorf("%w: duration must be between 1 and %d", ErrInvalid, selectedRole.MaxDurApproval)
 	}
 
 	if ticket == "" {
 		for {
-			ticket, err = promptString("Ticket: ")
+			ticket, err = p.promptString("Ticket: "+ticketPrefix, "--ticket")
 			if err != nil {
-				return fmt.Errorf("could not select ticket: %w", err)
+				return nil, fmt.Errorf("could not select ticket: %w", err)
 			}
 
+			ticket = withTicketPrefix(ticketPrefix, ticket)
+
 			if team.TicketRegex.MatchString(ticket) {
 				break
 			}
 
-			fmt.Println("Ticket format is not valid")
+			fmt.Fprintln(out, "Ticket format is not valid")
 		}
 	} else if !team.TicketRegex.MatchString(ticket) {
-		return fmt.Errorf("%w: ticket format is no valid", ErrInvalid)
+		return nil, fmt.Errorf("%w: ticket format is no valid", ErrInvalid)
 	}
 
 	if reason == "" {
-		reason, err = promptString("Justification: ")
+		reason, err = p.promptString("Justification: ", "--reason")
 		if err != nil {
-			return fmt.Errorf("could not select justification: %w", err)
+			return nil, fmt.Errorf("could not select justification: %w", err)
 		}
 	}
 
-	fmt.Println("")
-	fmt.Println("Details:")
-	fmt.Printf("  Account: id=%q name=%q\n", selectedAccount.ID, selectedAccount.Name)
-	fmt.Printf("  Role: name=%q\n", selectedRole.Name)
+	fmt.Fprintln(out, "")
+	fmt.Fprintln(out, "Details:")
+	fmt.Fprintf(out, "  Account: id=%q name=%q\n", selectedAccount.ID, selectedAccount.Name)
+	fmt.Fprintf(out, "  Role: name=%q\n", selectedRole.Name)
 
 	if startTime.IsZero() {
-		fmt.Println("  Start: now")
+		fmt.Fprintln(out, "  Start: now")
 	} else {
-		fmt.Printf("  Start: %q\n", startTime)
+		fmt.Fprintf(out, "  Start: %q\n", fmtDateIn(startTime, loc))
 	}
 
-	fmt.Printf("  Duration: %v\n", duration)
-	fmt.Printf("  Requires approval: %v\n", duration > selectedRole.MaxDurNoApproval)
+	fmt.Fprintf(out, "  Duration: %v\n", duration)
+	fmt.Fprintf(out, "  Requires approval: %v\n", duration > selectedRole.MaxDurNoApproval)
 
-	fmt.Printf("  Ticket: %q\n", ticket)
-	fmt.Printf("  Justification: %q\n", reason)
+	fmt.Fprintf(out, "  Ticket: %q\n", ticket)
+	fmt.Fprintf(out, "  Justification: %q\n", reason)
 
-	fmt.Println()
+	fmt.Fprintln(out)
 
 	if !autoConfirm {
-		cont, err := promptBool("Confirm (y/n)? ")
+		cont, err := p.promptBool("Confirm (y/n)? ", "--confirm")
 		if err != nil {
-			return fmt.Errorf("could not select confirmation: %w", err)
+			return nil, fmt.Errorf("could not select confirmation: %w", err)
 		}
 
 		if !cont {
-			return fmt.Errorf("%w: confirmation rejected", ErrInvalid)
+			return nil, fmt.Errorf("%w: confirmation rejected", ErrInvalid)
 		}
 	}
 
-	id, err := team.Request(cmd.Context(), cfg.ServerConfig, cfg.AuthToken, &team.AccessRequest{
+	return &team.AccessRequest{
 		AccountID:     selectedAccount.ID,
 		AccountName:   selectedAccount.Name,
 		Role:          selectedRole.Name,
@@ -274,13 +387,27 @@ func requestCmdRun(cmd *cobra.Command, args []string) error {
 		StartTime:     startTime,
 		Justification: reason,
 		Ticket:        ticket,
-	})
-	if err != nil {
-		return fmt.Errorf("could not request role: %w", err)
+	}, nil
+}
+
+// findAccount returns the account matching the ID or name, ignoring case.
+func findAccount(accounts map[string]*team.Account, query string) *team.Account {
+	for _, acc := range accounts {
+		if strings.EqualFold(acc.ID, query) || strings.EqualFold(acc.Name, query) {
+			return acc
+		}
 	}
 
-	fmt.Println("Request submitted")
-	fmt.Printf("Request ID: %s\n", id)
+	return nil
+}
+
+// findRole returns the role of the account matching the ID or name, ignoring case.
+func findRole(acc *team.Account, query string) *team.Role {
+	for _, role := range acc.Roles {
+		if strings.EqualFold(role.ID, query) || strings.EqualFold(role.Name, query) {
+			return role
+		}
+	}
 
 	return nil
 }
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// timeNow is replaced in tests to produce stable output.
+var timeNow = time.Now
+
+const startTimeExamples = "now, +30m, 15:00, tomorrow 09:00, 2006-01-02 15:04:05"
+
+var clockLayouts = []string{"15:04", "15:04:05"}
+
+var dateTimeLayouts = []string{time.DateTime, "2006-01-02 15:04", time.RFC3339}
+
+// parseStartTime parses a request start time relative to now. Times without an offset are interpreted in loc. A zero
+// time is returned for "now".
+func parseStartTime(input string, now time.Time, loc *time.Location) (time.Time, error) {
+	input = strings.TrimSpace(input)
+	now = now.In(loc)
+
+	if input == "" || strings.EqualFold(input, "now") {
+		return time.Time{}, nil
+	}
+
+	val, err := parseStartTimeValue(input, now, loc)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if val.Before(now.Truncate(time.Minute)) {
+		return time.Time{}, fmt.Errorf("%w: start time %s is in the past", ErrInvalid, val.Format(time.DateTime))
+	}
+
+	return val, nil
+}
+
+func parseStartTimeValue(input string, now time.Time, loc *time.Location) (time.Time, error) {
+	if rel, ok := strings.CutPrefix(input, "+"); ok {
+		dur, err := time.ParseDuration(rel)
+		if err != nil || dur <= 0 {
+			return time.Time{}, fmt.Errorf("%w: invalid relative start time %q", ErrInvalid, input)
+		}
+
+		return now.Add(dur), nil
+	}
+
+	lower := strings.ToLower(input)
+
+	for _, day := range []struct {
+		prefix string
+		offset int
+	}{
+		{prefix: "today ", offset: 0},
+		{prefix: "tomorrow ", offset: 1},
+	} {
+		clock, ok := strings.CutPrefix(lower, day.prefix)
+		if !ok {
+			continue
+		}
+
+		val, ok := parseClock(strings.TrimSpace(clock), now, loc)
+		if !ok {
+			return time.Time{}, fmt.Errorf("%w: invalid time %q", ErrInvalid, clock)
+		}
+
+		return val.AddDate(0, 0, day.offset), nil
+	}
+
+	if val, ok := parseClock(input, now, loc); ok {
+		// A bare time refers to its next occurrence.
+		if val.Before(now.Truncate(time.Minute)) {
+			val = val.AddDate(0, 0, 1)
+		}
+
+		return val, nil
+	}
+
+	for _, layout := range dateTimeLayouts {
+		if val, err := time.ParseInLocation(layout, input, loc); err == nil {
+			return val, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("%w: unknown start time format %q (e.g. %s)", ErrInvalid, input, startTimeExamples)
+}
+
+// parseClock parses a time of day, returning it on the same day as now.
+func parseClock(input string, now time.Time, loc *time.Location) (time.Time, bool) {
+	for _, layout := range clockLayouts {
+		clock, err := time.ParseInLocation(layout, input, loc)
+		if err != nil {
+			continue
+		}
+
+		return time.Date(now.Year(), now.Month(), now.Day(), clock.Hour(), clock.Minute(), clock.Second(), 0, loc), true
+	}
+
+	return time.Time{}, false
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseStartTime(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2030, 1, 2, 15, 30, 10, 0, time.UTC)
+
+	paris, err := time.LoadLocation("Europe/Paris")
+	require.NoError(t, err)
+
+	tests := []struct {
+		input    string
+		loc      *time.Location
+		expected time.Time
+		err      bool
+	}{
+		{input: "", loc: time.UTC, expected: time.Time{}},
+		{input: "NOW", loc: time.UTC, expected: time.Time{}},
+		{input: "+30m", loc: time.UTC, expected: now.Add(30 * time.Minute)},
+		{input: "+1h30m", loc: time.UTC, expected: now.Add(90 * time.Minute)},
+		{input: "+0s", loc: time.UTC, err: true},
+		{input: "+soon", loc: time.UTC, err: true},
+		{input: "16:00", loc: time.UTC, expected: time.Date(2030, 1, 2, 16, 0, 0, 0, time.UTC)},
+		{input: "15:30", loc: time.UTC, expected: time.Date(2030, 1, 2, 15, 30, 0, 0, time.UTC)},
+		{input: "09:00", loc: time.UTC, expected: time.Date(2030, 1, 3, 9, 0, 0, 0, time.UTC)},
+		{input: "16:00", loc: paris, expected: time.Date(2030, 1, 3, 16, 0, 0, 0, paris)},
+		{input: "tomorrow 09:00", loc: time.UTC, expected: time.Date(2030, 1, 3, 9, 0, 0, 0, time.UTC)},
+		{input: "Today 18:00", loc: time.UTC, expected: time.Date(2030, 1, 2, 18, 0, 0, 0, time.UTC)},
+		{input: "today 09:00", loc: time.UTC, err: true},
+		{input: "tomorrow noon", loc: time.UTC, err: true},
+		{input: "2030-01-05 10:00:00", loc: paris, expected: time.Date(2030, 1, 5, 10, 0, 0, 0, paris)},
+		{input: "2030-01-05 10:00", loc: time.UTC, expected: time.Date(2030, 1, 5, 10, 0, 0, 0, time.UTC)},
+		{input: "2030-01-05T10:00:00+02:00", loc: time.UTC, expected: time.Date(2030, 1, 5, 8, 0, 0, 0, time.UTC)},
+		{input: "2030-01-01 10:00:00", loc: time.UTC, err: true},
+		{input: "next week", loc: time.UTC, err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			t.Parallel()
+
+			val, err := parseStartTime(tt.input, now, tt.loc)
+			if tt.err {
+				require.ErrorIs(t, err, ErrInvalid)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.True(t, tt.expected.Equal(val), "expected %v, got %v", tt.expected, val)
+		})
+	}
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/csnewman/team-cli/team"
+)
+
+var ErrTokenNotFound = errors.New("token not found")
+
+const (
+	// tokenStoreService names the entries written to the secure store.
+	tokenStoreService = "team-cli"
+
+	// tokenStoreTimeout limits how long the store can take, which may include the user unlocking it.
+	tokenStoreTimeout = time.Minute
+)
+
+// tokenStore holds auth tokens in the credential store of the OS, rather than the config file.
+type tokenStore interface {
+	// name identifies the store in the config, so tokens are only read from the store they were written to.
+	name() string
+
+	get(ctx context.Context, account string) (string, error)
+	set(ctx context.Context, account string, secret string) error
+}
+
+// newTokenStore returns the credential store of the OS, or nil if none is available, in which case tokens are written
+// to the config file. Setting TEAM_CLI_TOKEN_STORE to "file" disables the store.
+var newTokenStore = func() tokenStore {
+	if os.Getenv("TEAM_CLI_TOKEN_STORE") == "file" {
+		return nil
+	}
+
+	switch runtime.GOOS {
+	case "darwin":
+		if _, err := exec.LookPath("security"); err == nil {
+			return &keychainStore{}
+		}
+	case "windows":
+	default:
+		if _, err := exec.LookPath("secret-tool"); err == nil {
+			return &secretServiceStore{}
+		}
+	}
+
+	return nil
+}
+
+// keychainStore holds tokens in the macOS keychain.
+type keychainStore struct{}
+
+func (s *keychainStore) name() string {
+	return "keychain"
+}
+
+func (s *keychainStore) get(ctx context.Context, account string) (string, error) {
+	out, err := exec.CommandContext(ctx, "security", "find-generic-password", "-s", tokenStoreService, "-a", account, "-w").
+		Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+
+		// The item could not be found
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 44 {
+			return "", ErrTokenNotFound
+		}
+
+		return "", fmt.Errorf("could not read keychain: %w", err)
+	}
+
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
+
+func (s *keychainStore) set(ctx context.Context, account string, secret string) error {
+	// The secret is passed as an argument, as security reads prompted secrets from the terminal, and limits the length
+	// of commands read from stdin. The arguments of a process are only visible to its user.
+	out, err := exec.CommandContext(
+		ctx,
+		"security", "add-generic-password", "-U", "-s", tokenStoreService, "-a", account, "-w", secret,
+	).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("could not write keychain: %w: %s", err, bytes.TrimSpace(out))
+	}
+
+	return nil
+}
+
+// secretServiceStore holds tokens via the Secret Service API, such as in GNOME Keyring or KWallet.
+type secretServiceStore struct{}
+
+func (s *secretServiceStore) name() string {
+	return "secret-service"
+}
+
+func (s *secretServiceStore) get(ctx context.Context, account string) (string, error) {
+	var stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, "secret-tool", "lookup", "service", tokenStoreService, "account", account)
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+
+		// Missing secrets are reported by exiting without output
+		if errors.As(err, &exitErr) && stderr.Len() == 0 {
+			return "", ErrTokenNotFound
+		}
+
+		return "", fmt.Errorf("could not read secret: %w: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+
+	return string(out), nil
+}
+
+func (s *secretServiceStore) set(ctx context.Context, account string, secret string) error {
+	cmd := exec.CommandContext(
+		ctx,
+		"secret-tool", "store", "--label", "team-cli token", "service", tokenStoreService, "account", account,
+	)
+	cmd.Stdin = strings.NewReader(secret)
+
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("could not write secret: %w: %s", err, bytes.TrimSpace(out))
+	}
+
+	return nil
+}
+
+// tokenAccount returns the name of the entry holding the token of a profile. Entries are distinguished by config
+// file, so separate config directories do not share tokens.
+func tokenAccount(profile string) (string, error) {
+	path, err := configPath("config.json")
+	if err != nil {
+		return "", fmt.Errorf("failed to get config path: %w", err)
+	}
+
+	return profile + "@" + path, nil
+}
+
+// storeToken writes the token of a profile to the store, unless it is already held there.
+func storeToken(store tokenStore, profile string, cfg *Config) error {
+	enc, err := json.Marshal(cfg.AuthToken)
+	if err != nil {
+		return fmt.Errorf("could not marshal token: %w", err)
+	}
+
+	if cfg.AuthTokenStore == store.name() && cfg.storedToken == string(enc) {
+		return nil
+	}
+
+	account, err := tokenAccount(profile)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), tokenStoreTimeout)
+	defer cancel()
+
+	if err := store.set(ctx, account, string(enc)); err != nil {
+		return err
+	}
+
+	cfg.AuthTokenStore = store.name()
+	cfg.storedToken = string(enc)
+
+	return nil
+}
+
+// storeTokens moves the tokens of the file into the store, if available, returning a copy of the file to write in
+// which they are omitted. Tokens which could not be stored are left in the file.
+func storeTokens(file *configFile) *configFile {
+	store := newTokenStore()
+
+	out := *file
+	out.Profiles = make(map[string]*Config, len(file.Profiles))
+
+	for name, cfg := range file.Profiles {
+		// Tokens which were not read remain in the store
+		if cfg == nil || cfg.AuthToken == nil {
+			out.Profiles[name] = cfg
+
+			continue
+		}
+
+		stored := *cfg
+		stored.AuthTokenStore = ""
+
+		if store != nil {
+			if err := storeToken(store, name, cfg); err != nil {
+				slog.Warn("Could not store token securely, writing it to the config file", "profile", name, "err", err)
+			} else {
+				stored.AuthToken = nil
+				stored.AuthTokenStore = store.name()
+			}
+		}
+
+		out.Profiles[name] = &stored
+	}
+
+	return &out
+}
+
+// loadToken reads the token of a profile from the store it was written to. The token is left empty if it cannot be
+// read, so the user is asked to authenticate again.
+func loadToken(profile string, cfg *Config) {
+	if cfg.AuthToken != nil || cfg.AuthTokenStore == "" {
+		return
+	}
+
+	store := newTokenStore()
+	if store == nil || store.name() != cfg.AuthTokenStore {
+		slog.Warn("Token store is not available", "store", cfg.AuthTokenStore)
+
+		return
+	}
+
+	account, err := tokenAccount(profile)
+	if err != nil {
+		slog.Warn("Could not read token", "err", err)
+
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), tokenStoreTimeout)
+	defer cancel()
+
+	secret, err := store.get(ctx, account)
+	if err != nil {
+		slog.Warn("Could not read token", "store", cfg.AuthTokenStore, "err", err)
+
+		return
+	}
+
+	var token *team.AuthToken
+
+	if err := json.Unmarshal([]byte(secret), &token); err != nil {
+		slog.Warn("Could not parse stored token", "err", err)
+
+		return
+	}
+
+	cfg.AuthToken = token
+	cfg.storedToken = secret
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenStores(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the token stores are faked with symlinks")
+	}
+
+	// ID and refresh tokens together can exceed the line length of interactive commands
+	secret := `{"id_token": "` + strings.Repeat("x", 8192) + `", "note": "it's \"quoted\""}`
+
+	stores := map[string]tokenStore{
+		"security":    &keychainStore{},
+		"secret-tool": &secretServiceStore{},
+	}
+
+	for bin, store := range stores {
+		t.Run(store.name(), func(t *testing.T) {
+			dir := t.TempDir()
+			require.NoError(t, os.Symlink(os.Args[0], filepath.Join(dir, bin)))
+
+			t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+			t.Setenv("TEAM_CLI_TEST_SECRETS", t.TempDir())
+
+			ctx := context.Background()
+
+			_, err := store.get(ctx, "alice@config.json")
+			require.ErrorIs(t, err, ErrTokenNotFound)
+
+			require.NoError(t, store.set(ctx, "alice@config.json", secret))
+
+			stored, err := store.get(ctx, "alice@config.json")
+			require.NoError(t, err)
+			require.Equal(t, secret, stored)
+
+			require.NoError(t, store.set(ctx, "alice@config.json", "updated"))
+
+			stored, err = store.get(ctx, "alice@config.json")
+			require.NoError(t, err)
+			require.Equal(t, "updated", stored)
+		})
+	}
+}
@@ -0,0 +1,435 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"os/signal"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/csnewman/team-cli/team"
+	"github.com/spf13/cobra"
+)
+
+// watchRetryDelay is the delay before resubscribing once a watch fails.
+var watchRetryDelay = 5 * time.Second
+
+// watchEvent is either the full set of requests, received whenever the watch (re)subscribes, or a single change. The
+// watch only fails if it could not initially subscribe.
+type watchEvent struct {
+	snapshot []*team.PermissionRequest
+	change   *team.PermissionRequest
+	err      error
+}
+
+// approvalWatch keeps the set of requests awaiting approval by the user up to date, while prompting for responses.
+type approvalWatch struct {
+	ctx       context.Context
+	p         *prompter
+	client    team.API
+	notifiers *notifiers
+	email     string
+	pending   map[string]*team.PermissionRequest
+	events    chan *watchEvent
+	lines     chan string
+
+	// status holds the messages printed since the list was last drawn, which are shown again under the list on
+	// terminals, as drawing clears the screen.
+	status []string
+}
+
+// watchRequests delivers events until the context is cancelled, resubscribing after failures.
+func (w *approvalWatch) watchRequests() {
+	subscribed := false
+
+	for {
+		err := w.client.WatchRequests(
+			w.ctx,
+			func(ctx context.Context) error {
+				requests, err := w.client.ListRequests(ctx, team.ListRequestsFilterRequiresMyApproval)
+				if err != nil {
+					return fmt.Errorf("could not fetch requests: %w", err)
+				}
+
+				subscribed = true
+
+				w.send(&watchEvent{snapshot: requests})
+
+				return nil
+			},
+			func(req *team.PermissionRequest) {
+				w.send(&watchEvent{change: req})
+			},
+		)
+		if w.ctx.Err() != nil {
+			return
+		}
+
+		if !subscribed {
+			w.send(&watchEvent{err: fmt.Errorf("could not watch requests: %w", err)})
+
+			return
+		}
+
+		slog.Warn("Watching requests failed, retrying", "err", err, "delay", watchRetryDelay)
+
+		select {
+		case <-time.After(watchRetryDelay):
+		case <-w.ctx.Done():
+			return
+		}
+	}
+}
+
+func (w *approvalWatch) send(event *watchEvent) {
+	select {
+	case w.events <- event:
+	case <-w.ctx.Done():
+	}
+}
+
+// readLines delivers lines of input, closing the channel at the end of the input.
+func (w *approvalWatch) readLines() {
+	defer close(w.lines)
+
+	for {
+		line, err := w.p.in.ReadString('\n')
+		if line != "" || err == nil {
+			select {
+			case w.lines <- strings.TrimSpace(line):
+			case <-w.ctx.Done():
+				return
+			}
+		}
+
+		if err != nil {
+			return
+		}
+	}
+}
+
+// apply updates the pending set with the event, returning a description of any change to it.
+func (w *approvalWatch) apply(event *watchEvent) string {
+	if event.snapshot != nil {
+		w.pending = make(map[string]*team.PermissionRequest)
+
+		for _, req := range event.snapshot {
+			w.pending[req.ID] = req
+		}
+
+		return ""
+	}
+
+	req := event.change
+	_, known := w.pending[req.ID]
+
+	switch {
+	case req.RequiresApprovalBy(w.email):
+		w.pending[req.ID] = req
+
+		if !known {
+			w.notifiers.notify(approvalRequiredEvent(req))
+
+			return fmt.Sprintf("New request from %s for role %q in account %q", req.Email, req.Role, req.AccountName)
+		}
+
+		return ""
+	case known:
+		delete(w.pending, req.ID)
+
+		return fmt.Sprintf("Request %s from %s is now %s", req.ID, req.Email, describeStatus(req))
+	default:
+		return ""
+	}
+}
+
+// describeStatus returns the status of the request, with the approver if known.
+func describeStatus(req *team.PermissionRequest) string {
+	if req.Approver != "" && (req.Status == "approved" || req.Status == "rejected") {
+		return req.Status + " by " + req.Approver
+	}
+
+	return req.Status
+}
+
+func (w *approvalWatch) sorted() []*team.PermissionRequest {
+	return slices.SortedFunc(maps.Values(w.pending), func(a *team.PermissionRequest, b *team.PermissionRequest) int {
+		return cmp.Or(a.CreatedAt.Compare(b.CreatedAt), strings.Compare(a.ID, b.ID))
+	})
+}
+
+// draw prints the pending requests, clearing the screen first on terminals.
+func (w *approvalWatch) draw(requests []*team.PermissionRequest) {
+	out := w.p.out
+
+	if w.p.tty != nil {
+		fmt.Fprint(out, "\033[H\033[2J")
+	}
+
+	fmt.Fprintln(out)
+
+	if len(requests) == 0 {
+		fmt.Fprintln(out, "There are no requests to approve, waiting for new requests")
+	} else {
+		fmt.Fprintln(out, "Pending requests:")
+
+		for i, req := range requests {
+			fmt.Fprintf(out, "  [%d] requester=%q account=%q role=%q start_time=%q duration=%q ticket=%q\n",
+				i+1, req.Email, req.AccountName, req.Role, fmtDate(req.StartTime), req.Duration+" hours", req.TicketNo)
+		}
+	}
+
+	fmt.Fprintln(out)
+
+	if len(w.status) > 0 {
+		for _, msg := range w.status {
+			fmt.Fprintln(out, msg)
+		}
+
+		fmt.Fprintln(out)
+
+		w.status = nil
+	}
+
+	if w.p.noInput {
+		return
+	}
+
+	if len(requests) > 0 {
+		fmt.Fprint(out, "Request option, or q to quit? ")
+	} else {
+		fmt.Fprint(out, "Enter q to quit: ")
+	}
+}
+
+// showStatus prints a message, keeping it to show again once the list is redrawn on terminals.
+func (w *approvalWatch) showStatus(msg string) {
+	fmt.Fprintln(w.p.out, msg)
+
+	if w.p.tty != nil {
+		w.status = append(w.status, msg)
+	}
+}
+
+var errWatchQuit = errors.New("quit")
+
+// readLine waits for a line of input, applying events meanwhile. If requested, it returns early when the pending set
+// changes, reporting the change.
+func (w *approvalWatch) readLine(returnOnChange bool) (string, bool, error) {
+	for {
+		select {
+		case event := <-w.events:
+			change := w.apply(event)
+
+			if change != "" {
+				fmt.Fprintln(w.p.out)
+				w.showStatus(change)
+			}
+
+			if returnOnChange && (change != "" || event.snapshot != nil) {
+				return "", true, nil
+			}
+		case line, ok := <-w.lines:
+			if !ok {
+				return "", false, errWatchQuit
+			}
+
+			return line, false, nil
+		case <-w.ctx.Done():
+			return "", false, errWatchQuit
+		}
+	}
+}
+
+// respond prompts for a response to the request, giving up if it is handled elsewhere in the meantime.
+func (w *approvalWatch) respond(req *team.PermissionRequest) error {
+	out := w.p.out
+
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Details:")
+	fmt.Fprintf(out, "  ID: %q\n", req.ID)
+	fmt.Fprintf(out, "  Requester: email=%q\n", req.Email)
+	fmt.Fprintf(out, "  Account: id=%q name=%q\n", req.AccountID, req.AccountName)
+	fmt.Fprintf(out, "  Role: name=%q\n", req.Role)
+	fmt.Fprintf(out, "  Created: %q\n", fmtDate(req.CreatedAt))
+	fmt.Fprintf(out, "  Start: %q\n", fmtDate(req.StartTime))
+	fmt.Fprintf(out, "  Duration: %q\n", req.Duration+" Hours")
+	fmt.Fprintf(out, "  Ticket: %q\n", req.TicketNo)
+	fmt.Fprintf(out, "  Justification: %q\n", req.Justification)
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Please select the response:")
+	fmt.Fprintln(out, "  [1] Approve")
+	fmt.Fprintln(out, "  [2] Approve without comment")
+	fmt.Fprintln(out, "  [3] Reject")
+	fmt.Fprintln(out, "  [4] Reject without comment")
+	fmt.Fprintln(out, "  [5] Skip")
+	fmt.Fprintln(out)
+
+	var option int
+
+	for option == 0 {
+		fmt.Fprint(out, "Response option? ")
+
+		line, err := w.readPending(req)
+		if err != nil {
+			return err
+		}
+
+		if n, err := strconv.Atoi(line); err == nil && n >= 1 && n <= 5 {
+			option = n
+		} else {
+			fmt.Fprintln(out, "Invalid option")
+		}
+	}
+
+	if option == 5 {
+		return nil
+	}
+
+	comment := "No comment."
+
+	if option == 1 || option == 3 {
+		fmt.Fprint(out, "Comment? ")
+
+		line, err := w.readPending(req)
+		if err != nil {
+			return err
+		}
+
+		comment = cmp.Or(line, comment)
+	}
+
+	status := "approved"
+
+	if option > 2 {
+		status = "rejected"
+	}
+
+	if err := w.client.Respond(w.ctx, &team.AccessResponse{
+		ID:      req.ID,
+		Status:  status,
+		Comment: comment,
+	}); err != nil {
+		return fmt.Errorf("could not respond to request: %w", err)
+	}
+
+	// The update is also received via the subscription, but may arrive after the list is redrawn
+	delete(w.pending, req.ID)
+
+	w.showStatus("Responded")
+
+	return nil
+}
+
+var errHandledElsewhere = errors.New("handled elsewhere")
+
+// readPending reads a line of input while the request is still pending.
+func (w *approvalWatch) readPending(req *team.PermissionRequest) (string, error) {
+	line, _, err := w.readLine(false)
+	if err != nil {
+		return "", err
+	}
+
+	if _, ok := w.pending[req.ID]; !ok {
+		return "", errHandledElsewhere
+	}
+
+	return line, nil
+}
+
+func approveWatchRun(cmd *cobra.Command, p *prompter, cfg *Config) error {
+	idTok, err := cfg.AuthToken.ParseIDToken()
+	if err != nil {
+		return fmt.Errorf("could not parse ID token: %w", err)
+	}
+
+	email, _ := idTok.Email.(string)
+
+	// Stopping cancels the context, ending the watch
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+	defer stop()
+
+	// Tokens are refreshed, as the watch can outlive them
+	var client team.API = team.NewClient(cfg.ServerConfig, team.RefreshingTokenSource(cfg.ServerConfig, cfg.AuthToken))
+
+	if cfg.daemon != nil {
+		client = cfg.daemon
+	}
+
+	n := newNotifiers(cmd, cfg)
+	defer n.close()
+
+	w := &approvalWatch{
+		ctx:       ctx,
+		p:         p,
+		client:    client,
+		notifiers: n,
+		email:     email,
+		pending:   make(map[string]*team.PermissionRequest),
+		events:    make(chan *watchEvent),
+		lines:     make(chan string),
+	}
+
+	go w.watchRequests()
+
+	if !p.noInput {
+		go w.readLines()
+	}
+
+	// Wait for the initial set of requests
+	select {
+	case event := <-w.events:
+		if event.err != nil {
+			return event.err
+		}
+
+		w.apply(event)
+	case <-ctx.Done():
+		return nil
+	}
+
+	for {
+		requests := w.sorted()
+		w.draw(requests)
+
+		line, changed, err := w.readLine(true)
+		if errors.Is(err, errWatchQuit) {
+			fmt.Fprintln(p.out)
+
+			return nil
+		}
+
+		if changed || line == "" {
+			continue
+		}
+
+		if strings.EqualFold(line, "q") {
+			return nil
+		}
+
+		idx, err := strconv.Atoi(line)
+		if err != nil || idx < 1 || idx > len(requests) {
+			w.showStatus("Invalid option")
+
+			continue
+		}
+
+		switch err := w.respond(requests[idx-1]); {
+		case errors.Is(err, errHandledElsewhere):
+			w.showStatus("The request was handled by another approver")
+		case errors.Is(err, errWatchQuit):
+			fmt.Fprintln(p.out)
+
+			return nil
+		case err != nil:
+			return err
+		}
+	}
+}
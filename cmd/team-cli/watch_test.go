@@ -0,0 +1,36 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/csnewman/team-cli/team"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApprovalWatchStatus(t *testing.T) {
+	t.Parallel()
+
+	var out strings.Builder
+
+	// Only the presence of a terminal is checked, causing the screen to be cleared when drawing
+	w := &approvalWatch{
+		p: &prompter{out: &out, tty: os.Stdin},
+	}
+
+	w.showStatus("New request from bob@example.com")
+	w.showStatus("Invalid option")
+
+	out.Reset()
+	w.draw([]*team.PermissionRequest{{Email: "bob@example.com", Duration: "1"}})
+
+	screen := out.String()
+	require.True(t, strings.HasPrefix(screen, "\033[H\033[2J"))
+	require.Contains(t, screen, "New request from bob@example.com\nInvalid option\n\nRequest option, or q to quit? ")
+
+	// Messages are only shown again once
+	out.Reset()
+	w.draw(nil)
+	require.NotContains(t, out.String(), "Invalid option")
+}
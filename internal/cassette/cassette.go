@@ -0,0 +1,100 @@
+// Package cassette records HTTP exchanges and websocket frames to a file, and replays them offline.
+//
+// Tokens are redacted as they are recorded. ID tokens keep their claims, with only the signature removed, as the
+// claims are required to replay a session.
+package cassette
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+var ErrNoInteraction = errors.New("no matching interaction")
+
+const currentVersion = 1
+
+// Redacted replaces secrets in recorded traffic.
+const Redacted = "REDACTED"
+
+// Cassette is a recorded session.
+type Cassette struct {
+	Version      int            `json:"version"`
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// Interaction is either an HTTP exchange or a websocket connection.
+type Interaction struct {
+	Request  *Request  `json:"request,omitempty"`
+	Response *Response `json:"response,omitempty"`
+
+	Websocket string   `json:"websocket,omitempty"`
+	Frames    []*Frame `json:"frames,omitempty"`
+}
+
+type Request struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+type Response struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+// FrameDirection indicates whether a frame was sent or received by the client.
+type FrameDirection string
+
+const (
+	FrameSent     FrameDirection = "sent"
+	FrameReceived FrameDirection = "received"
+)
+
+type Frame struct {
+	Direction FrameDirection  `json:"direction"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// Load reads a cassette from a file.
+func Load(path string) (*Cassette, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cassette: %w", err)
+	}
+
+	var c *Cassette
+
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal cassette: %w", err)
+	}
+
+	if c.Version != currentVersion {
+		return nil, fmt.Errorf("unsupported cassette version %d", c.Version)
+	}
+
+	return c, nil
+}
+
+// Save writes the cassette to a file, creating parent directories as needed.
+func (c *Cassette) Save(path string) error {
+	enc, err := json.MarshalIndent(c, "", "    ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal cassette: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create cassette dir: %w", err)
+	}
+
+	if err := os.WriteFile(path, enc, 0600); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+
+	return nil
+}
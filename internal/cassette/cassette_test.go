@@ -0,0 +1,123 @@
+package cassette
+
+import (
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactBody(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		expected    string
+	}{
+		{
+			name:        "form",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "code=abc&grant_type=authorization_code&code_verifier=xyz",
+			expected:    "code=REDACTED&code_verifier=REDACTED&grant_type=authorization_code",
+		},
+		{
+			name:        "token response",
+			contentType: "application/json",
+			body:        `{"access_token":"a.b.c","expires_in":3600,"id_token":"head.claims.sig","refresh_token":"r"}`,
+			expected:    `{"access_token":"REDACTED","expires_in":3600,"id_token":"head.claims.REDACTED","refresh_token":"REDACTED"}`,
+		},
+		{
+			name:        "nested",
+			contentType: "application/json",
+			body:        `{"payload":{"extensions":{"authorization":{"Authorization":"secret","host":"example.com"}}}}`,
+			expected:    `{"payload":{"extensions":{"authorization":{"Authorization":"REDACTED","host":"example.com"}}}}`,
+		},
+		{
+			name:        "not json",
+			contentType: "text/html",
+			body:        "<html></html>",
+			expected:    "<html></html>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tt.expected, redactBody(tt.contentType, tt.body))
+		})
+	}
+}
+
+func TestRedactHeader(t *testing.T) {
+	t.Parallel()
+
+	header := http.Header{}
+	header.Set("Authorization", "token")
+	header.Set("Content-Type", "application/json")
+
+	redacted := redactHeader(header)
+
+	require.Equal(t, Redacted, redacted.Get("Authorization"))
+	require.Equal(t, "application/json", redacted.Get("Content-Type"))
+	require.Equal(t, "token", header.Get("Authorization"))
+}
+
+func TestReplayer(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	c := &Cassette{
+		Version: currentVersion,
+		Interactions: []*Interaction{
+			{
+				Request:  &Request{Method: http.MethodGet, URL: "https://example.com/a"},
+				Response: &Response{StatusCode: http.StatusOK, Body: "first"},
+			},
+			{
+				Websocket: "wss://example.com/realtime",
+				Frames: []*Frame{
+					{Direction: FrameSent, Data: []byte(`{"type":"start","id":"recorded"}`)},
+					{Direction: FrameReceived, Data: []byte(`{"type":"start_ack","id":"recorded"}`)},
+				},
+			},
+			{
+				Request:  &Request{Method: http.MethodGet, URL: "https://example.com/a"},
+				Response: &Response{StatusCode: http.StatusNotFound, Body: "second"},
+			},
+		},
+	}
+	require.NoError(t, c.Save(path))
+
+	loaded, err := Load(path)
+	require.NoError(t, err)
+
+	rep := NewReplayer(loaded)
+
+	resp, err := rep.Client().Get("https://example.com/a?ignored=1")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NoError(t, resp.Body.Close())
+
+	resp, err = rep.Client().Get("https://example.com/a")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	require.NoError(t, resp.Body.Close())
+
+	_, err = rep.Client().Get("https://example.com/a")
+	require.ErrorIs(t, err, ErrNoInteraction)
+
+	conn, err := rep.Dial(t.Context(), "wss://example.com/realtime?header=abc", nil)
+	require.NoError(t, err)
+
+	require.NoError(t, conn.WriteJSON(map[string]string{"type": "start", "id": "actual"}))
+
+	var msg map[string]string
+
+	require.NoError(t, conn.ReadJSON(&msg))
+	require.Equal(t, map[string]string{"type": "start_ack", "id": "actual"}, msg)
+}
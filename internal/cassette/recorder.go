@@ -0,0 +1,169 @@
+package cassette
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+
+	"github.com/csnewman/team-cli/internal/gql"
+)
+
+// Recorder captures traffic into a cassette, saving it after every interaction so that failed sessions are kept.
+type Recorder struct {
+	path     string
+	client   *http.Client
+	next     http.RoundTripper
+	mu       sync.Mutex
+	cassette *Cassette
+}
+
+// NewRecorder creates a recorder writing to path, sending requests via the transport of the given client.
+func NewRecorder(path string, client *http.Client) *Recorder {
+	next := client.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return &Recorder{
+		path:   path,
+		client: client,
+		next:   next,
+		cassette: &Cassette{
+			Version: currentVersion,
+		},
+	}
+}
+
+// Client returns an HTTP client which records through the recorder.
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+func (r *Recorder) add(interaction *Interaction) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+
+	r.saveLocked()
+}
+
+func (r *Recorder) saveLocked() {
+	if err := r.cassette.Save(r.path); err != nil {
+		slog.Warn("Failed to save cassette", "err", err)
+	}
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+
+	if req.Body != nil {
+		var err error
+
+		reqBody, err = io.ReadAll(req.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+
+		_ = req.Body.Close()
+
+		req.Body = io.NopCloser(bytes.NewReader(reqBody))
+	}
+
+	resp, err := r.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+
+	_ = resp.Body.Close()
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	r.add(&Interaction{
+		Request: &Request{
+			Method: req.Method,
+			URL:    req.URL.String(),
+			Header: redactHeader(req.Header),
+			Body:   redactBody(req.Header.Get("Content-Type"), string(reqBody)),
+		},
+		Response: &Response{
+			StatusCode: resp.StatusCode,
+			Header:     redactHeader(resp.Header),
+			Body:       redactBody(resp.Header.Get("Content-Type"), string(respBody)),
+		},
+	})
+
+	return resp, nil
+}
+
+// Dial is a gql.Dialer which records every frame of the connection.
+func (r *Recorder) Dial(ctx context.Context, endpoint string, header http.Header) (gql.Conn, error) {
+	conn, err := gql.DialWebsocket(gql.WithHTTPClient(ctx, r.client), endpoint, header)
+	if err != nil {
+		return nil, err
+	}
+
+	interaction := &Interaction{
+		Websocket: endpoint,
+	}
+
+	r.add(interaction)
+
+	return &recordingConn{
+		Conn:        conn,
+		recorder:    r,
+		interaction: interaction,
+	}, nil
+}
+
+type recordingConn struct {
+	gql.Conn
+	recorder    *Recorder
+	interaction *Interaction
+}
+
+func (c *recordingConn) record(direction FrameDirection, data []byte) {
+	c.recorder.mu.Lock()
+	defer c.recorder.mu.Unlock()
+
+	c.interaction.Frames = append(c.interaction.Frames, &Frame{
+		Direction: direction,
+		Data:      json.RawMessage(redactBody("application/json", string(data))),
+	})
+
+	c.recorder.saveLocked()
+}
+
+func (c *recordingConn) ReadJSON(v any) error {
+	var raw json.RawMessage
+
+	if err := c.Conn.ReadJSON(&raw); err != nil {
+		return err
+	}
+
+	c.record(FrameReceived, raw)
+
+	return json.Unmarshal(raw, v)
+}
+
+func (c *recordingConn) WriteJSON(v any) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	c.record(FrameSent, raw)
+
+	return c.Conn.WriteJSON(json.RawMessage(raw))
+}
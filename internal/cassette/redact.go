@@ -0,0 +1,99 @@
+package cassette
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+var (
+	redactedHeaders    = []string{"Authorization", "Cookie", "Set-Cookie", "Sec-Websocket-Protocol"}
+	redactedFormFields = []string{"code", "code_verifier", "refresh_token"}
+	redactedJSONFields = map[string]func(string) string{
+		"access_token":  redactAll,
+		"refresh_token": redactAll,
+		"id_token":      redactSignature,
+		"Authorization": redactAll,
+	}
+)
+
+func redactAll(string) string {
+	return Redacted
+}
+
+// redactSignature removes the signature of a JWT, leaving its claims intact.
+func redactSignature(token string) string {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Redacted
+	}
+
+	return parts[0] + "." + parts[1] + "." + Redacted
+}
+
+func redactHeader(header http.Header) http.Header {
+	out := header.Clone()
+
+	for _, name := range redactedHeaders {
+		if out.Get(name) != "" {
+			out.Set(name, Redacted)
+		}
+	}
+
+	return out
+}
+
+// redactBody redacts tokens within JSON and form encoded bodies.
+func redactBody(contentType string, body string) string {
+	if strings.HasPrefix(contentType, "application/x-www-form-urlencoded") {
+		values, err := url.ParseQuery(body)
+		if err != nil {
+			return body
+		}
+
+		for _, field := range redactedFormFields {
+			if values.Has(field) {
+				values.Set(field, Redacted)
+			}
+		}
+
+		return values.Encode()
+	}
+
+	var value any
+
+	if err := json.Unmarshal([]byte(body), &value); err != nil {
+		return body
+	}
+
+	enc, err := json.Marshal(redactJSON(value))
+	if err != nil {
+		return body
+	}
+
+	return string(enc)
+}
+
+func redactJSON(value any) any {
+	switch value := value.(type) {
+	case map[string]any:
+		for k, v := range value {
+			if redact, ok := redactedJSONFields[k]; ok {
+				if str, ok := v.(string); ok {
+					value[k] = redact(str)
+
+					continue
+				}
+			}
+
+			value[k] = redactJSON(v)
+		}
+	case []any:
+		for i, v := range value {
+			value[i] = redactJSON(v)
+		}
+	}
+
+	return value
+}
@@ -0,0 +1,201 @@
+package cassette
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/csnewman/team-cli/internal/gql"
+)
+
+// Replayer serves recorded interactions in place of the network. Requests are matched in order against unused
+// interactions with the same method, host and path.
+type Replayer struct {
+	mu       sync.Mutex
+	cassette *Cassette
+	used     []bool
+}
+
+func NewReplayer(c *Cassette) *Replayer {
+	return &Replayer{
+		cassette: c,
+		used:     make([]bool, len(c.Interactions)),
+	}
+}
+
+// Client returns an HTTP client which is served by the replayer.
+func (r *Replayer) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+func (r *Replayer) next(match func(*Interaction) bool) *Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, interaction := range r.cassette.Interactions {
+		if r.used[i] || !match(interaction) {
+			continue
+		}
+
+		r.used[i] = true
+
+		return interaction
+	}
+
+	return nil
+}
+
+func sameEndpoint(a string, b *url.URL) bool {
+	u, err := url.Parse(a)
+	if err != nil {
+		return false
+	}
+
+	return u.Host == b.Host && u.Path == b.Path
+}
+
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		_ = req.Body.Close()
+	}
+
+	interaction := r.next(func(i *Interaction) bool {
+		return i.Request != nil && i.Request.Method == req.Method && sameEndpoint(i.Request.URL, req.URL)
+	})
+	if interaction == nil {
+		return nil, fmt.Errorf("%w: %s %s", ErrNoInteraction, req.Method, req.URL)
+	}
+
+	return &http.Response{
+		Status:        http.StatusText(interaction.Response.StatusCode),
+		StatusCode:    interaction.Response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        interaction.Response.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader([]byte(interaction.Response.Body))),
+		ContentLength: int64(len(interaction.Response.Body)),
+		Request:       req,
+	}, nil
+}
+
+// Dial is a gql.Dialer which replays a recorded websocket connection.
+func (r *Replayer) Dial(_ context.Context, endpoint string, _ http.Header) (gql.Conn, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint: %w", err)
+	}
+
+	interaction := r.next(func(i *Interaction) bool {
+		return i.Websocket != "" && sameEndpoint(i.Websocket, u)
+	})
+	if interaction == nil {
+		return nil, fmt.Errorf("%w: websocket %s", ErrNoInteraction, endpoint)
+	}
+
+	return &replayConn{
+		frames: interaction.Frames,
+		ids:    make(map[string]string),
+	}, nil
+}
+
+// replayConn returns the received frames of a recording in order. As subscription IDs are generated randomly, the
+// IDs of sent frames are mapped onto their recorded counterparts.
+type replayConn struct {
+	frames []*Frame
+	read   int
+	sent   int
+	ids    map[string]string
+}
+
+type frameID struct {
+	ID string `json:"id"`
+}
+
+func (c *replayConn) ReadJSON(v any) error {
+	for ; c.read < len(c.frames); c.read++ {
+		frame := c.frames[c.read]
+
+		if frame.Direction != FrameReceived {
+			continue
+		}
+
+		c.read++
+
+		var fields map[string]any
+
+		if err := json.Unmarshal(frame.Data, &fields); err != nil {
+			return fmt.Errorf("invalid recorded frame: %w", err)
+		}
+
+		if id, ok := fields["id"].(string); ok {
+			if mapped, ok := c.ids[id]; ok {
+				fields["id"] = mapped
+			}
+		}
+
+		raw, err := json.Marshal(fields)
+		if err != nil {
+			return err
+		}
+
+		return json.Unmarshal(raw, v)
+	}
+
+	return io.EOF
+}
+
+func (c *replayConn) WriteJSON(v any) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	var actual frameID
+
+	if err := json.Unmarshal(raw, &actual); err != nil {
+		return err
+	}
+
+	for ; c.sent < len(c.frames); c.sent++ {
+		frame := c.frames[c.sent]
+
+		if frame.Direction != FrameSent {
+			continue
+		}
+
+		c.sent++
+
+		var recorded frameID
+
+		if err := json.Unmarshal(frame.Data, &recorded); err != nil {
+			return fmt.Errorf("invalid recorded frame: %w", err)
+		}
+
+		if recorded.ID != "" && actual.ID != "" {
+			c.ids[recorded.ID] = actual.ID
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("%w: unexpected websocket frame", ErrNoInteraction)
+}
+
+func (c *replayConn) SetReadDeadline(time.Time) error {
+	return nil
+}
+
+func (c *replayConn) SetWriteDeadline(time.Time) error {
+	return nil
+}
+
+func (c *replayConn) Close() error {
+	return nil
+}
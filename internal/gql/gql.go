@@ -11,6 +11,7 @@ import (
 	"log/slog"
 	"net/http"
 	"net/url"
+	"reflect"
 	"strings"
 	"time"
 
@@ -20,6 +21,71 @@ import (
 
 var ErrUnexpected = errors.New("unexpected error")
 
+type httpClientKey struct{}
+
+// WithHTTPClient returns a context which causes requests made with it to be sent using the given client.
+func WithHTTPClient(ctx context.Context, client *http.Client) context.Context {
+	return context.WithValue(ctx, httpClientKey{}, client)
+}
+
+// HTTPClient returns the client requests made with the context should use, defaulting to http.DefaultClient.
+func HTTPClient(ctx context.Context) *http.Client {
+	if client, ok := ctx.Value(httpClientKey{}).(*http.Client); ok && client != nil {
+		return client
+	}
+
+	return http.DefaultClient
+}
+
+// Conn is a websocket connection used by Subscribe.
+type Conn interface {
+	ReadJSON(v any) error
+	WriteJSON(v any) error
+	SetReadDeadline(t time.Time) error
+	SetWriteDeadline(t time.Time) error
+	Close() error
+}
+
+// Dialer opens a websocket connection to the endpoint.
+type Dialer func(ctx context.Context, endpoint string, header http.Header) (Conn, error)
+
+type dialerKey struct{}
+
+// WithDialer returns a context which causes Subscribe to open connections using the given dialer.
+func WithDialer(ctx context.Context, dialer Dialer) context.Context {
+	return context.WithValue(ctx, dialerKey{}, dialer)
+}
+
+// DialWebsocket is the default Dialer, connecting using the transport of the context's HTTP client.
+func DialWebsocket(ctx context.Context, endpoint string, header http.Header) (Conn, error) {
+	ws, _, err := wsDialer(HTTPClient(ctx)).DialContext(ctx, endpoint, header)
+	if err != nil {
+		return nil, err
+	}
+
+	return ws, nil
+}
+
+func dial(ctx context.Context, endpoint string, header http.Header) (Conn, error) {
+	if dialer, ok := ctx.Value(dialerKey{}).(Dialer); ok && dialer != nil {
+		return dialer(ctx, endpoint, header)
+	}
+
+	return DialWebsocket(ctx, endpoint, header)
+}
+
+func wsDialer(client *http.Client) *websocket.Dialer {
+	dialer := *websocket.DefaultDialer
+
+	if transport, ok := client.Transport.(*http.Transport); ok {
+		dialer.Proxy = transport.Proxy
+		dialer.NetDialContext = transport.DialContext
+		dialer.TLSClientConfig = transport.TLSClientConfig
+	}
+
+	return &dialer
+}
+
 type wsMessage struct {
 	Type    string   `json:"type"`
 	Payload *Payload `json:"payload,omitempty"`
@@ -29,7 +95,7 @@ type wsMessage struct {
 type Payload struct {
 	Data       json.RawMessage    `json:"data,omitempty"`
 	Extensions *PayloadExtensions `json:"extensions,omitempty"`
-	Errors     []*wsError         `json:"errors,omitempty"`
+	Errors     []*Error           `json:"errors,omitempty"`
 }
 
 func (p *Payload) UnmarshalData(tgt any) error {
@@ -40,14 +106,102 @@ type PayloadExtensions struct {
 	Authorization map[string]string `json:"authorization"`
 }
 
-type wsError struct {
+// Error is an error reported by the server.
+type Error struct {
 	ErrorType string `json:"errorType"`
 	Message   string `json:"message"`
 }
 
+func (e *Error) Error() string {
+	if e.ErrorType == "" {
+		return e.Message
+	}
+
+	return e.ErrorType + ": " + e.Message
+}
+
+// ResponseError is returned by Do when the server responds with one or more errors.
+type ResponseError struct {
+	Errors []*Error
+}
+
+func (e *ResponseError) Error() string {
+	msgs := make([]string, 0, len(e.Errors))
+
+	for _, err := range e.Errors {
+		msgs = append(msgs, err.Error())
+	}
+
+	return "server returned an error: " + strings.Join(msgs, "; ")
+}
+
+func (e *ResponseError) Unwrap() error {
+	return ErrUnexpected
+}
+
 type Request struct {
-	Query     string         `json:"query"`
-	Variables map[string]any `json:"variables,omitempty"`
+	Query     string    `json:"query"`
+	Variables Variables `json:"variables,omitempty"`
+}
+
+// Variables contains the variables of a request.
+type Variables map[string]any
+
+// Set sets the named variable, returning the variables to allow chaining.
+func (v Variables) Set(name string, value any) Variables {
+	v[name] = value
+
+	return v
+}
+
+// SetOptional sets the named variable if the value is not nil, returning the variables to allow chaining.
+func (v Variables) SetOptional(name string, value any) Variables {
+	if value == nil {
+		return v
+	}
+
+	switch rv := reflect.ValueOf(value); rv.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Map, reflect.Interface:
+		if rv.IsNil() {
+			return v
+		}
+	default:
+	}
+
+	v[name] = value
+
+	return v
+}
+
+// Client holds the details required to execute requests against an endpoint.
+type Client struct {
+	Endpoint    string
+	AccessToken string
+}
+
+// Do executes the request, decoding the data of the response into T. Errors returned by the server are reported as a
+// ResponseError.
+func Do[T any](ctx context.Context, client *Client, req *Request) (T, error) {
+	var result T
+
+	payload, err := Execute(ctx, client.Endpoint, client.AccessToken, req)
+	if err != nil {
+		return result, err
+	}
+
+	if len(payload.Errors) > 0 {
+		return result, &ResponseError{Errors: payload.Errors}
+	}
+
+	if len(payload.Data) == 0 || string(payload.Data) == "null" {
+		return result, fmt.Errorf("%w: response contained no data", ErrUnexpected)
+	}
+
+	if err := payload.UnmarshalData(&result); err != nil {
+		return result, fmt.Errorf("failed to unmarshal payload data: %w", err)
+	}
+
+	return result, nil
 }
 
 func Execute(
@@ -72,7 +226,7 @@ func Execute(
 	r.Header.Add("Content-Type", "application/json")
 	r.Header.Add("Authorization", accessToken)
 
-	resp, err := http.DefaultClient.Do(r)
+	resp, err := HTTPClient(ctx).Do(r)
 	if err != nil {
 		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
@@ -94,11 +248,15 @@ func Execute(
 		return nil, fmt.Errorf("failed to unmarshal payload body: %w", err)
 	}
 
+	if payload == nil {
+		return nil, fmt.Errorf("%w: empty response", ErrUnexpected)
+	}
+
 	return payload, nil
 }
 
 type wsSubscriber struct {
-	ws      *websocket.Conn
+	ws      Conn
 	authExt map[string]string
 	reqID   uuid.UUID
 }
@@ -108,15 +266,64 @@ func Subscribe(
 	endpoint string,
 	accessToken string,
 	subscription *Request,
-	onReady func(ctx context.Context) error,
+	onReady func(ctx context.Context) (bool, error),
 	onData func(ctx context.Context, payload *Payload) (bool, error),
 ) error {
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
+	wss, err := connect(ctx, endpoint, accessToken)
+	if err != nil {
+		return err
+	}
+
+	defer wss.ws.Close()
+
+	slog.Debug("Websocket initialized")
+
+	if err := wss.start(subscription); err != nil {
+		return fmt.Errorf("failed to start subscription: %w", err)
+	}
+
+	slog.Debug("Websocket subscription ready")
+
+	cont, err := onReady(ctx)
+	if err != nil {
+		return fmt.Errorf("onReady error: %w", err)
+	}
+
+	if !cont {
+		slog.Debug("Ready handler requested exit")
+
+		return nil
+	}
+
+	if err := wss.process(onData); err != nil {
+		return fmt.Errorf("failed to process subscription: %w", err)
+	}
+
+	return nil
+}
+
+// CheckRealtime opens a realtime connection and closes it once acknowledged, verifying the endpoint is reachable and
+// accepts the access token.
+func CheckRealtime(ctx context.Context, endpoint string, accessToken string) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	wss, err := connect(ctx, endpoint, accessToken)
+	if err != nil {
+		return err
+	}
+
+	return wss.ws.Close()
+}
+
+// connect opens an initialised realtime connection, which is closed when the context is cancelled.
+func connect(ctx context.Context, endpoint string, accessToken string) (*wsSubscriber, error) {
 	u, err := url.Parse(endpoint)
 	if err != nil {
-		return fmt.Errorf("unable to parse endpoint %s: %w", endpoint, err)
+		return nil, fmt.Errorf("unable to parse endpoint %s: %w", endpoint, err)
 	}
 
 	authExt := map[string]string{
@@ -130,22 +337,20 @@ func Subscribe(
 
 	encAuth, err := json.Marshal(authExt)
 	if err != nil {
-		return fmt.Errorf("failed to marshal auth data: %w", err)
+		return nil, fmt.Errorf("failed to marshal auth data: %w", err)
 	}
 
 	subprotocol := `header-` + strings.ReplaceAll(base64.URLEncoding.EncodeToString(encAuth), "=", "")
 
-	ws, _, err := websocket.DefaultDialer.DialContext(
+	ws, err := dial(
 		ctx,
 		endpoint,
 		http.Header{"sec-websocket-protocol": []string{"graphql-ws", subprotocol}},
 	)
 	if err != nil {
-		return fmt.Errorf("failed to dial websocket: %w", err)
+		return nil, fmt.Errorf("failed to dial websocket: %w", err)
 	}
 
-	defer ws.Close()
-
 	go func() {
 		select {
 		case <-ctx.Done():
@@ -160,26 +365,12 @@ func Subscribe(
 	}
 
 	if err := wss.initConnection(); err != nil {
-		return fmt.Errorf("failed to init connection: %w", err)
-	}
-
-	slog.Debug("Websocket initialized")
-
-	if err := wss.start(subscription); err != nil {
-		return fmt.Errorf("failed to start subscription: %w", err)
-	}
-
-	slog.Debug("Websocket subscription ready")
+		_ = ws.Close()
 
-	if err := onReady(ctx); err != nil {
-		return fmt.Errorf("onReady error: %w", err)
+		return nil, fmt.Errorf("failed to init connection: %w", err)
 	}
 
-	if err := wss.process(onData); err != nil {
-		return fmt.Errorf("failed to process subscription: %w", err)
-	}
-
-	return nil
+	return wss, nil
 }
 
 func GenerateWSAddr(u *url.URL) string {
@@ -213,7 +404,13 @@ func (s *wsSubscriber) initConnection() error {
 		case "connection_ack":
 			return nil
 		case "connection_error":
-			return fmt.Errorf("%w: connection error: %q", ErrUnexpected, pkt.Payload)
+			if pkt.Payload != nil {
+				for _, err := range pkt.Payload.Errors {
+					slog.Warn("Received connection error", "error", err)
+				}
+			}
+
+			return fmt.Errorf("%w: connection error", ErrUnexpected)
 		default:
 			slog.Warn("Received unexpected packet", "type", pkt.Type)
 		}
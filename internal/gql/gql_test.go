@@ -0,0 +1,143 @@
+package gql_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/csnewman/team-cli/internal/gql"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDo(t *testing.T) {
+	t.Parallel()
+
+	type result struct {
+		Value string `json:"value"`
+	}
+
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		expected *result
+		err      string
+	}{
+		{
+			name:     "data",
+			status:   http.StatusOK,
+			body:     `{"data": {"value": "abc"}}`,
+			expected: &result{Value: "abc"},
+		},
+		{
+			name:   "errors and data",
+			status: http.StatusOK,
+			body:   `{"data": {"value": "abc"}, "errors": [{"errorType": "Unauthorized", "message": "denied"}]}`,
+			err:    "server returned an error: Unauthorized: denied",
+		},
+		{
+			name:   "null data",
+			status: http.StatusOK,
+			body:   `{"data": null}`,
+			err:    "response contained no data",
+		},
+		{
+			name:   "missing data",
+			status: http.StatusOK,
+			body:   `{}`,
+			err:    "response contained no data",
+		},
+		{
+			name:   "null body",
+			status: http.StatusOK,
+			body:   `null`,
+			err:    "empty response",
+		},
+		{
+			name:   "status",
+			status: http.StatusInternalServerError,
+			body:   `{"data": {"value": "abc"}}`,
+			err:    "unexpected status code: 500",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, err := io.ReadAll(r.Body)
+				if err != nil {
+					panic(err)
+				}
+
+				var req *gql.Request
+
+				if err := json.Unmarshal(body, &req); err != nil {
+					panic(err)
+				}
+
+				if r.Header.Get("Authorization") != "token" || req.Variables["id"] != "req-1" {
+					w.WriteHeader(http.StatusBadRequest)
+
+					return
+				}
+
+				w.WriteHeader(tt.status)
+				_, _ = io.WriteString(w, tt.body)
+			}))
+			defer srv.Close()
+
+			client := &gql.Client{Endpoint: srv.URL, AccessToken: "token"}
+
+			res, err := gql.Do[*result](context.Background(), client, &gql.Request{
+				Query:     "query { value }",
+				Variables: gql.Variables{}.Set("id", "req-1"),
+			})
+			if tt.err != "" {
+				require.ErrorIs(t, err, gql.ErrUnexpected)
+				require.ErrorContains(t, err, tt.err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, res)
+		})
+	}
+}
+
+func TestVariables(t *testing.T) {
+	t.Parallel()
+
+	var (
+		nilPtr   *string
+		nilSlice []string
+		value    = "abc"
+	)
+
+	tests := []struct {
+		name     string
+		value    any
+		expected gql.Variables
+	}{
+		{name: "nil", value: nil, expected: gql.Variables{"a": 1}},
+		{name: "nil pointer", value: nilPtr, expected: gql.Variables{"a": 1}},
+		{name: "nil slice", value: nilSlice, expected: gql.Variables{"a": 1}},
+		{name: "pointer", value: &value, expected: gql.Variables{"a": 1, "b": &value}},
+		{name: "empty string", value: "", expected: gql.Variables{"a": 1, "b": ""}},
+		{name: "zero", value: 0, expected: gql.Variables{"a": 1, "b": 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			vars := gql.Variables{}.Set("a", 1).SetOptional("b", tt.value)
+			require.Equal(t, tt.expected, vars)
+		})
+	}
+}
@@ -0,0 +1,542 @@
+// Command gqlgen generates typed GraphQL operations from a schema and a document of operations.
+//
+// For every operation it emits the query string, a result struct matching the selection set and a constructor
+// accepting the operation variables. Fragments become structs which are reused wherever they are spread.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/formatter"
+)
+
+func main() {
+	schemaPaths := flag.String("schema", "", "comma separated list of schema files")
+	opsPath := flag.String("operations", "", "operations file")
+	pkg := flag.String("package", "", "package name")
+	out := flag.String("out", "", "output file")
+
+	flag.Parse()
+
+	if *schemaPaths == "" || *opsPath == "" || *pkg == "" || *out == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var sources []*ast.Source
+
+	for _, path := range strings.Split(*schemaPaths, ",") {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			log.Fatalf("failed to read schema: %v", err)
+		}
+
+		sources = append(sources, &ast.Source{Name: path, Input: string(raw)})
+	}
+
+	schema, err := gqlparser.LoadSchema(sources...)
+	if err != nil {
+		log.Fatalf("failed to load schema: %v", err)
+	}
+
+	raw, err := os.ReadFile(*opsPath)
+	if err != nil {
+		log.Fatalf("failed to read operations: %v", err)
+	}
+
+	doc, errs := gqlparser.LoadQueryWithRules(schema, string(raw), nil)
+	if len(errs) > 0 {
+		log.Fatalf("invalid operations: %v", errs)
+	}
+
+	g := &generator{
+		schema: schema,
+		doc:    doc,
+		inputs: make(map[string]bool),
+		enums:  make(map[string]bool),
+	}
+
+	src, err := g.generate(*pkg, *opsPath)
+	if err != nil {
+		log.Fatalf("failed to generate: %v", err)
+	}
+
+	if err := os.WriteFile(*out, src, 0644); err != nil {
+		log.Fatalf("failed to write output: %v", err)
+	}
+}
+
+type generator struct {
+	schema *ast.Schema
+	doc    *ast.QueryDocument
+	buf    bytes.Buffer
+	inputs map[string]bool
+	enums  map[string]bool
+	time   bool
+	json   bool
+}
+
+func (g *generator) printf(format string, args ...any) {
+	_, _ = fmt.Fprintf(&g.buf, format, args...)
+}
+
+func (g *generator) generate(pkg string, source string) ([]byte, error) {
+	for _, frag := range g.doc.Fragments {
+		if err := g.generateFragment(frag); err != nil {
+			return nil, fmt.Errorf("fragment %s: %w", frag.Name, err)
+		}
+	}
+
+	for _, op := range g.doc.Operations {
+		if op.Name == "" {
+			return nil, fmt.Errorf("anonymous operations are not supported")
+		}
+
+		if err := g.generateOperation(op); err != nil {
+			return nil, fmt.Errorf("operation %s: %w", op.Name, err)
+		}
+	}
+
+	g.printf("// operationQueries contains the query of every generated operation, keyed by operation name.\n")
+	g.printf("var operationQueries = map[string]string{\n")
+
+	for _, op := range g.doc.Operations {
+		g.printf("%q: %sQuery,\n", op.Name, lowerFirst(op.Name))
+	}
+
+	g.printf("}\n\n")
+
+	for _, name := range slices.Sorted(maps.Keys(g.inputs)) {
+		g.generateInput(g.schema.Types[name])
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(g.enums)) {
+		g.generateEnum(g.schema.Types[name])
+	}
+
+	body := g.buf.Bytes()
+	g.buf = bytes.Buffer{}
+
+	g.printf("// Code generated by gqlgen from %s. DO NOT EDIT.\n\n", source)
+	g.printf("package %s\n\n", pkg)
+	g.printf("import (\n")
+
+	if g.json {
+		g.printf("\"encoding/json\"\n")
+	}
+
+	if g.time {
+		g.printf("\"time\"\n")
+	}
+
+	g.printf("\n\"github.com/csnewman/team-cli/internal/gql\"\n")
+	g.printf(")\n\n")
+	g.buf.Write(body)
+
+	src, err := format.Source(g.buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format output: %w\n%s", err, g.buf.String())
+	}
+
+	return src, nil
+}
+
+func (g *generator) generateFragment(frag *ast.FragmentDefinition) error {
+	name := lowerFirst(frag.Name)
+
+	g.printf("// %s is the %s fragment on %s.\n", name, frag.Name, frag.TypeCondition)
+
+	return g.generateStruct(name, frag.SelectionSet)
+}
+
+func (g *generator) generateOperation(op *ast.OperationDefinition) error {
+	name := lowerFirst(op.Name)
+
+	query, err := g.operationQuery(op)
+	if err != nil {
+		return err
+	}
+
+	g.printf("// %sQuery is the %s %s.\n", name, op.Name, op.Operation)
+	g.printf("const %sQuery = `%s`\n\n", name, query)
+
+	params := make([]string, 0, len(op.VariableDefinitions))
+
+	for _, v := range op.VariableDefinitions {
+		goType, err := g.inputType(v.Type)
+		if err != nil {
+			return fmt.Errorf("variable %s: %w", v.Variable, err)
+		}
+
+		if !v.Type.NonNull && !strings.HasPrefix(goType, "*") && !strings.HasPrefix(goType, "[]") {
+			goType = "*" + goType
+		}
+
+		params = append(params, fmt.Sprintf("%s %s", paramIdent(v.Variable), goType))
+	}
+
+	g.printf("// new%sRequest creates a request for the %s %s.\n", op.Name, op.Name, op.Operation)
+	g.printf("func new%sRequest(%s) *gql.Request {\n", op.Name, strings.Join(params, ", "))
+	g.printf("return &gql.Request{\n")
+	g.printf("Query: %sQuery,\n", name)
+
+	if len(op.VariableDefinitions) > 0 {
+		g.printf("Variables: gql.Variables{}")
+
+		for _, v := range op.VariableDefinitions {
+			method := "SetOptional"
+
+			if v.Type.NonNull {
+				method = "Set"
+			}
+
+			g.printf(".\n%s(%q, %s)", method, v.Variable, paramIdent(v.Variable))
+		}
+
+		g.printf(",\n")
+	}
+
+	g.printf("}\n")
+	g.printf("}\n\n")
+
+	g.printf("// %sResult is the result of the %s %s.\n", name, op.Name, op.Operation)
+
+	return g.generateStruct(name+"Result", op.SelectionSet)
+}
+
+// operationQuery renders the operation along with every fragment it depends on.
+func (g *generator) operationQuery(op *ast.OperationDefinition) (string, error) {
+	doc := &ast.QueryDocument{
+		Operations: ast.OperationList{op},
+	}
+
+	seen := make(map[string]bool)
+
+	var collect func(set ast.SelectionSet) error
+
+	collect = func(set ast.SelectionSet) error {
+		for _, sel := range set {
+			switch sel := sel.(type) {
+			case *ast.Field:
+				if err := collect(sel.SelectionSet); err != nil {
+					return err
+				}
+			case *ast.FragmentSpread:
+				if seen[sel.Name] {
+					continue
+				}
+
+				seen[sel.Name] = true
+
+				frag := g.doc.Fragments.ForName(sel.Name)
+				if frag == nil {
+					return fmt.Errorf("unknown fragment %s", sel.Name)
+				}
+
+				doc.Fragments = append(doc.Fragments, frag)
+
+				if err := collect(frag.SelectionSet); err != nil {
+					return err
+				}
+			default:
+				return fmt.Errorf("unsupported selection %T", sel)
+			}
+		}
+
+		return nil
+	}
+
+	if err := collect(op.SelectionSet); err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+
+	formatter.NewFormatter(&buf, formatter.WithIndent("  ")).FormatQueryDocument(doc)
+
+	return strings.TrimSpace(buf.String()), nil
+}
+
+func (g *generator) generateStruct(name string, set ast.SelectionSet) error {
+	type nested struct {
+		name string
+		set  ast.SelectionSet
+	}
+
+	var children []nested
+
+	g.printf("type %s struct {\n", name)
+
+	for _, sel := range set {
+		switch sel := sel.(type) {
+		case *ast.Field:
+			fieldName := goIdent(sel.Alias)
+
+			if sel.Name == "__typename" {
+				g.printf("Typename string `json:%q`\n", sel.Alias)
+
+				continue
+			}
+
+			var elem string
+
+			if len(sel.SelectionSet) > 0 {
+				elem = g.selectionType(name+fieldName, sel.SelectionSet)
+
+				if elem == name+fieldName {
+					children = append(children, nested{name: elem, set: sel.SelectionSet})
+				}
+			} else {
+				var err error
+
+				elem, err = g.scalarType(sel.Definition.Type.Name())
+				if err != nil {
+					return fmt.Errorf("field %s: %w", sel.Alias, err)
+				}
+			}
+
+			g.printf("%s %s `json:%q`\n", fieldName, g.outputType(sel.Definition.Type, elem), sel.Alias)
+		case *ast.FragmentSpread:
+			g.printf("%s\n", lowerFirst(sel.Name))
+		default:
+			return fmt.Errorf("unsupported selection %T", sel)
+		}
+	}
+
+	g.printf("}\n\n")
+
+	for _, child := range children {
+		if err := g.generateStruct(child.name, child.set); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// selectionType returns the struct used for a selection set. Selections consisting solely of a fragment spread reuse
+// the fragment struct.
+func (g *generator) selectionType(name string, set ast.SelectionSet) string {
+	if len(set) == 1 {
+		if spread, ok := set[0].(*ast.FragmentSpread); ok {
+			return lowerFirst(spread.Name)
+		}
+	}
+
+	return name
+}
+
+func (g *generator) outputType(t *ast.Type, elem string) string {
+	if t.Elem != nil {
+		return "[]" + g.outputType(t.Elem, elem)
+	}
+
+	if def := g.schema.Types[t.NamedType]; def != nil && def.Kind == ast.Object {
+		return "*" + elem
+	}
+
+	return elem
+}
+
+func (g *generator) inputType(t *ast.Type) (string, error) {
+	if t.Elem != nil {
+		elem, err := g.inputType(t.Elem)
+		if err != nil {
+			return "", err
+		}
+
+		return "[]" + elem, nil
+	}
+
+	def := g.schema.Types[t.NamedType]
+	if def == nil {
+		return "", fmt.Errorf("unknown type %s", t.NamedType)
+	}
+
+	if def.Kind != ast.InputObject {
+		return g.scalarType(t.NamedType)
+	}
+
+	if !g.inputs[def.Name] {
+		g.inputs[def.Name] = true
+
+		for _, field := range def.Fields {
+			if _, err := g.inputType(field.Type); err != nil {
+				return "", fmt.Errorf("field %s: %w", field.Name, err)
+			}
+		}
+	}
+
+	return "*" + lowerFirst(def.Name), nil
+}
+
+func (g *generator) scalarType(name string) (string, error) {
+	switch name {
+	case "ID", "String", "AWSEmail", "AWSURL", "AWSPhone", "AWSIPAddress", "AWSDate", "AWSTime":
+		return "string", nil
+	case "Int":
+		return "int", nil
+	case "Float":
+		return "float64", nil
+	case "Boolean":
+		return "bool", nil
+	case "AWSTimestamp":
+		return "int64", nil
+	case "AWSDateTime":
+		g.time = true
+
+		return "time.Time", nil
+	case "AWSJSON":
+		g.json = true
+
+		return "json.RawMessage", nil
+	}
+
+	def := g.schema.Types[name]
+	if def == nil || def.Kind != ast.Enum {
+		return "", fmt.Errorf("unsupported type %s", name)
+	}
+
+	g.enums[name] = true
+
+	return lowerFirst(name), nil
+}
+
+func (g *generator) generateInput(def *ast.Definition) {
+	name := lowerFirst(def.Name)
+
+	g.printf("// %s is the %s input type.\n", name, def.Name)
+	g.printf("type %s struct {\n", name)
+
+	for _, field := range def.Fields {
+		// Types have been validated when the input was first referenced.
+		goType, _ := g.inputType(field.Type)
+
+		tag := field.Name
+
+		// Nullable fields are omitted when nil, so empty values are still sent when set
+		if !field.Type.NonNull {
+			tag += ",omitempty"
+
+			if !strings.HasPrefix(goType, "*") && !strings.HasPrefix(goType, "[]") {
+				goType = "*" + goType
+			}
+		}
+
+		g.printf("%s %s `json:%q`\n", goIdent(field.Name), goType, tag)
+	}
+
+	g.printf("}\n\n")
+}
+
+func (g *generator) generateEnum(def *ast.Definition) {
+	name := lowerFirst(def.Name)
+
+	g.printf("// %s is the %s enum.\n", name, def.Name)
+	g.printf("type %s string\n\n", name)
+	g.printf("const (\n")
+
+	for _, value := range def.EnumValues {
+		g.printf("%s%s %s = %q\n", name, goIdent(value.Name), name, value.Name)
+	}
+
+	g.printf(")\n\n")
+}
+
+var initialisms = map[string]string{
+	"id":   "ID",
+	"ids":  "IDs",
+	"url":  "URL",
+	"json": "JSON",
+	"api":  "API",
+}
+
+// goIdent converts a GraphQL name into an exported Go identifier, e.g. approver_ids becomes ApproverIDs.
+func goIdent(name string) string {
+	var out strings.Builder
+
+	for _, word := range identWords(name) {
+		out.WriteString(titleWord(word))
+	}
+
+	if out.Len() == 0 {
+		return "Null"
+	}
+
+	return out.String()
+}
+
+// paramIdent converts a GraphQL name into an unexported Go identifier, e.g. id becomes id and userId becomes userID.
+func paramIdent(name string) string {
+	words := identWords(name)
+	if len(words) == 0 {
+		return "null"
+	}
+
+	var out strings.Builder
+
+	// Initialisms are lowered entirely when they start the identifier
+	if _, ok := initialisms[strings.ToLower(words[0])]; ok {
+		out.WriteString(strings.ToLower(words[0]))
+	} else {
+		out.WriteString(lowerFirst(words[0]))
+	}
+
+	for _, word := range words[1:] {
+		out.WriteString(titleWord(word))
+	}
+
+	return out.String()
+}
+
+// identWords splits a GraphQL name into words, at underscores and upper case letters.
+func identWords(name string) []string {
+	var (
+		words   []string
+		current []rune
+	)
+
+	for _, r := range name {
+		switch {
+		case r == '_':
+			if len(current) > 0 {
+				words = append(words, string(current))
+				current = nil
+			}
+		case unicode.IsUpper(r) && len(current) > 0:
+			words = append(words, string(current))
+			current = []rune{r}
+		default:
+			current = append(current, r)
+		}
+	}
+
+	if len(current) > 0 {
+		words = append(words, string(current))
+	}
+
+	return words
+}
+
+func titleWord(word string) string {
+	if initialism, ok := initialisms[strings.ToLower(word)]; ok {
+		return initialism
+	}
+
+	return strings.ToUpper(word[:1]) + word[1:]
+}
+
+func lowerFirst(name string) string {
+	return strings.ToLower(name[:1]) + name[1:]
+}
@@ -0,0 +1,514 @@
+package teamtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+// Request is an access request held by the fake, mirroring the TEAM requests type.
+type Request struct {
+	ID              string   `json:"id"`
+	Email           string   `json:"email"`
+	AccountID       string   `json:"accountId"`
+	AccountName     string   `json:"accountName"`
+	Role            string   `json:"role"`
+	RoleID          string   `json:"roleId"`
+	StartTime       string   `json:"startTime"`
+	Duration        string   `json:"duration"`
+	Justification   string   `json:"justification"`
+	Status          string   `json:"status"`
+	Comment         string   `json:"comment"`
+	Username        string   `json:"username"`
+	Approver        string   `json:"approver"`
+	ApproverID      string   `json:"approverId"`
+	Approvers       []string `json:"approvers"`
+	ApproverIDs     []string `json:"approver_ids"`
+	Revoker         string   `json:"revoker"`
+	RevokerID       string   `json:"revokerId"`
+	EndTime         string   `json:"endTime"`
+	TicketNo        string   `json:"ticketNo"`
+	RevokeComment   string   `json:"revokeComment"`
+	SessionDuration string   `json:"session_duration"`
+	CreatedAt       string   `json:"createdAt"`
+	UpdatedAt       string   `json:"updatedAt"`
+	Owner           string   `json:"owner"`
+	Typename        string   `json:"__typename"`
+}
+
+func (r *Request) fields() map[string]any {
+	raw, _ := json.Marshal(r)
+
+	var out map[string]any
+
+	_ = json.Unmarshal(raw, &out)
+
+	return out
+}
+
+// AddRequest stores a request directly, filling in the ID and timestamps if unset.
+func (s *Server) AddRequest(req *Request) *Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.addRequest(req)
+
+	return req
+}
+
+func (s *Server) addRequest(req *Request) {
+	now := s.Now().UTC().Format(time.RFC3339)
+
+	if req.ID == "" {
+		req.ID = s.newID("request")
+	}
+
+	if req.CreatedAt == "" {
+		req.CreatedAt = now
+	}
+
+	if req.UpdatedAt == "" {
+		req.UpdatedAt = now
+	}
+
+	req.Typename = "requests"
+
+	s.requests = append(s.requests, req)
+}
+
+// Requests returns a copy of every stored request.
+func (s *Server) Requests() []*Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make([]*Request, 0, len(s.requests))
+
+	for _, req := range s.requests {
+		cp := *req
+		out = append(out, &cp)
+	}
+
+	return out
+}
+
+// UpdateRequest modifies a stored request and notifies onUpdateRequests subscribers.
+func (s *Server) UpdateRequest(id string, update func(req *Request)) {
+	s.mu.Lock()
+
+	req := s.findRequest(id)
+	if req == nil {
+		s.mu.Unlock()
+
+		panic("unknown request " + id)
+	}
+
+	update(req)
+	req.UpdatedAt = s.Now().UTC().Format(time.RFC3339)
+
+	fields := req.fields()
+
+	s.mu.Unlock()
+
+	s.publish("onUpdateRequests", fields)
+}
+
+func (s *Server) findRequest(id string) *Request {
+	for _, req := range s.requests {
+		if req.ID == id {
+			return req
+		}
+	}
+
+	return nil
+}
+
+// PublishPolicy publishes the policy of the user to onPublishPolicy subscribers.
+func (s *Server) PublishPolicy(u *User) {
+	s.publish("onPublishPolicy", policyFields(u))
+}
+
+func policyFields(u *User) map[string]any {
+	entitlements := make([]map[string]any, 0, len(u.Policy))
+
+	for _, ent := range u.Policy {
+		accounts := make([]map[string]any, 0, len(ent.Accounts))
+
+		for _, acc := range ent.Accounts {
+			accounts = append(accounts, map[string]any{"name": acc.Name, "id": acc.ID, "__typename": "Account"})
+		}
+
+		permissions := make([]map[string]any, 0, len(ent.Permissions))
+
+		for _, perm := range ent.Permissions {
+			permissions = append(permissions, map[string]any{"name": perm.Name, "id": perm.ID, "__typename": "Permission"})
+		}
+
+		entitlements = append(entitlements, map[string]any{
+			"accounts":         accounts,
+			"permissions":      permissions,
+			"approvalRequired": ent.ApprovalRequired,
+			"duration":         strconv.Itoa(ent.Duration),
+			"__typename":       "Entitlement",
+		})
+	}
+
+	return map[string]any{
+		"id":         u.ID,
+		"policy":     entitlements,
+		"username":   u.Username,
+		"__typename": "Policy",
+	}
+}
+
+type graphQLRequest struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+type graphQLError struct {
+	ErrorType string `json:"errorType"`
+	Message   string `json:"message"`
+}
+
+// parseRootField returns the single root field selected by a request, along with its resolved arguments.
+func parseRootField(req *graphQLRequest) (*ast.Field, map[string]any, error) {
+	doc, err := parser.ParseQuery(&ast.Source{Input: req.Query})
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid query: %w", err)
+	}
+
+	var op *ast.OperationDefinition
+
+	if req.OperationName != "" {
+		op = doc.Operations.ForName(req.OperationName)
+	} else if len(doc.Operations) == 1 {
+		op = doc.Operations[0]
+	}
+
+	if op == nil {
+		return nil, nil, fmt.Errorf("operation not found")
+	}
+
+	if len(op.SelectionSet) != 1 {
+		return nil, nil, fmt.Errorf("exactly one root field must be selected")
+	}
+
+	field, ok := op.SelectionSet[0].(*ast.Field)
+	if !ok {
+		return nil, nil, fmt.Errorf("root selection must be a field")
+	}
+
+	args := make(map[string]any)
+
+	for _, arg := range field.Arguments {
+		val, err := arg.Value.Value(req.Variables)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid argument %s: %w", arg.Name, err)
+		}
+
+		args[arg.Name] = val
+	}
+
+	return field, args, nil
+}
+
+func (s *Server) handleGraphQL(w http.ResponseWriter, r *http.Request) {
+	user := s.userForToken(r.Header.Get("Authorization"))
+	if user == nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		writeJSON(w, map[string]any{
+			"errors": []*graphQLError{{ErrorType: "UnauthorizedException", Message: "Valid authorization header not provided."}},
+		})
+
+		return
+	}
+
+	var req graphQLRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	field, args, err := parseRootField(&req)
+	if err != nil {
+		writeJSON(w, map[string]any{
+			"errors": []*graphQLError{{ErrorType: "GraphQLError", Message: err.Error()}},
+		})
+
+		return
+	}
+
+	result, err := s.resolve(user, field.Name, args)
+	if err != nil {
+		writeJSON(w, map[string]any{
+			"data":   map[string]any{field.Alias: nil},
+			"errors": []*graphQLError{{ErrorType: "DynamoDB:ConditionalCheckFailedException", Message: err.Error()}},
+		})
+
+		return
+	}
+
+	writeJSON(w, map[string]any{
+		"data": map[string]any{field.Alias: result},
+	})
+}
+
+func (s *Server) resolve(user *User, name string, args map[string]any) (any, error) {
+	switch name {
+	case "getUserPolicy":
+		if s.OnGetUserPolicy != nil {
+			s.OnGetUserPolicy(s, user)
+		}
+
+		if s.SyncPolicy {
+			return policyFields(user), nil
+		}
+
+		go s.PublishPolicy(user)
+
+		return nil, nil
+	case "listRequests":
+		return s.listRequests(args), nil
+	case "getRequests":
+		return s.getRequest(args), nil
+	case "createRequests":
+		return s.createRequest(user, args)
+	case "updateRequests":
+		return s.updateRequest(args)
+	default:
+		return nil, fmt.Errorf("unsupported field %s", name)
+	}
+}
+
+func (s *Server) listRequests(args map[string]any) map[string]any {
+	filter, _ := args["filter"].(map[string]any)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	items := make([]map[string]any, 0)
+
+	for _, req := range s.requests {
+		fields := req.fields()
+
+		if matchFilter(filter, fields) {
+			items = append(items, fields)
+		}
+	}
+
+	return map[string]any{
+		"items":      items,
+		"nextToken":  nil,
+		"__typename": "ModelRequestsConnection",
+	}
+}
+
+func (s *Server) getRequest(args map[string]any) any {
+	id, _ := args["id"].(string)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	req := s.findRequest(id)
+	if req == nil {
+		return nil
+	}
+
+	return req.fields()
+}
+
+func (s *Server) createRequest(user *User, args map[string]any) (any, error) {
+	input, _ := args["input"].(map[string]any)
+
+	req := &Request{
+		Email:     user.Email,
+		Username:  user.Username,
+		Owner:     user.Username,
+		Status:    "pending",
+		Approvers: slices.Clone(s.Approvers),
+	}
+
+	if err := applyInput(req, input); err != nil {
+		return nil, err
+	}
+
+	if req.AccountID == "" || req.RoleID == "" || req.StartTime == "" || req.Duration == "" {
+		return nil, fmt.Errorf("missing required input")
+	}
+
+	s.mu.Lock()
+	s.addRequest(req)
+	fields := req.fields()
+	s.mu.Unlock()
+
+	s.publish("onCreateRequests", fields)
+
+	return fields, nil
+}
+
+func (s *Server) updateRequest(args map[string]any) (any, error) {
+	input, _ := args["input"].(map[string]any)
+	id, _ := input["id"].(string)
+
+	s.mu.Lock()
+
+	req := s.findRequest(id)
+	if req == nil {
+		s.mu.Unlock()
+
+		return nil, fmt.Errorf("the conditional request failed")
+	}
+
+	if err := applyInput(req, input); err != nil {
+		s.mu.Unlock()
+
+		return nil, err
+	}
+
+	req.UpdatedAt = s.Now().UTC().Format(time.RFC3339)
+	fields := req.fields()
+
+	s.mu.Unlock()
+
+	s.publish("onUpdateRequests", fields)
+
+	return fields, nil
+}
+
+func applyInput(req *Request, input map[string]any) error {
+	merged := req.fields()
+
+	for k, v := range input {
+		merged[k] = v
+	}
+
+	raw, err := json.Marshal(merged)
+	if err != nil {
+		return fmt.Errorf("invalid input: %w", err)
+	}
+
+	if err := json.Unmarshal(raw, req); err != nil {
+		return fmt.Errorf("invalid input: %w", err)
+	}
+
+	return nil
+}
+
+// matchFilter evaluates a TEAM model filter against the fields of a request.
+func matchFilter(filter map[string]any, fields map[string]any) bool {
+	for key, cond := range filter {
+		switch key {
+		case "and":
+			for _, sub := range toList(cond) {
+				sub, _ := sub.(map[string]any)
+
+				if !matchFilter(sub, fields) {
+					return false
+				}
+			}
+		case "or":
+			subs := toList(cond)
+			matched := len(subs) == 0
+
+			for _, sub := range subs {
+				sub, _ := sub.(map[string]any)
+
+				if matchFilter(sub, fields) {
+					matched = true
+
+					break
+				}
+			}
+
+			if !matched {
+				return false
+			}
+		case "not":
+			sub, _ := cond.(map[string]any)
+
+			if matchFilter(sub, fields) {
+				return false
+			}
+		default:
+			ops, _ := cond.(map[string]any)
+
+			if !matchOps(ops, fields[key]) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func matchOps(ops map[string]any, value any) bool {
+	for op, arg := range ops {
+		if !matchOp(op, arg, value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func matchOp(op string, arg any, value any) bool {
+	argStr := fmt.Sprint(arg)
+
+	switch op {
+	case "eq":
+		return fmt.Sprint(value) == argStr
+	case "ne":
+		return fmt.Sprint(value) != argStr
+	case "contains":
+		return contains(value, argStr)
+	case "notContains":
+		return !contains(value, argStr)
+	case "beginsWith":
+		str, ok := value.(string)
+
+		return ok && strings.HasPrefix(str, argStr)
+	case "in":
+		for _, item := range toList(arg) {
+			if fmt.Sprint(item) == fmt.Sprint(value) {
+				return true
+			}
+		}
+
+		return false
+	case "notIn":
+		return !matchOp("in", arg, value)
+	default:
+		return false
+	}
+}
+
+func contains(value any, arg string) bool {
+	switch value := value.(type) {
+	case string:
+		return strings.Contains(value, arg)
+	case []any:
+		for _, item := range value {
+			if fmt.Sprint(item) == arg {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func toList(v any) []any {
+	list, _ := v.([]any)
+
+	return list
+}
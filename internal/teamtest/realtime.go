@@ -0,0 +1,210 @@
+package teamtest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+type wsMessage struct {
+	Type    string          `json:"type"`
+	ID      string          `json:"id,omitempty"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+type wsConn struct {
+	ws *websocket.Conn
+	mu sync.Mutex
+}
+
+func (c *wsConn) send(msg any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	_ = c.ws.WriteJSON(msg)
+}
+
+type subscription struct {
+	conn   *wsConn
+	id     string
+	field  string
+	alias  string
+	filter map[string]any
+}
+
+var upgrader = websocket.Upgrader{
+	Subprotocols: []string{"graphql-ws"},
+}
+
+func (s *Server) handleRealtime(w http.ResponseWriter, r *http.Request) {
+	user := s.realtimeUser(r)
+	if user == nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+
+		return
+	}
+
+	ws, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+
+	conn := &wsConn{ws: ws}
+
+	defer func() {
+		s.mu.Lock()
+
+		for sub := range s.subs {
+			if sub.conn == conn {
+				delete(s.subs, sub)
+			}
+		}
+
+		s.mu.Unlock()
+
+		_ = ws.Close()
+	}()
+
+	for {
+		var msg wsMessage
+
+		if err := ws.ReadJSON(&msg); err != nil {
+			return
+		}
+
+		switch msg.Type {
+		case "connection_init":
+			conn.send(map[string]any{
+				"type":    "connection_ack",
+				"payload": map[string]any{"connectionTimeoutMs": 300000},
+			})
+		case "start":
+			s.startSubscription(conn, &msg)
+		case "stop":
+			s.mu.Lock()
+
+			for sub := range s.subs {
+				if sub.conn == conn && sub.id == msg.ID {
+					delete(s.subs, sub)
+				}
+			}
+
+			s.mu.Unlock()
+
+			conn.send(&wsMessage{Type: "complete", ID: msg.ID})
+		default:
+			conn.send(map[string]any{
+				"type":    "error",
+				"id":      msg.ID,
+				"payload": map[string]any{"errors": []*graphQLError{{ErrorType: "UnsupportedOperation", Message: msg.Type}}},
+			})
+		}
+	}
+}
+
+// realtimeUser authenticates a websocket connection using the authorization header encoded in its subprotocol.
+func (s *Server) realtimeUser(r *http.Request) *User {
+	var protos []string
+
+	for _, value := range r.Header.Values("Sec-Websocket-Protocol") {
+		protos = append(protos, strings.Split(value, ",")...)
+	}
+
+	for _, proto := range protos {
+		enc, ok := strings.CutPrefix(strings.TrimSpace(proto), "header-")
+		if !ok {
+			continue
+		}
+
+		raw, err := base64.RawURLEncoding.DecodeString(enc)
+		if err != nil {
+			return nil
+		}
+
+		var header map[string]string
+
+		if err := json.Unmarshal(raw, &header); err != nil {
+			return nil
+		}
+
+		return s.userForToken(header["Authorization"])
+	}
+
+	return nil
+}
+
+func (s *Server) startSubscription(conn *wsConn, msg *wsMessage) {
+	fail := func(message string) {
+		conn.send(map[string]any{
+			"type":    "error",
+			"id":      msg.ID,
+			"payload": map[string]any{"errors": []*graphQLError{{ErrorType: "BadRequestException", Message: message}}},
+		})
+	}
+
+	var payload struct {
+		Data string `json:"data"`
+	}
+
+	if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+		fail(err.Error())
+
+		return
+	}
+
+	var req graphQLRequest
+
+	if err := json.Unmarshal([]byte(payload.Data), &req); err != nil {
+		fail(err.Error())
+
+		return
+	}
+
+	field, args, err := parseRootField(&req)
+	if err != nil {
+		fail(err.Error())
+
+		return
+	}
+
+	filter, _ := args["filter"].(map[string]any)
+
+	s.mu.Lock()
+	s.subs[&subscription{
+		conn:   conn,
+		id:     msg.ID,
+		field:  field.Name,
+		alias:  field.Alias,
+		filter: filter,
+	}] = struct{}{}
+	s.mu.Unlock()
+
+	conn.send(&wsMessage{Type: "start_ack", ID: msg.ID})
+}
+
+// publish delivers an event to every subscription of the given field whose filter matches.
+func (s *Server) publish(field string, fields map[string]any) {
+	s.mu.Lock()
+
+	var targets []*subscription
+
+	for sub := range s.subs {
+		if sub.field == field && matchFilter(sub.filter, fields) {
+			targets = append(targets, sub)
+		}
+	}
+
+	s.mu.Unlock()
+
+	for _, sub := range targets {
+		sub.conn.send(map[string]any{
+			"type":    "data",
+			"id":      sub.id,
+			"payload": map[string]any{"data": map[string]any{sub.alias: fields}},
+		})
+	}
+}
@@ -0,0 +1,396 @@
+// Package teamtest provides an in-process fake of an AWS TEAM deployment for use in tests.
+//
+// The fake serves the TEAM homepage and configuration script, the Cognito OAuth endpoints, the AppSync GraphQL
+// endpoint and the AppSync realtime websocket protocol from a single TLS test server, backed by an in-memory store.
+package teamtest
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/csnewman/team-cli/internal/gql"
+	"github.com/csnewman/team-cli/team"
+)
+
+const (
+	// ClientID is the Cognito app client ID served by the fake.
+	ClientID = "teamtest-client"
+
+	// LocalhostRedirect is the redirect URI used by team.FetchToken.
+	LocalhostRedirect = "http://localhost:43672/"
+
+	jsPath = "/static/js/main.0123abcd.js"
+)
+
+// User is a TEAM user known to the fake.
+type User struct {
+	ID       string
+	Username string
+	Email    string
+	GroupIDs []string
+	Policy   []*Entitlement
+}
+
+// Entitlement grants access to a set of accounts and permission sets.
+type Entitlement struct {
+	Accounts         []*Account
+	Permissions      []*Permission
+	ApprovalRequired bool
+	Duration         int
+}
+
+// Account is an AWS account referenced by an entitlement.
+type Account struct {
+	Name string `json:"name"`
+	ID   string `json:"id"`
+}
+
+// Permission is a permission set referenced by an entitlement.
+type Permission struct {
+	Name string `json:"name"`
+	ID   string `json:"id"`
+}
+
+// Server is a fake TEAM deployment.
+type Server struct {
+	*httptest.Server
+
+	// Now returns the current time, used when timestamping requests.
+	Now func() time.Time
+
+	// SyncPolicy causes getUserPolicy to return the policy directly, rather than publishing it via onPublishPolicy.
+	SyncPolicy bool
+
+	// OnGetUserPolicy is invoked before the policy of the user is published, allowing tests to interleave events.
+	OnGetUserPolicy func(s *Server, u *User)
+
+	// RedirectURIs are the callback URLs allowed by the Cognito app client.
+	RedirectURIs []string
+
+	// Approvers are the emails assigned as approvers of new requests.
+	Approvers []string
+
+	// AccessPortalURL is linked to from the TEAM frontend if set, as done by customised deployments.
+	AccessPortalURL string
+
+	mu       sync.Mutex
+	users    []*User
+	login    *User
+	codes    map[string]*authCode
+	access   map[string]*User
+	refresh  map[string]*User
+	requests []*Request
+	subs     map[*subscription]struct{}
+	nextID   int
+}
+
+type authCode struct {
+	user        *User
+	redirectURI string
+	challenge   string
+}
+
+// New starts a fake TEAM server, which is closed when the test completes.
+func New(t testing.TB) *Server {
+	t.Helper()
+
+	s := &Server{
+		Now:     time.Now,
+		codes:   make(map[string]*authCode),
+		access:  make(map[string]*User),
+		refresh: make(map[string]*User),
+		subs:    make(map[*subscription]struct{}),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", s.handleHomepage)
+	mux.HandleFunc("GET "+jsPath, s.handleScript)
+	mux.HandleFunc("GET /oauth2/authorize", s.handleAuthorize)
+	mux.HandleFunc("POST /oauth2/token", s.handleToken)
+	mux.HandleFunc("POST /graphql", s.handleGraphQL)
+	mux.HandleFunc("GET /graphql/realtime", s.handleRealtime)
+
+	s.Server = httptest.NewTLSServer(mux)
+	s.RedirectURIs = []string{LocalhostRedirect, s.URL + "/device_code/"}
+
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+// Context returns a context which causes team and gql requests to trust the server.
+func (s *Server) Context(ctx context.Context) context.Context {
+	return gql.WithHTTPClient(ctx, s.Client())
+}
+
+// RemoteConfig returns the configuration team.ExtractConfig is expected to produce for the server.
+func (s *Server) RemoteConfig() *team.RemoteConfig {
+	return &team.RemoteConfig{
+		Server:            s.URL,
+		GraphQLEndpoint:   s.URL + "/graphql",
+		UserPoolClientID:  ClientID,
+		OAuthDomain:       s.host(),
+		OAuthResponseType: "code",
+		OAuthScopes:       []string{"phone", "email", "openid", "profile", "aws.cognito.signin.user.admin"},
+		RedirectSignIn:    s.URL + "/",
+		AccessPortalURL:   s.AccessPortalURL,
+	}
+}
+
+func (s *Server) host() string {
+	u, _ := url.Parse(s.URL)
+
+	return u.Host
+}
+
+// AddUser registers a user. The first user added is the one logged in by the authorize endpoint.
+func (s *Server) AddUser(u *User) *User {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.users = append(s.users, u)
+
+	if s.login == nil {
+		s.login = u
+	}
+
+	return u
+}
+
+// Login sets the user logged in by the authorize endpoint.
+func (s *Server) Login(u *User) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.login = u
+}
+
+// Token issues a token for the user without going through the OAuth flow.
+func (s *Server) Token(u *User) *team.AuthToken {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	raw := s.issueToken(u, true)
+
+	return &team.AuthToken{
+		IdToken:      raw["id_token"].(string),
+		AccessToken:  raw["access_token"].(string),
+		RefreshToken: raw["refresh_token"].(string),
+		ExpiresAt:    s.Now().Add(time.Hour),
+		TokenType:    "Bearer",
+	}
+}
+
+// IssueCode creates an authorization code for the user, as if they had logged in via the given redirect URI.
+func (s *Server) IssueCode(u *User, redirectURI string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.issueCode(u, redirectURI, "")
+}
+
+func (s *Server) issueCode(u *User, redirectURI string, challenge string) string {
+	code := s.newID("code")
+
+	s.codes[code] = &authCode{
+		user:        u,
+		redirectURI: redirectURI,
+		challenge:   challenge,
+	}
+
+	return code
+}
+
+func (s *Server) newID(prefix string) string {
+	s.nextID++
+
+	return fmt.Sprintf("%s-%012d", prefix, s.nextID)
+}
+
+func (s *Server) handleHomepage(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/html")
+
+	_, _ = fmt.Fprintf(w, `<!doctype html><html lang="en"><head><title>TEAM</title>`+
+		`<script defer="defer" src="%s"></script></head><body><div id="root"></div></body></html>`, jsPath)
+}
+
+func (s *Server) handleScript(w http.ResponseWriter, _ *http.Request) {
+	cfg := s.RemoteConfig()
+
+	scopes, _ := json.Marshal(cfg.OAuthScopes)
+
+	w.Header().Set("Content-Type", "application/javascript")
+
+	_, _ = fmt.Fprintf(
+		w,
+		`(()=>{var e={aws_project_region:"us-east-1",aws_appsync_graphqlEndpoint:%q,`+
+			`aws_appsync_region:"us-east-1",aws_appsync_authenticationType:"AMAZON_COGNITO_USER_POOLS",`+
+			`aws_cognito_region:"us-east-1",aws_user_pools_id:"us-east-1_teamtest",aws_user_pools_web_client_id:%q,`+
+			`oauth:{domain:%q,scope:%s,redirectSignIn:%q,redirectSignOut:%q,responseType:%q}};window.config=e})();`,
+		cfg.GraphQLEndpoint,
+		cfg.UserPoolClientID,
+		cfg.OAuthDomain,
+		scopes,
+		cfg.RedirectSignIn,
+		cfg.RedirectSignIn,
+		cfg.OAuthResponseType,
+	)
+
+	if cfg.AccessPortalURL != "" {
+		_, _ = fmt.Fprintf(w, `var t=%q;`, cfg.AccessPortalURL)
+	}
+}
+
+func (s *Server) handleAuthorize(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+
+	redirectURI := params.Get("redirect_uri")
+
+	if params.Get("client_id") != ClientID || !slices.Contains(s.RedirectURIs, redirectURI) {
+		http.Redirect(w, r, "/error?error=redirect_mismatch&client_id="+url.QueryEscape(params.Get("client_id")), http.StatusFound)
+
+		return
+	}
+
+	if params.Get("response_type") != "code" {
+		http.Redirect(w, r, redirectURI+"?error=unsupported_response_type", http.StatusFound)
+
+		return
+	}
+
+	s.mu.Lock()
+
+	if s.login == nil {
+		s.mu.Unlock()
+		http.Error(w, "no user to log in", http.StatusUnauthorized)
+
+		return
+	}
+
+	code := s.issueCode(s.login, redirectURI, params.Get("code_challenge"))
+
+	s.mu.Unlock()
+
+	redir := url.Values{
+		"code":  {code},
+		"state": {params.Get("state")},
+	}
+
+	http.Redirect(w, r, redirectURI+"?"+redir.Encode(), http.StatusFound)
+}
+
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		tokenError(w, "invalid_request")
+
+		return
+	}
+
+	if r.PostForm.Get("client_id") != ClientID {
+		tokenError(w, "invalid_client")
+
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch r.PostForm.Get("grant_type") {
+	case "authorization_code":
+		code, ok := s.codes[r.PostForm.Get("code")]
+		if !ok || code.redirectURI != r.PostForm.Get("redirect_uri") {
+			tokenError(w, "invalid_grant")
+
+			return
+		}
+
+		delete(s.codes, r.PostForm.Get("code"))
+
+		if code.challenge != "" {
+			hash := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+
+			if base64.RawURLEncoding.EncodeToString(hash[:]) != code.challenge {
+				tokenError(w, "invalid_grant")
+
+				return
+			}
+		}
+
+		writeJSON(w, s.issueToken(code.user, true))
+	case "refresh_token":
+		user, ok := s.refresh[r.PostForm.Get("refresh_token")]
+		if !ok {
+			tokenError(w, "invalid_grant")
+
+			return
+		}
+
+		// Cognito does not return a new refresh token when refreshing.
+		writeJSON(w, s.issueToken(user, false))
+	default:
+		tokenError(w, "unsupported_grant_type")
+	}
+}
+
+func (s *Server) issueToken(u *User, withRefresh bool) map[string]any {
+	claims, _ := json.Marshal(map[string]any{
+		"sub":              u.ID,
+		"userId":           u.ID,
+		"groupIds":         strings.Join(u.GroupIDs, ","),
+		"email":            u.Email,
+		"cognito:username": u.Username,
+		"exp":              s.Now().Add(time.Hour).Unix(),
+	})
+
+	idToken := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","typ":"JWT"}`)) + "." +
+		base64.RawURLEncoding.EncodeToString(claims) + ".teamtest"
+
+	accessToken := s.newID("access")
+	s.access[accessToken] = u
+
+	out := map[string]any{
+		"id_token":     idToken,
+		"access_token": accessToken,
+		"expires_in":   3600,
+		"token_type":   "Bearer",
+	}
+
+	if withRefresh {
+		refreshToken := s.newID("refresh")
+		s.refresh[refreshToken] = u
+		out["refresh_token"] = refreshToken
+	}
+
+	return out
+}
+
+func (s *Server) userForToken(token string) *User {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.access[token]
+}
+
+func tokenError(w http.ResponseWriter, code string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": code})
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+
+	_ = json.NewEncoder(w).Encode(v)
+}
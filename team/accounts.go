@@ -0,0 +1,256 @@
+package team
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/csnewman/team-cli/internal/gql"
+)
+
+type Account struct {
+	ID    string
+	Name  string
+	Roles map[string]*Role
+}
+
+type Role struct {
+	ID   string
+	Name string
+
+	MaxDurNoApproval int
+	MaxDurApproval   int
+}
+
+// FetchAccounts returns the accounts and roles the user is entitled to request, keyed by account ID.
+func (c *Client) FetchAccounts(ctx context.Context) (map[string]*Account, error) {
+	slog.Info("Fetching AWS accounts")
+
+	ctx, token, err := c.prepare(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	idTok, err := token.ParseIDToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ID token: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Minute)
+	defer cancel()
+
+	var policy *policyFields
+
+	// The subscription must be active before the policy is requested, as older TEAM versions only publish the result.
+	if err := gql.Subscribe(
+		ctx,
+		c.Remote.GraphQLEndpoint,
+		token.AccessToken,
+		newOnPublishPolicyRequest(),
+		func(ctx context.Context) (bool, error) {
+			rawResult, err := do[getUserPolicyResult](ctx, c.gqlClient(token), newGetUserPolicyRequest(
+				&idTok.UserID,
+				strings.Split(idTok.GroupIDs, ","),
+			))
+			if err != nil {
+				return false, fmt.Errorf("failed to request: %w", err)
+			}
+
+			// Newer TEAM versions return the policy directly, rather than publishing it.
+			if rawResult.GetUserPolicy != nil && idTok.matchesPolicy(rawResult.GetUserPolicy) {
+				slog.Debug("Received policy synchronously")
+
+				policy = rawResult.GetUserPolicy
+
+				return false, nil
+			}
+
+			slog.Debug("Waiting for policy to be published")
+
+			return true, nil
+		},
+		func(ctx context.Context, payload *gql.Payload) (bool, error) {
+			var rawData onPublishPolicyResult
+
+			if err := payload.UnmarshalData(&rawData); err != nil {
+				return false, fmt.Errorf("failed to unmarshal payload: %w", err)
+			}
+
+			if rawData.OnPublishPolicy == nil || !idTok.matchesPolicy(rawData.OnPublishPolicy) {
+				slog.Debug("Ignoring policy published for another user")
+
+				return true, nil
+			}
+
+			policy = rawData.OnPublishPolicy
+
+			return false, nil
+		},
+	); err != nil {
+		return nil, fmt.Errorf("failed to fetch: %w", err)
+	}
+
+	if policy == nil {
+		return nil, fmt.Errorf("%w: no policy received", ErrUnexpected)
+	}
+
+	return policy.accounts()
+}
+
+// accounts returns the accounts and roles granted by the policy, keyed by account ID.
+func (p *policyFields) accounts() (map[string]*Account, error) {
+	accounts := make(map[string]*Account)
+
+	for _, pol := range p.Policy {
+		slog.Debug("Policy", "dur", pol.Duration, "approval_required", pol.ApprovalRequired)
+
+		duration, err := strconv.Atoi(pol.Duration)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse policy duration %q: %w", pol.Duration, err)
+		}
+
+		for _, account := range pol.Accounts {
+			slog.Debug("Account", "name", account.Name, "id", account.ID)
+
+			acc, ok := accounts[account.ID]
+			if !ok {
+				acc = &Account{
+					ID:    account.ID,
+					Name:  account.Name,
+					Roles: make(map[string]*Role),
+				}
+
+				accounts[account.ID] = acc
+			}
+
+			for _, perm := range pol.Permissions {
+				slog.Debug("Permission", "name", perm.Name, "id", perm.ID)
+
+				role, ok := acc.Roles[perm.ID]
+				if !ok {
+					role = &Role{
+						ID:   perm.ID,
+						Name: perm.Name,
+					}
+
+					acc.Roles[perm.ID] = role
+				}
+
+				role.MaxDurApproval = max(duration, role.MaxDurApproval)
+
+				if !pol.ApprovalRequired {
+					role.MaxDurNoApproval = max(duration, role.MaxDurNoApproval)
+				}
+			}
+		}
+	}
+
+	return accounts, nil
+}
+
+// WatchAccounts requests the policy of the user, then calls fn with the accounts and roles it grants whenever the
+// policy is published, until the context is cancelled or the subscription fails. TEAM publishes the policy whenever it
+// is evaluated, such as when the user opens the TEAM frontend. Calls are not concurrent.
+func (c *Client) WatchAccounts(ctx context.Context, fn func(accounts map[string]*Account)) error {
+	slog.Info("Watching AWS accounts")
+
+	ctx, token, err := c.prepare(ctx)
+	if err != nil {
+		return err
+	}
+
+	idTok, err := token.ParseIDToken()
+	if err != nil {
+		return fmt.Errorf("failed to parse ID token: %w", err)
+	}
+
+	deliver := func(policy *policyFields) error {
+		accounts, err := policy.accounts()
+		if err != nil {
+			return err
+		}
+
+		fn(accounts)
+
+		return nil
+	}
+
+	err = gql.Subscribe(
+		ctx,
+		c.Remote.GraphQLEndpoint,
+		token.AccessToken,
+		newOnPublishPolicyRequest(),
+		func(ctx context.Context) (bool, error) {
+			rawResult, err := do[getUserPolicyResult](ctx, c.gqlClient(token), newGetUserPolicyRequest(
+				&idTok.UserID,
+				strings.Split(idTok.GroupIDs, ","),
+			))
+			if err != nil {
+				return false, fmt.Errorf("failed to request: %w", err)
+			}
+
+			// Newer TEAM versions return the policy directly, as well as publishing it
+			if rawResult.GetUserPolicy != nil && idTok.matchesPolicy(rawResult.GetUserPolicy) {
+				if err := deliver(rawResult.GetUserPolicy); err != nil {
+					return false, err
+				}
+			}
+
+			return true, nil
+		},
+		func(ctx context.Context, payload *gql.Payload) (bool, error) {
+			var rawData onPublishPolicyResult
+
+			if err := payload.UnmarshalData(&rawData); err != nil {
+				return false, fmt.Errorf("failed to unmarshal payload: %w", err)
+			}
+
+			if rawData.OnPublishPolicy == nil || !idTok.matchesPolicy(rawData.OnPublishPolicy) {
+				slog.Debug("Ignoring policy published for another user")
+
+				return true, nil
+			}
+
+			slog.Debug("Policy published")
+
+			if err := deliver(rawData.OnPublishPolicy); err != nil {
+				return false, err
+			}
+
+			return true, nil
+		},
+	)
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to watch: %w", err)
+	}
+
+	return fmt.Errorf("%w: subscription ended", ErrUnexpected)
+}
+
+// matchesPolicy reports whether a policy was generated for the user of the ID token. Policies which do not identify
+// their user are accepted, as there is no way to tell them apart.
+func (t *IDToken) matchesPolicy(policy *policyFields) bool {
+	if policy.ID == "" && policy.Username == "" {
+		return true
+	}
+
+	if policy.ID != "" && policy.ID == t.UserID {
+		return true
+	}
+
+	if policy.Username == "" {
+		return false
+	}
+
+	email, _ := t.Email.(string)
+
+	return policy.Username == t.Username || policy.Username == email
+}
@@ -0,0 +1,134 @@
+package team_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/csnewman/team-cli/internal/teamtest"
+	"github.com/csnewman/team-cli/team"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchAccounts(t *testing.T) {
+	t.Parallel()
+
+	expected := map[string]*team.Account{
+		"111111111111": {
+			ID:   "111111111111",
+			Name: "prod",
+			Roles: map[string]*team.Role{
+				"perm-ro": {
+					ID:               "perm-ro",
+					Name:             "ReadOnlyAccess",
+					MaxDurNoApproval: 4,
+					MaxDurApproval:   8,
+				},
+				"perm-admin": {
+					ID:             "perm-admin",
+					Name:           "AdministratorAccess",
+					MaxDurApproval: 8,
+				},
+			},
+		},
+	}
+
+	for _, tc := range []struct {
+		name  string
+		setup func(srv *teamtest.Server)
+	}{
+		{
+			name:  "published",
+			setup: func(*teamtest.Server) {},
+		},
+		{
+			name: "synchronous",
+			setup: func(srv *teamtest.Server) {
+				srv.SyncPolicy = true
+			},
+		},
+		{
+			name: "other user published first",
+			setup: func(srv *teamtest.Server) {
+				other := srv.AddUser(&teamtest.User{
+					ID:       "user-2",
+					Username: "bob",
+					Email:    "bob@example.com",
+					Policy: []*teamtest.Entitlement{{
+						Accounts:    []*teamtest.Account{{Name: "dev", ID: "222222222222"}},
+						Permissions: []*teamtest.Permission{{Name: "AdministratorAccess", ID: "perm-admin"}},
+						Duration:    1,
+					}},
+				})
+
+				srv.OnGetUserPolicy = func(srv *teamtest.Server, _ *teamtest.User) {
+					srv.PublishPolicy(other)
+				}
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			srv, user := newTestServer(t)
+			tc.setup(srv)
+
+			accounts, err := newTestClient(srv, user).FetchAccounts(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, expected, accounts)
+		})
+	}
+}
+
+func TestWatchAccounts(t *testing.T) {
+	t.Parallel()
+
+	srv, user := newTestServer(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	updates := make(chan map[string]*team.Account, 10)
+	done := make(chan error, 1)
+
+	go func() {
+		done <- newTestClient(srv, user).WatchAccounts(ctx, func(accounts map[string]*team.Account) {
+			updates <- accounts
+		})
+	}()
+
+	// The policy is requested once subscribed
+	accounts := <-updates
+	require.Contains(t, accounts, "111111111111")
+	require.NotContains(t, accounts, "222222222222")
+
+	// And updated whenever it is published
+	srv.PublishPolicy(&teamtest.User{
+		ID:       user.ID,
+		Username: user.Username,
+		Policy: []*teamtest.Entitlement{{
+			Accounts:    []*teamtest.Account{{Name: "dev", ID: "222222222222"}},
+			Permissions: []*teamtest.Permission{{Name: "ReadOnlyAccess", ID: "perm-ro"}},
+			Duration:    2,
+		}},
+	})
+
+	accounts = <-updates
+	require.Equal(t, map[string]*team.Account{
+		"222222222222": {
+			ID:   "222222222222",
+			Name: "dev",
+			Roles: map[string]*team.Role{
+				"perm-ro": {
+					ID:               "perm-ro",
+					Name:             "ReadOnlyAccess",
+					MaxDurNoApproval: 2,
+					MaxDurApproval:   2,
+				},
+			},
+		},
+	}, accounts)
+
+	cancel()
+	require.ErrorIs(t, <-done, context.Canceled)
+}
@@ -17,12 +17,70 @@ import (
 	"runtime"
 	"strings"
 	"time"
+
+	"github.com/csnewman/team-cli/internal/gql"
 )
 
 //go:embed auth.html
 var closePageSrc string
 
-const localhostRedir = "http://localhost:43672/"
+// LocalhostRedirectURI is the redirect URI used by FetchToken, which must be allowed by the Cognito app client.
+const LocalhostRedirectURI = "http://localhost:43672/"
+
+// ErrRedirectMismatch is returned by CheckRedirectURI when Cognito does not allow the redirect URI.
+var ErrRedirectMismatch = errors.New("redirect URI is not allowed by the app client")
+
+// DeviceCodeRedirectURI is the redirect URI used by FetchTokenViaDeviceCode, which must be allowed by the Cognito app
+// client.
+func (c *RemoteConfig) DeviceCodeRedirectURI() string {
+	return c.RedirectSignIn + "device_code/"
+}
+
+// CheckRedirectURI begins an authorization using the redirect URI, without following the resulting redirect, to
+// check whether Cognito allows it.
+func CheckRedirectURI(ctx context.Context, cfg *RemoteConfig, redirectURI string) error {
+	params := url.Values{
+		"redirect_uri":  {redirectURI},
+		"response_type": {cfg.OAuthResponseType},
+		"client_id":     {cfg.UserPoolClientID},
+		"scope":         {strings.Join(cfg.OAuthScopes, " ")},
+	}
+
+	u := url.URL{
+		Scheme:   "https",
+		Host:     cfg.OAuthDomain,
+		Path:     "/oauth2/authorize",
+		RawQuery: params.Encode(),
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return fmt.Errorf("could not create request: %w", err)
+	}
+
+	client := *gql.HTTPClient(ctx)
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("could not send request: %w", err)
+	}
+
+	defer resp.Body.Close()
+
+	// Cognito reports rejected redirect URIs by redirecting to its error page
+	if location, err := resp.Location(); err == nil && location.Query().Get("error") == "redirect_mismatch" {
+		return ErrRedirectMismatch
+	}
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("%w: authorize failed: %v", ErrUnexpected, resp.Status)
+	}
+
+	return nil
+}
 
 type AuthToken struct {
 	IdToken      string    `json:"id_token"`
@@ -36,6 +94,7 @@ type IDToken struct {
 	UserID   string `json:"userId"`
 	GroupIDs string `json:"groupIds"`
 	Email    any    `json:"email"`
+	Username string `json:"cognito:username"`
 }
 
 func (t *AuthToken) ParseIDToken() (*IDToken, error) {
@@ -70,6 +129,7 @@ type rawAuthToken struct {
 func FetchTokenViaDeviceCode(
 	ctx context.Context,
 	cfg *RemoteConfig,
+	out io.Writer,
 	readCode func(context.Context) (string, error),
 ) (*AuthToken, error) {
 	slog.Info("Fetching authentication token")
@@ -77,7 +137,7 @@ func FetchTokenViaDeviceCode(
 	state := randomCharacters(32)
 	pkceKey, challenge := generateChallenge()
 
-	redirUri := cfg.RedirectSignIn + "device_code/"
+	redirUri := cfg.DeviceCodeRedirectURI()
 
 	params := url.Values{
 		"redirect_uri":  {redirUri},
@@ -99,8 +159,8 @@ func FetchTokenViaDeviceCode(
 		RawQuery: params.Encode(),
 	}
 
-	fmt.Println("\nPlease visit the following URL in your browser to authenticate:")
-	fmt.Println(u.String())
+	_, _ = fmt.Fprintln(out, "\nPlease visit the following URL in your browser to authenticate:")
+	_, _ = fmt.Fprintln(out, u.String())
 
 	code, err := readCode(ctx)
 	if err != nil {
@@ -123,7 +183,7 @@ func FetchTokenViaDeviceCode(
 	return fetchToken(ctx, u, data)
 }
 
-func FetchToken(ctx context.Context, cfg *RemoteConfig, noBrowser bool) (*AuthToken, error) {
+func FetchToken(ctx context.Context, cfg *RemoteConfig, out io.Writer, noBrowser bool) (*AuthToken, error) {
 	slog.Info("Fetching authentication token")
 
 	codeChan := make(chan string, 1)
@@ -167,7 +227,7 @@ func FetchToken(ctx context.Context, cfg *RemoteConfig, noBrowser bool) (*AuthTo
 	state := randomCharacters(32)
 	pkceKey, challenge := generateChallenge()
 
-	redirUri := localhostRedir
+	redirUri := LocalhostRedirectURI
 
 	params := url.Values{
 		"redirect_uri":  {redirUri},
@@ -189,11 +249,11 @@ func FetchToken(ctx context.Context, cfg *RemoteConfig, noBrowser bool) (*AuthTo
 		RawQuery: params.Encode(),
 	}
 
-	fmt.Println("\nPlease visit the following URL in your browser to authenticate:")
-	fmt.Println(u.String())
+	_, _ = fmt.Fprintln(out, "\nPlease visit the following URL in your browser to authenticate:")
+	_, _ = fmt.Fprintln(out, u.String())
 
 	if !noBrowser {
-		if err := openBrowser(u.String()); err != nil {
+		if err := OpenBrowser(u.String()); err != nil {
 			slog.Warn("failed to open browser", "err", err)
 		}
 	}
@@ -227,6 +287,8 @@ func FetchToken(ctx context.Context, cfg *RemoteConfig, noBrowser bool) (*AuthTo
 	return fetchToken(ctx, u, data)
 }
 
+// RefreshToken exchanges the refresh token of the old token for a new token. Cognito does not return a new refresh
+// token, so the old one is kept, allowing long running clients to refresh again once the new token expires.
 func RefreshToken(ctx context.Context, remote *RemoteConfig, old *AuthToken) (*AuthToken, error) {
 	u := url.URL{
 		Scheme: "https",
@@ -239,7 +301,16 @@ func RefreshToken(ctx context.Context, remote *RemoteConfig, old *AuthToken) (*A
 	data.Set("client_id", remote.UserPoolClientID)
 	data.Set("refresh_token", old.RefreshToken)
 
-	return fetchToken(ctx, u, data)
+	token, err := fetchToken(ctx, u, data)
+	if err != nil {
+		return nil, err
+	}
+
+	if token.RefreshToken == "" {
+		token.RefreshToken = old.RefreshToken
+	}
+
+	return token, nil
 }
 
 func fetchToken(ctx context.Context, u url.URL, data url.Values) (*AuthToken, error) {
@@ -255,7 +326,7 @@ func fetchToken(ctx context.Context, u url.URL, data url.Values) (*AuthToken, er
 
 	r.Header.Add("Content-Type", "application/x-www-form-urlencoded")
 
-	resp, err := http.DefaultClient.Do(r)
+	resp, err := gql.HTTPClient(ctx).Do(r)
 	if err != nil {
 		return nil, fmt.Errorf("failed to send token request: %w", err)
 	}
@@ -310,7 +381,8 @@ func generateChallenge() (string, string) {
 	return challenge, encoded
 }
 
-func openBrowser(url string) error {
+// OpenBrowser opens the URL in the default browser, without waiting for it to close.
+func OpenBrowser(url string) error {
 	var (
 		cmd  string
 		args []string
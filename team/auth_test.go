@@ -0,0 +1,99 @@
+package team_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/csnewman/team-cli/team"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchTokenViaDeviceCode(t *testing.T) {
+	t.Parallel()
+
+	srv, user := newTestServer(t)
+
+	client := srv.Client()
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	var out bytes.Buffer
+
+	token, err := team.FetchTokenViaDeviceCode(
+		srv.Context(context.Background()),
+		srv.RemoteConfig(),
+		&out,
+		func(context.Context) (string, error) {
+			// The URL is the last line printed
+			lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+
+			resp, err := client.Get(lines[len(lines)-1])
+			if err != nil {
+				return "", err
+			}
+
+			defer resp.Body.Close()
+
+			redir, err := url.Parse(resp.Header.Get("Location"))
+			if err != nil {
+				return "", err
+			}
+
+			require.Equal(t, srv.URL+"/device_code/", redir.Scheme+"://"+redir.Host+redir.Path)
+
+			return redir.Query().Get("code"), nil
+		},
+	)
+	require.NoError(t, err)
+	require.NotEmpty(t, token.AccessToken)
+	require.NotEmpty(t, token.RefreshToken)
+
+	idTok, err := token.ParseIDToken()
+	require.NoError(t, err)
+	require.Equal(t, user.ID, idTok.UserID)
+	require.Equal(t, "group-1,group-2", idTok.GroupIDs)
+	require.Equal(t, user.Email, idTok.Email)
+	require.Equal(t, user.Username, idTok.Username)
+}
+
+func TestRefreshToken(t *testing.T) {
+	t.Parallel()
+
+	srv, user := newTestServer(t)
+
+	old := srv.Token(user)
+
+	token, err := team.RefreshToken(srv.Context(context.Background()), srv.RemoteConfig(), old)
+	require.NoError(t, err)
+	require.NotEqual(t, old.AccessToken, token.AccessToken)
+	require.Equal(t, old.RefreshToken, token.RefreshToken)
+
+	// The refreshed token can itself be refreshed
+	again, err := team.RefreshToken(srv.Context(context.Background()), srv.RemoteConfig(), token)
+	require.NoError(t, err)
+	require.NotEqual(t, token.AccessToken, again.AccessToken)
+	require.Equal(t, old.RefreshToken, again.RefreshToken)
+
+	_, err = team.RefreshToken(srv.Context(context.Background()), srv.RemoteConfig(), &team.AuthToken{
+		RefreshToken: "invalid",
+	})
+	require.ErrorIs(t, err, team.ErrUnexpected)
+}
+
+func TestCheckRedirectURI(t *testing.T) {
+	t.Parallel()
+
+	srv, _ := newTestServer(t)
+	srv.RedirectURIs = []string{team.LocalhostRedirectURI}
+
+	ctx := srv.Context(context.Background())
+	remote := srv.RemoteConfig()
+
+	require.NoError(t, team.CheckRedirectURI(ctx, remote, team.LocalhostRedirectURI))
+	require.ErrorIs(t, team.CheckRedirectURI(ctx, remote, remote.DeviceCodeRedirectURI()), team.ErrRedirectMismatch)
+}
@@ -0,0 +1,138 @@
+package team
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/csnewman/team-cli/internal/gql"
+)
+
+// API is the set of TEAM operations provided by Client, allowing consumers to substitute a mock.
+type API interface {
+	FetchAccounts(ctx context.Context) (map[string]*Account, error)
+	GetRequest(ctx context.Context, id string) (*PermissionRequest, error)
+	ListRequests(ctx context.Context, filter ListRequestsFilter) ([]*PermissionRequest, error)
+	Request(ctx context.Context, req *AccessRequest) (string, error)
+	Respond(ctx context.Context, resp *AccessResponse) error
+	WaitForRequest(ctx context.Context, id string, done func(req *PermissionRequest) bool) (*PermissionRequest, error)
+	WatchRequests(ctx context.Context, onReady func(ctx context.Context) error, fn func(req *PermissionRequest)) error
+}
+
+var _ API = (*Client)(nil)
+
+// Client performs operations against a TEAM deployment on behalf of a single user.
+type Client struct {
+	Remote *RemoteConfig
+	Tokens TokenSource
+
+	// HTTPClient is used for all requests. If nil, the client provided via WithHTTPClient is used, falling back to
+	// http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+func NewClient(remote *RemoteConfig, tokens TokenSource) *Client {
+	return &Client{
+		Remote: remote,
+		Tokens: tokens,
+	}
+}
+
+func (c *Client) prepare(ctx context.Context) (context.Context, *AuthToken, error) {
+	if c.HTTPClient != nil {
+		ctx = gql.WithHTTPClient(ctx, c.HTTPClient)
+	}
+
+	token, err := c.Tokens.Token(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get token: %w", err)
+	}
+
+	return ctx, token, nil
+}
+
+func (c *Client) gqlClient(token *AuthToken) *gql.Client {
+	return &gql.Client{
+		Endpoint:    c.Remote.GraphQLEndpoint,
+		AccessToken: token.AccessToken,
+	}
+}
+
+// do executes the request, reporting errors returned by the server as ErrUnexpected.
+func do[T any](ctx context.Context, client *gql.Client, req *gql.Request) (T, error) {
+	result, err := gql.Do[T](ctx, client, req)
+
+	var respErr *gql.ResponseError
+	if errors.As(err, &respErr) {
+		return result, fmt.Errorf("%w: %w", ErrUnexpected, err)
+	}
+
+	return result, err
+}
+
+// ptr returns a pointer to the value, for setting the nullable fields of inputs.
+func ptr[T any](value T) *T {
+	return &value
+}
+
+// WithHTTPClient returns a context which causes package level functions, such as ExtractConfig and FetchToken, to send
+// requests via the given client.
+func WithHTTPClient(ctx context.Context, client *http.Client) context.Context {
+	return gql.WithHTTPClient(ctx, client)
+}
+
+// TokenSource provides the token used to authenticate requests.
+type TokenSource interface {
+	Token(ctx context.Context) (*AuthToken, error)
+}
+
+type staticTokenSource struct {
+	token *AuthToken
+}
+
+// StaticTokenSource returns a TokenSource which always returns the given token.
+func StaticTokenSource(token *AuthToken) TokenSource {
+	return &staticTokenSource{token: token}
+}
+
+func (s *staticTokenSource) Token(context.Context) (*AuthToken, error) {
+	return s.token, nil
+}
+
+// refreshWindow is how long before expiry a token is refreshed.
+const refreshWindow = 5 * time.Minute
+
+type refreshingTokenSource struct {
+	remote *RemoteConfig
+	mu     sync.Mutex
+	token  *AuthToken
+}
+
+// RefreshingTokenSource returns a TokenSource which refreshes the token shortly before it expires.
+func RefreshingTokenSource(remote *RemoteConfig, token *AuthToken) TokenSource {
+	return &refreshingTokenSource{
+		remote: remote,
+		token:  token,
+	}
+}
+
+func (s *refreshingTokenSource) Token(ctx context.Context) (*AuthToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if time.Until(s.token.ExpiresAt) > refreshWindow {
+		return s.token, nil
+	}
+
+	token, err := RefreshToken(ctx, s.remote, s.token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to refresh token: %w", err)
+	}
+
+	s.token = token
+
+	return token, nil
+}
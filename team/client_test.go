@@ -0,0 +1,40 @@
+package team_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/csnewman/team-cli/team"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRefreshingTokenSource(t *testing.T) {
+	t.Parallel()
+
+	srv, user := newTestServer(t)
+	ctx := srv.Context(context.Background())
+
+	valid := srv.Token(user)
+
+	tokens := team.RefreshingTokenSource(srv.RemoteConfig(), valid)
+
+	token, err := tokens.Token(ctx)
+	require.NoError(t, err)
+	require.Same(t, valid, token)
+
+	expired := srv.Token(user)
+	expired.ExpiresAt = time.Now().Add(-time.Minute)
+
+	tokens = team.RefreshingTokenSource(srv.RemoteConfig(), expired)
+
+	token, err = tokens.Token(ctx)
+	require.NoError(t, err)
+	require.NotEqual(t, expired.AccessToken, token.AccessToken)
+	require.Equal(t, expired.RefreshToken, token.RefreshToken)
+	require.True(t, token.ExpiresAt.After(time.Now()))
+
+	again, err := tokens.Token(ctx)
+	require.NoError(t, err)
+	require.Same(t, token, again)
+}
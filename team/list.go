@@ -0,0 +1,188 @@
+package team
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+)
+
+type PermissionRequest struct {
+	ID     string `json:"id"`
+	Email  string `json:"email"`
+	Status string `json:"status"`
+
+	AccountID     string    `json:"accountId"`
+	AccountName   string    `json:"accountName"`
+	Role          string    `json:"role"`
+	RoleID        string    `json:"roleId"`
+	StartTime     time.Time `json:"startTime"`
+	EndTime       time.Time `json:"endTime"`
+	Duration      string    `json:"duration"`
+	TicketNo      string    `json:"ticketNo"`
+	Justification string    `json:"justification"`
+
+	Comment    string   `json:"comment"`
+	Approver   string   `json:"approver"`
+	ApproverID string   `json:"approverId"`
+	Approvers  []string `json:"approvers"`
+
+	Revoker   string `json:"revoker"`
+	RevokerID string `json:"revokerId"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+type ListRequestsFilter string
+
+const (
+	ListRequestsFilterAll                ListRequestsFilter = "all"
+	ListRequestsFilterRequiresMyApproval ListRequestsFilter = "requires-my-approval"
+	ListRequestsFilterMyPending          ListRequestsFilter = "my-pending"
+	ListRequestsFilterMyActive           ListRequestsFilter = "my-active"
+)
+
+// GetRequest returns the request with the given ID.
+func (c *Client) GetRequest(ctx context.Context, id string) (*PermissionRequest, error) {
+	ctx, token, err := c.prepare(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rawResult, err := do[getRequestsResult](ctx, c.gqlClient(token), newGetRequestsRequest(id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to request: %w", err)
+	}
+
+	if rawResult.GetRequests == nil {
+		return nil, fmt.Errorf("%w: request %q not found", ErrUnexpected, id)
+	}
+
+	return rawResult.GetRequests.toPermissionRequest()
+}
+
+// ListRequests returns the requests matching the filter.
+func (c *Client) ListRequests(ctx context.Context, filter ListRequestsFilter) ([]*PermissionRequest, error) {
+	ctx, token, err := c.prepare(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	idTok, err := token.ParseIDToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ID token: %w", err)
+	}
+
+	var filterInput *modelRequestsFilterInput
+
+	switch filter {
+	case ListRequestsFilterAll:
+	// no filter
+	case ListRequestsFilterRequiresMyApproval:
+		email, _ := idTok.Email.(string)
+
+		filterInput = &modelRequestsFilterInput{
+			And: []*modelRequestsFilterInput{
+				{Email: &modelStringInput{Ne: ptr(email)}},
+				{Status: &modelStringInput{Eq: ptr("pending")}},
+				{Approvers: &modelStringInput{Contains: ptr(email)}},
+			},
+		}
+	case ListRequestsFilterMyPending:
+		email, _ := idTok.Email.(string)
+
+		filterInput = &modelRequestsFilterInput{
+			And: []*modelRequestsFilterInput{
+				{Email: &modelStringInput{Eq: ptr(email)}},
+				{Status: &modelStringInput{Eq: ptr("pending")}},
+			},
+		}
+	case ListRequestsFilterMyActive:
+		email, _ := idTok.Email.(string)
+
+		filterInput = &modelRequestsFilterInput{
+			And: []*modelRequestsFilterInput{
+				{Email: &modelStringInput{Eq: ptr(email)}},
+				{Status: &modelStringInput{Eq: ptr("in progress")}},
+			},
+		}
+	default:
+		panic("unknown filter")
+	}
+
+	rawResult, err := do[listRequestsResult](
+		ctx,
+		c.gqlClient(token),
+		newListRequestsRequest(filterInput, nil, nil),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute: %w", err)
+	}
+
+	if rawResult.ListRequests == nil {
+		return nil, nil
+	}
+
+	requests := make([]*PermissionRequest, 0, len(rawResult.ListRequests.Items))
+
+	for _, item := range rawResult.ListRequests.Items {
+		req, err := item.toPermissionRequest()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse request %q: %w", item.ID, err)
+		}
+
+		requests = append(requests, req)
+	}
+
+	return requests, nil
+}
+
+// RequiresApprovalBy reports whether the request awaits approval by the user, as matched by
+// ListRequestsFilterRequiresMyApproval.
+func (r *PermissionRequest) RequiresApprovalBy(email string) bool {
+	return r.Email != email && r.Status == "pending" && slices.Contains(r.Approvers, email)
+}
+
+func (r *requestFields) toPermissionRequest() (*PermissionRequest, error) {
+	startTime, err := parseRequestTime(r.StartTime)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start time: %w", err)
+	}
+
+	endTime, err := parseRequestTime(r.EndTime)
+	if err != nil {
+		return nil, fmt.Errorf("invalid end time: %w", err)
+	}
+
+	return &PermissionRequest{
+		ID:            r.ID,
+		Email:         r.Email,
+		Status:        r.Status,
+		AccountID:     r.AccountID,
+		AccountName:   r.AccountName,
+		Role:          r.Role,
+		RoleID:        r.RoleID,
+		StartTime:     startTime,
+		EndTime:       endTime,
+		Duration:      r.Duration,
+		TicketNo:      r.TicketNo,
+		Justification: r.Justification,
+		Comment:       r.Comment,
+		Approver:      r.Approver,
+		ApproverID:    r.ApproverID,
+		Approvers:     r.Approvers,
+		Revoker:       r.Revoker,
+		RevokerID:     r.RevokerID,
+		CreatedAt:     r.CreatedAt,
+		UpdatedAt:     r.UpdatedAt,
+	}, nil
+}
+
+func parseRequestTime(raw string) (time.Time, error) {
+	if raw == "" {
+		return time.Time{}, nil
+	}
+
+	return time.Parse(time.RFC3339, raw)
+}
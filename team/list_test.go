@@ -0,0 +1,78 @@
+package team_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/csnewman/team-cli/internal/teamtest"
+	"github.com/csnewman/team-cli/team"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListRequests(t *testing.T) {
+	t.Parallel()
+
+	srv, user := newTestServer(t)
+
+	pending := srv.AddRequest(&teamtest.Request{
+		Email:       "bob@example.com",
+		AccountID:   "111111111111",
+		AccountName: "prod",
+		Role:        "AdministratorAccess",
+		RoleID:      "perm-admin",
+		StartTime:   "2030-01-02T03:04:00Z",
+		Duration:    "1",
+		Status:      "pending",
+		Approvers:   []string{user.Email},
+		TicketNo:    "INC-1",
+	})
+
+	srv.AddRequest(&teamtest.Request{
+		Email:     "bob@example.com",
+		StartTime: "2030-01-02T03:04:00Z",
+		Status:    "approved",
+		Approvers: []string{user.Email},
+	})
+
+	srv.AddRequest(&teamtest.Request{
+		Email:     user.Email,
+		StartTime: "2030-01-02T03:04:00Z",
+		Status:    "pending",
+		Approvers: []string{user.Email},
+	})
+
+	active := srv.AddRequest(&teamtest.Request{
+		Email:     user.Email,
+		StartTime: "2030-01-02T03:04:00Z",
+		EndTime:   "2030-01-02T04:04:00Z",
+		Status:    "in progress",
+	})
+
+	ctx := context.Background()
+	client := newTestClient(srv, user)
+
+	all, err := client.ListRequests(ctx, team.ListRequestsFilterAll)
+	require.NoError(t, err)
+	require.Len(t, all, 4)
+
+	mine, err := client.ListRequests(ctx, team.ListRequestsFilterRequiresMyApproval)
+	require.NoError(t, err)
+	require.Len(t, mine, 1)
+	require.Equal(t, pending.ID, mine[0].ID)
+	require.Equal(t, "AdministratorAccess", mine[0].Role)
+	require.Equal(t, time.Date(2030, 1, 2, 3, 4, 0, 0, time.UTC), mine[0].StartTime)
+	require.True(t, mine[0].EndTime.IsZero())
+
+	own, err := client.ListRequests(ctx, team.ListRequestsFilterMyPending)
+	require.NoError(t, err)
+	require.Len(t, own, 1)
+	require.Equal(t, user.Email, own[0].Email)
+	require.Equal(t, "pending", own[0].Status)
+
+	activeRequests, err := client.ListRequests(ctx, team.ListRequestsFilterMyActive)
+	require.NoError(t, err)
+	require.Len(t, activeRequests, 1)
+	require.Equal(t, active.ID, activeRequests[0].ID)
+	require.Equal(t, time.Date(2030, 1, 2, 4, 4, 0, 0, time.UTC), activeRequests[0].EndTime)
+}
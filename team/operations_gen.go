@@ -0,0 +1,706 @@
+// Code generated by gqlgen from operations.graphql. DO NOT EDIT.
+
+package team
+
+import (
+	"time"
+
+	"github.com/csnewman/team-cli/internal/gql"
+)
+
+// requestFields is the RequestFields fragment on requests.
+type requestFields struct {
+	ID              string    `json:"id"`
+	Email           string    `json:"email"`
+	AccountID       string    `json:"accountId"`
+	AccountName     string    `json:"accountName"`
+	Role            string    `json:"role"`
+	RoleID          string    `json:"roleId"`
+	StartTime       string    `json:"startTime"`
+	Duration        string    `json:"duration"`
+	Justification   string    `json:"justification"`
+	Status          string    `json:"status"`
+	Comment         string    `json:"comment"`
+	Username        string    `json:"username"`
+	Approver        string    `json:"approver"`
+	ApproverID      string    `json:"approverId"`
+	Approvers       []string  `json:"approvers"`
+	ApproverIDs     []string  `json:"approver_ids"`
+	Revoker         string    `json:"revoker"`
+	RevokerID       string    `json:"revokerId"`
+	EndTime         string    `json:"endTime"`
+	TicketNo        string    `json:"ticketNo"`
+	RevokeComment   string    `json:"revokeComment"`
+	SessionDuration string    `json:"session_duration"`
+	CreatedAt       time.Time `json:"createdAt"`
+	UpdatedAt       time.Time `json:"updatedAt"`
+	Owner           string    `json:"owner"`
+	Typename        string    `json:"__typename"`
+}
+
+// policyFields is the PolicyFields fragment on Policy.
+type policyFields struct {
+	ID       string                `json:"id"`
+	Policy   []*policyFieldsPolicy `json:"policy"`
+	Username string                `json:"username"`
+	Typename string                `json:"__typename"`
+}
+
+type policyFieldsPolicy struct {
+	Accounts         []*policyFieldsPolicyAccounts    `json:"accounts"`
+	Permissions      []*policyFieldsPolicyPermissions `json:"permissions"`
+	ApprovalRequired bool                             `json:"approvalRequired"`
+	Duration         string                           `json:"duration"`
+	Typename         string                           `json:"__typename"`
+}
+
+type policyFieldsPolicyAccounts struct {
+	Name     string `json:"name"`
+	ID       string `json:"id"`
+	Typename string `json:"__typename"`
+}
+
+type policyFieldsPolicyPermissions struct {
+	Name     string `json:"name"`
+	ID       string `json:"id"`
+	Typename string `json:"__typename"`
+}
+
+// listRequestsQuery is the ListRequests query.
+const listRequestsQuery = `query ListRequests ($filter: ModelRequestsFilterInput, $limit: Int, $nextToken: String) {
+  listRequests(filter: $filter, limit: $limit, nextToken: $nextToken) {
+    items {
+      ... RequestFields
+    }
+    nextToken
+    __typename
+  }
+}
+fragment RequestFields on requests {
+  id
+  email
+  accountId
+  accountName
+  role
+  roleId
+  startTime
+  duration
+  justification
+  status
+  comment
+  username
+  approver
+  approverId
+  approvers
+  approver_ids
+  revoker
+  revokerId
+  endTime
+  ticketNo
+  revokeComment
+  session_duration
+  createdAt
+  updatedAt
+  owner
+  __typename
+}`
+
+// newListRequestsRequest creates a request for the ListRequests query.
+func newListRequestsRequest(filter *modelRequestsFilterInput, limit *int, nextToken *string) *gql.Request {
+	return &gql.Request{
+		Query: listRequestsQuery,
+		Variables: gql.Variables{}.
+			SetOptional("filter", filter).
+			SetOptional("limit", limit).
+			SetOptional("nextToken", nextToken),
+	}
+}
+
+// listRequestsResult is the result of the ListRequests query.
+type listRequestsResult struct {
+	ListRequests *listRequestsResultListRequests `json:"listRequests"`
+}
+
+type listRequestsResultListRequests struct {
+	Items     []*requestFields `json:"items"`
+	NextToken string           `json:"nextToken"`
+	Typename  string           `json:"__typename"`
+}
+
+// getRequestsQuery is the GetRequests query.
+const getRequestsQuery = `query GetRequests ($id: ID!) {
+  getRequests(id: $id) {
+    ... RequestFields
+  }
+}
+fragment RequestFields on requests {
+  id
+  email
+  accountId
+  accountName
+  role
+  roleId
+  startTime
+  duration
+  justification
+  status
+  comment
+  username
+  approver
+  approverId
+  approvers
+  approver_ids
+  revoker
+  revokerId
+  endTime
+  ticketNo
+  revokeComment
+  session_duration
+  createdAt
+  updatedAt
+  owner
+  __typename
+}`
+
+// newGetRequestsRequest creates a request for the GetRequests query.
+func newGetRequestsRequest(id string) *gql.Request {
+	return &gql.Request{
+		Query: getRequestsQuery,
+		Variables: gql.Variables{}.
+			Set("id", id),
+	}
+}
+
+// getRequestsResult is the result of the GetRequests query.
+type getRequestsResult struct {
+	GetRequests *requestFields `json:"getRequests"`
+}
+
+// createRequestsQuery is the CreateRequests mutation.
+const createRequestsQuery = `mutation CreateRequests ($input: CreateRequestsInput!, $condition: ModelRequestsConditionInput) {
+  createRequests(input: $input, condition: $condition) {
+    ... RequestFields
+  }
+}
+fragment RequestFields on requests {
+  id
+  email
+  accountId
+  accountName
+  role
+  roleId
+  startTime
+  duration
+  justification
+  status
+  comment
+  username
+  approver
+  approverId
+  approvers
+  approver_ids
+  revoker
+  revokerId
+  endTime
+  ticketNo
+  revokeComment
+  session_duration
+  createdAt
+  updatedAt
+  owner
+  __typename
+}`
+
+// newCreateRequestsRequest creates a request for the CreateRequests mutation.
+func newCreateRequestsRequest(input *createRequestsInput, condition *modelRequestsConditionInput) *gql.Request {
+	return &gql.Request{
+		Query: createRequestsQuery,
+		Variables: gql.Variables{}.
+			Set("input", input).
+			SetOptional("condition", condition),
+	}
+}
+
+// createRequestsResult is the result of the CreateRequests mutation.
+type createRequestsResult struct {
+	CreateRequests *requestFields `json:"createRequests"`
+}
+
+// updateRequestsQuery is the UpdateRequests mutation.
+const updateRequestsQuery = `mutation UpdateRequests ($input: UpdateRequestsInput!, $condition: ModelRequestsConditionInput) {
+  updateRequests(input: $input, condition: $condition) {
+    ... RequestFields
+  }
+}
+fragment RequestFields on requests {
+  id
+  email
+  accountId
+  accountName
+  role
+  roleId
+  startTime
+  duration
+  justification
+  status
+  comment
+  username
+  approver
+  approverId
+  approvers
+  approver_ids
+  revoker
+  revokerId
+  endTime
+  ticketNo
+  revokeComment
+  session_duration
+  createdAt
+  updatedAt
+  owner
+  __typename
+}`
+
+// newUpdateRequestsRequest creates a request for the UpdateRequests mutation.
+func newUpdateRequestsRequest(input *updateRequestsInput, condition *modelRequestsConditionInput) *gql.Request {
+	return &gql.Request{
+		Query: updateRequestsQuery,
+		Variables: gql.Variables{}.
+			Set("input", input).
+			SetOptional("condition", condition),
+	}
+}
+
+// updateRequestsResult is the result of the UpdateRequests mutation.
+type updateRequestsResult struct {
+	UpdateRequests *requestFields `json:"updateRequests"`
+}
+
+// getUserPolicyQuery is the GetUserPolicy query.
+const getUserPolicyQuery = `query GetUserPolicy ($userId: String, $groupIds: [String]) {
+  getUserPolicy(userId: $userId, groupIds: $groupIds) {
+    ... PolicyFields
+  }
+}
+fragment PolicyFields on Policy {
+  id
+  policy {
+    accounts {
+      name
+      id
+      __typename
+    }
+    permissions {
+      name
+      id
+      __typename
+    }
+    approvalRequired
+    duration
+    __typename
+  }
+  username
+  __typename
+}`
+
+// newGetUserPolicyRequest creates a request for the GetUserPolicy query.
+func newGetUserPolicyRequest(userID *string, groupIDs []string) *gql.Request {
+	return &gql.Request{
+		Query: getUserPolicyQuery,
+		Variables: gql.Variables{}.
+			SetOptional("userId", userID).
+			SetOptional("groupIds", groupIDs),
+	}
+}
+
+// getUserPolicyResult is the result of the GetUserPolicy query.
+type getUserPolicyResult struct {
+	GetUserPolicy *policyFields `json:"getUserPolicy"`
+}
+
+// onPublishPolicyQuery is the OnPublishPolicy subscription.
+const onPublishPolicyQuery = `subscription OnPublishPolicy {
+  onPublishPolicy {
+    ... PolicyFields
+  }
+}
+fragment PolicyFields on Policy {
+  id
+  policy {
+    accounts {
+      name
+      id
+      __typename
+    }
+    permissions {
+      name
+      id
+      __typename
+    }
+    approvalRequired
+    duration
+    __typename
+  }
+  username
+  __typename
+}`
+
+// newOnPublishPolicyRequest creates a request for the OnPublishPolicy subscription.
+func newOnPublishPolicyRequest() *gql.Request {
+	return &gql.Request{
+		Query: onPublishPolicyQuery,
+	}
+}
+
+// onPublishPolicyResult is the result of the OnPublishPolicy subscription.
+type onPublishPolicyResult struct {
+	OnPublishPolicy *policyFields `json:"onPublishPolicy"`
+}
+
+// onCreateRequestsQuery is the OnCreateRequests subscription.
+const onCreateRequestsQuery = `subscription OnCreateRequests ($filter: ModelSubscriptionRequestsFilterInput) {
+  onCreateRequests(filter: $filter) {
+    ... RequestFields
+  }
+}
+fragment RequestFields on requests {
+  id
+  email
+  accountId
+  accountName
+  role
+  roleId
+  startTime
+  duration
+  justification
+  status
+  comment
+  username
+  approver
+  approverId
+  approvers
+  approver_ids
+  revoker
+  revokerId
+  endTime
+  ticketNo
+  revokeComment
+  session_duration
+  createdAt
+  updatedAt
+  owner
+  __typename
+}`
+
+// newOnCreateRequestsRequest creates a request for the OnCreateRequests subscription.
+func newOnCreateRequestsRequest(filter *modelSubscriptionRequestsFilterInput) *gql.Request {
+	return &gql.Request{
+		Query: onCreateRequestsQuery,
+		Variables: gql.Variables{}.
+			SetOptional("filter", filter),
+	}
+}
+
+// onCreateRequestsResult is the result of the OnCreateRequests subscription.
+type onCreateRequestsResult struct {
+	OnCreateRequests *requestFields `json:"onCreateRequests"`
+}
+
+// onUpdateRequestsQuery is the OnUpdateRequests subscription.
+const onUpdateRequestsQuery = `subscription OnUpdateRequests ($filter: ModelSubscriptionRequestsFilterInput) {
+  onUpdateRequests(filter: $filter) {
+    ... RequestFields
+  }
+}
+fragment RequestFields on requests {
+  id
+  email
+  accountId
+  accountName
+  role
+  roleId
+  startTime
+  duration
+  justification
+  status
+  comment
+  username
+  approver
+  approverId
+  approvers
+  approver_ids
+  revoker
+  revokerId
+  endTime
+  ticketNo
+  revokeComment
+  session_duration
+  createdAt
+  updatedAt
+  owner
+  __typename
+}`
+
+// newOnUpdateRequestsRequest creates a request for the OnUpdateRequests subscription.
+func newOnUpdateRequestsRequest(filter *modelSubscriptionRequestsFilterInput) *gql.Request {
+	return &gql.Request{
+		Query: onUpdateRequestsQuery,
+		Variables: gql.Variables{}.
+			SetOptional("filter", filter),
+	}
+}
+
+// onUpdateRequestsResult is the result of the OnUpdateRequests subscription.
+type onUpdateRequestsResult struct {
+	OnUpdateRequests *requestFields `json:"onUpdateRequests"`
+}
+
+// operationQueries contains the query of every generated operation, keyed by operation name.
+var operationQueries = map[string]string{
+	"ListRequests":     listRequestsQuery,
+	"GetRequests":      getRequestsQuery,
+	"CreateRequests":   createRequestsQuery,
+	"UpdateRequests":   updateRequestsQuery,
+	"GetUserPolicy":    getUserPolicyQuery,
+	"OnPublishPolicy":  onPublishPolicyQuery,
+	"OnCreateRequests": onCreateRequestsQuery,
+	"OnUpdateRequests": onUpdateRequestsQuery,
+}
+
+// createRequestsInput is the CreateRequestsInput input type.
+type createRequestsInput struct {
+	ID              *string  `json:"id,omitempty"`
+	Email           *string  `json:"email,omitempty"`
+	AccountID       string   `json:"accountId"`
+	AccountName     string   `json:"accountName"`
+	Role            string   `json:"role"`
+	RoleID          string   `json:"roleId"`
+	StartTime       string   `json:"startTime"`
+	Duration        string   `json:"duration"`
+	Justification   *string  `json:"justification,omitempty"`
+	Status          *string  `json:"status,omitempty"`
+	Comment         *string  `json:"comment,omitempty"`
+	Username        *string  `json:"username,omitempty"`
+	Approver        *string  `json:"approver,omitempty"`
+	ApproverID      *string  `json:"approverId,omitempty"`
+	Approvers       []string `json:"approvers,omitempty"`
+	ApproverIDs     []string `json:"approver_ids,omitempty"`
+	Revoker         *string  `json:"revoker,omitempty"`
+	RevokerID       *string  `json:"revokerId,omitempty"`
+	EndTime         *string  `json:"endTime,omitempty"`
+	TicketNo        *string  `json:"ticketNo,omitempty"`
+	RevokeComment   *string  `json:"revokeComment,omitempty"`
+	SessionDuration *string  `json:"session_duration,omitempty"`
+}
+
+// modelIDInput is the ModelIDInput input type.
+type modelIDInput struct {
+	Ne              *string              `json:"ne,omitempty"`
+	Eq              *string              `json:"eq,omitempty"`
+	Le              *string              `json:"le,omitempty"`
+	Lt              *string              `json:"lt,omitempty"`
+	Ge              *string              `json:"ge,omitempty"`
+	Gt              *string              `json:"gt,omitempty"`
+	Contains        *string              `json:"contains,omitempty"`
+	NotContains     *string              `json:"notContains,omitempty"`
+	Between         []string             `json:"between,omitempty"`
+	BeginsWith      *string              `json:"beginsWith,omitempty"`
+	AttributeExists *bool                `json:"attributeExists,omitempty"`
+	AttributeType   *modelAttributeTypes `json:"attributeType,omitempty"`
+	Size            *modelSizeInput      `json:"size,omitempty"`
+}
+
+// modelRequestsConditionInput is the ModelRequestsConditionInput input type.
+type modelRequestsConditionInput struct {
+	Email           *modelStringInput              `json:"email,omitempty"`
+	AccountID       *modelStringInput              `json:"accountId,omitempty"`
+	AccountName     *modelStringInput              `json:"accountName,omitempty"`
+	Role            *modelStringInput              `json:"role,omitempty"`
+	RoleID          *modelStringInput              `json:"roleId,omitempty"`
+	StartTime       *modelStringInput              `json:"startTime,omitempty"`
+	Duration        *modelStringInput              `json:"duration,omitempty"`
+	Justification   *modelStringInput              `json:"justification,omitempty"`
+	Status          *modelStringInput              `json:"status,omitempty"`
+	Comment         *modelStringInput              `json:"comment,omitempty"`
+	Username        *modelStringInput              `json:"username,omitempty"`
+	Approver        *modelStringInput              `json:"approver,omitempty"`
+	ApproverID      *modelStringInput              `json:"approverId,omitempty"`
+	Approvers       *modelStringInput              `json:"approvers,omitempty"`
+	ApproverIDs     *modelStringInput              `json:"approver_ids,omitempty"`
+	Revoker         *modelStringInput              `json:"revoker,omitempty"`
+	RevokerID       *modelStringInput              `json:"revokerId,omitempty"`
+	EndTime         *modelStringInput              `json:"endTime,omitempty"`
+	TicketNo        *modelStringInput              `json:"ticketNo,omitempty"`
+	RevokeComment   *modelStringInput              `json:"revokeComment,omitempty"`
+	SessionDuration *modelStringInput              `json:"session_duration,omitempty"`
+	And             []*modelRequestsConditionInput `json:"and,omitempty"`
+	Or              []*modelRequestsConditionInput `json:"or,omitempty"`
+	Not             *modelRequestsConditionInput   `json:"not,omitempty"`
+	CreatedAt       *modelStringInput              `json:"createdAt,omitempty"`
+	UpdatedAt       *modelStringInput              `json:"updatedAt,omitempty"`
+	Owner           *modelStringInput              `json:"owner,omitempty"`
+}
+
+// modelRequestsFilterInput is the ModelRequestsFilterInput input type.
+type modelRequestsFilterInput struct {
+	ID              *modelIDInput               `json:"id,omitempty"`
+	Email           *modelStringInput           `json:"email,omitempty"`
+	AccountID       *modelStringInput           `json:"accountId,omitempty"`
+	AccountName     *modelStringInput           `json:"accountName,omitempty"`
+	Role            *modelStringInput           `json:"role,omitempty"`
+	RoleID          *modelStringInput           `json:"roleId,omitempty"`
+	StartTime       *modelStringInput           `json:"startTime,omitempty"`
+	Duration        *modelStringInput           `json:"duration,omitempty"`
+	Justification   *modelStringInput           `json:"justification,omitempty"`
+	Status          *modelStringInput           `json:"status,omitempty"`
+	Comment         *modelStringInput           `json:"comment,omitempty"`
+	Username        *modelStringInput           `json:"username,omitempty"`
+	Approver        *modelStringInput           `json:"approver,omitempty"`
+	ApproverID      *modelStringInput           `json:"approverId,omitempty"`
+	Approvers       *modelStringInput           `json:"approvers,omitempty"`
+	ApproverIDs     *modelStringInput           `json:"approver_ids,omitempty"`
+	Revoker         *modelStringInput           `json:"revoker,omitempty"`
+	RevokerID       *modelStringInput           `json:"revokerId,omitempty"`
+	EndTime         *modelStringInput           `json:"endTime,omitempty"`
+	TicketNo        *modelStringInput           `json:"ticketNo,omitempty"`
+	RevokeComment   *modelStringInput           `json:"revokeComment,omitempty"`
+	SessionDuration *modelStringInput           `json:"session_duration,omitempty"`
+	CreatedAt       *modelStringInput           `json:"createdAt,omitempty"`
+	UpdatedAt       *modelStringInput           `json:"updatedAt,omitempty"`
+	And             []*modelRequestsFilterInput `json:"and,omitempty"`
+	Or              []*modelRequestsFilterInput `json:"or,omitempty"`
+	Not             *modelRequestsFilterInput   `json:"not,omitempty"`
+	Owner           *modelStringInput           `json:"owner,omitempty"`
+}
+
+// modelSizeInput is the ModelSizeInput input type.
+type modelSizeInput struct {
+	Ne      *int  `json:"ne,omitempty"`
+	Eq      *int  `json:"eq,omitempty"`
+	Le      *int  `json:"le,omitempty"`
+	Lt      *int  `json:"lt,omitempty"`
+	Ge      *int  `json:"ge,omitempty"`
+	Gt      *int  `json:"gt,omitempty"`
+	Between []int `json:"between,omitempty"`
+}
+
+// modelStringInput is the ModelStringInput input type.
+type modelStringInput struct {
+	Ne              *string              `json:"ne,omitempty"`
+	Eq              *string              `json:"eq,omitempty"`
+	Le              *string              `json:"le,omitempty"`
+	Lt              *string              `json:"lt,omitempty"`
+	Ge              *string              `json:"ge,omitempty"`
+	Gt              *string              `json:"gt,omitempty"`
+	Contains        *string              `json:"contains,omitempty"`
+	NotContains     *string              `json:"notContains,omitempty"`
+	Between         []string             `json:"between,omitempty"`
+	BeginsWith      *string              `json:"beginsWith,omitempty"`
+	AttributeExists *bool                `json:"attributeExists,omitempty"`
+	AttributeType   *modelAttributeTypes `json:"attributeType,omitempty"`
+	Size            *modelSizeInput      `json:"size,omitempty"`
+}
+
+// modelSubscriptionIDInput is the ModelSubscriptionIDInput input type.
+type modelSubscriptionIDInput struct {
+	Ne          *string  `json:"ne,omitempty"`
+	Eq          *string  `json:"eq,omitempty"`
+	Le          *string  `json:"le,omitempty"`
+	Lt          *string  `json:"lt,omitempty"`
+	Ge          *string  `json:"ge,omitempty"`
+	Gt          *string  `json:"gt,omitempty"`
+	Contains    *string  `json:"contains,omitempty"`
+	NotContains *string  `json:"notContains,omitempty"`
+	Between     []string `json:"between,omitempty"`
+	BeginsWith  *string  `json:"beginsWith,omitempty"`
+	In          []string `json:"in,omitempty"`
+	NotIn       []string `json:"notIn,omitempty"`
+}
+
+// modelSubscriptionRequestsFilterInput is the ModelSubscriptionRequestsFilterInput input type.
+type modelSubscriptionRequestsFilterInput struct {
+	ID              *modelSubscriptionIDInput               `json:"id,omitempty"`
+	Email           *modelSubscriptionStringInput           `json:"email,omitempty"`
+	AccountID       *modelSubscriptionStringInput           `json:"accountId,omitempty"`
+	AccountName     *modelSubscriptionStringInput           `json:"accountName,omitempty"`
+	Role            *modelSubscriptionStringInput           `json:"role,omitempty"`
+	RoleID          *modelSubscriptionStringInput           `json:"roleId,omitempty"`
+	StartTime       *modelSubscriptionStringInput           `json:"startTime,omitempty"`
+	Duration        *modelSubscriptionStringInput           `json:"duration,omitempty"`
+	Justification   *modelSubscriptionStringInput           `json:"justification,omitempty"`
+	Status          *modelSubscriptionStringInput           `json:"status,omitempty"`
+	Comment         *modelSubscriptionStringInput           `json:"comment,omitempty"`
+	Username        *modelSubscriptionStringInput           `json:"username,omitempty"`
+	Approver        *modelSubscriptionStringInput           `json:"approver,omitempty"`
+	ApproverID      *modelSubscriptionStringInput           `json:"approverId,omitempty"`
+	Approvers       *modelSubscriptionStringInput           `json:"approvers,omitempty"`
+	ApproverIDs     *modelSubscriptionStringInput           `json:"approver_ids,omitempty"`
+	Revoker         *modelSubscriptionStringInput           `json:"revoker,omitempty"`
+	RevokerID       *modelSubscriptionStringInput           `json:"revokerId,omitempty"`
+	EndTime         *modelSubscriptionStringInput           `json:"endTime,omitempty"`
+	TicketNo        *modelSubscriptionStringInput           `json:"ticketNo,omitempty"`
+	RevokeComment   *modelSubscriptionStringInput           `json:"revokeComment,omitempty"`
+	SessionDuration *modelSubscriptionStringInput           `json:"session_duration,omitempty"`
+	CreatedAt       *modelSubscriptionStringInput           `json:"createdAt,omitempty"`
+	UpdatedAt       *modelSubscriptionStringInput           `json:"updatedAt,omitempty"`
+	And             []*modelSubscriptionRequestsFilterInput `json:"and,omitempty"`
+	Or              []*modelSubscriptionRequestsFilterInput `json:"or,omitempty"`
+}
+
+// modelSubscriptionStringInput is the ModelSubscriptionStringInput input type.
+type modelSubscriptionStringInput struct {
+	Ne          *string  `json:"ne,omitempty"`
+	Eq          *string  `json:"eq,omitempty"`
+	Le          *string  `json:"le,omitempty"`
+	Lt          *string  `json:"lt,omitempty"`
+	Ge          *string  `json:"ge,omitempty"`
+	Gt          *string  `json:"gt,omitempty"`
+	Contains    *string  `json:"contains,omitempty"`
+	NotContains *string  `json:"notContains,omitempty"`
+	Between     []string `json:"between,omitempty"`
+	BeginsWith  *string  `json:"beginsWith,omitempty"`
+	In          []string `json:"in,omitempty"`
+	NotIn       []string `json:"notIn,omitempty"`
+}
+
+// updateRequestsInput is the UpdateRequestsInput input type.
+type updateRequestsInput struct {
+	ID              string   `json:"id"`
+	Email           *string  `json:"email,omitempty"`
+	AccountID       *string  `json:"accountId,omitempty"`
+	AccountName     *string  `json:"accountName,omitempty"`
+	Role            *string  `json:"role,omitempty"`
+	RoleID          *string  `json:"roleId,omitempty"`
+	StartTime       *string  `json:"startTime,omitempty"`
+	Duration        *string  `json:"duration,omitempty"`
+	Justification   *string  `json:"justification,omitempty"`
+	Status          *string  `json:"status,omitempty"`
+	Comment         *string  `json:"comment,omitempty"`
+	Username        *string  `json:"username,omitempty"`
+	Approver        *string  `json:"approver,omitempty"`
+	ApproverID      *string  `json:"approverId,omitempty"`
+	Approvers       []string `json:"approvers,omitempty"`
+	ApproverIDs     []string `json:"approver_ids,omitempty"`
+	Revoker         *string  `json:"revoker,omitempty"`
+	RevokerID       *string  `json:"revokerId,omitempty"`
+	EndTime         *string  `json:"endTime,omitempty"`
+	TicketNo        *string  `json:"ticketNo,omitempty"`
+	RevokeComment   *string  `json:"revokeComment,omitempty"`
+	SessionDuration *string  `json:"session_duration,omitempty"`
+}
+
+// modelAttributeTypes is the ModelAttributeTypes enum.
+type modelAttributeTypes string
+
+const (
+	modelAttributeTypesBinary    modelAttributeTypes = "binary"
+	modelAttributeTypesBinarySet modelAttributeTypes = "binarySet"
+	modelAttributeTypesBool      modelAttributeTypes = "bool"
+	modelAttributeTypesList      modelAttributeTypes = "list"
+	modelAttributeTypesMap       modelAttributeTypes = "map"
+	modelAttributeTypesNumber    modelAttributeTypes = "number"
+	modelAttributeTypesNumberSet modelAttributeTypes = "numberSet"
+	modelAttributeTypesString    modelAttributeTypes = "string"
+	modelAttributeTypesStringSet modelAttributeTypes = "stringSet"
+	modelAttributeTypesNull      modelAttributeTypes = "_null"
+)
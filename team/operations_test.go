@@ -0,0 +1,42 @@
+package team
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+func TestOperationsMatchSchema(t *testing.T) {
+	t.Parallel()
+
+	paths, err := filepath.Glob(filepath.Join("schema", "*.graphql"))
+	require.NoError(t, err)
+	require.NotEmpty(t, paths)
+
+	sources := make([]*ast.Source, 0, len(paths))
+
+	for _, path := range paths {
+		raw, err := os.ReadFile(path)
+		require.NoError(t, err)
+
+		sources = append(sources, &ast.Source{Name: path, Input: string(raw)})
+	}
+
+	schema, err := gqlparser.LoadSchema(sources...)
+	require.NoError(t, err)
+
+	for name, query := range operationQueries {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			doc, errs := gqlparser.LoadQueryWithRules(schema, query, nil)
+			require.Empty(t, errs)
+			require.Len(t, doc.Operations, 1)
+			require.Equal(t, name, doc.Operations[0].Name)
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package team
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+var TicketRegex = regexp.MustCompile("^[a-zA-Z0-9_-]+$")
+
+type AccessRequest struct {
+	AccountID     string
+	AccountName   string
+	Role          string
+	RoleID        string
+	Duration      int
+	StartTime     time.Time
+	Justification string
+	Ticket        string
+}
+
+// Request submits an access request, returning its ID.
+func (c *Client) Request(ctx context.Context, req *AccessRequest) (string, error) {
+	slog.Info("Requesting access")
+
+	ctx, token, err := c.prepare(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	startTime := req.StartTime
+
+	if startTime.IsZero() {
+		startTime = time.Now()
+	}
+
+	startTime = startTime.Truncate(time.Minute)
+
+	rawResult, err := do[createRequestsResult](ctx, c.gqlClient(token), newCreateRequestsRequest(
+		&createRequestsInput{
+			AccountID:     req.AccountID,
+			AccountName:   req.AccountName,
+			Role:          req.Role,
+			RoleID:        req.RoleID,
+			Duration:      strconv.Itoa(req.Duration),
+			StartTime:     startTime.UTC().Format(time.RFC3339),
+			Justification: ptr(req.Justification),
+			TicketNo:      ptr(req.Ticket),
+		},
+		nil,
+	))
+	if err != nil {
+		return "", fmt.Errorf("failed to execute: %w", err)
+	}
+
+	if rawResult.CreateRequests == nil {
+		return "", fmt.Errorf("%w: no request returned", ErrUnexpected)
+	}
+
+	return rawResult.CreateRequests.ID, nil
+}
@@ -0,0 +1,65 @@
+package team_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/csnewman/team-cli/team"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTicketRegex(t *testing.T) {
+	t.Parallel()
+
+	for _, valid := range []string{
+		"123", "ticket123", "ticket-123", "ticket_123",
+	} {
+		t.Run("valid="+valid, func(t *testing.T) {
+			t.Parallel()
+
+			require.True(t, team.TicketRegex.MatchString(valid))
+		})
+	}
+
+	for _, invalid := range []string{
+		" 123 ", "  ", "|||||",
+	} {
+		t.Run("invalid="+invalid, func(t *testing.T) {
+			t.Parallel()
+
+			require.False(t, team.TicketRegex.MatchString(invalid))
+		})
+	}
+}
+
+func TestRequest(t *testing.T) {
+	t.Parallel()
+
+	srv, user := newTestServer(t)
+
+	start := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	id, err := newTestClient(srv, user).Request(context.Background(), &team.AccessRequest{
+		AccountID:     "111111111111",
+		AccountName:   "prod",
+		Role:          "ReadOnlyAccess",
+		RoleID:        "perm-ro",
+		Duration:      2,
+		StartTime:     start,
+		Justification: "testing",
+		Ticket:        "INC-1",
+	})
+	require.NoError(t, err)
+
+	reqs := srv.Requests()
+	require.Len(t, reqs, 1)
+	require.Equal(t, id, reqs[0].ID)
+	require.Equal(t, user.Email, reqs[0].Email)
+	require.Equal(t, "111111111111", reqs[0].AccountID)
+	require.Equal(t, "perm-ro", reqs[0].RoleID)
+	require.Equal(t, "2030-01-02T03:04:00Z", reqs[0].StartTime)
+	require.Equal(t, "2", reqs[0].Duration)
+	require.Equal(t, "testing", reqs[0].Justification)
+	require.Equal(t, "INC-1", reqs[0].TicketNo)
+}
@@ -0,0 +1,36 @@
+package team
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+type AccessResponse struct {
+	ID      string
+	Status  string
+	Comment string
+}
+
+// Respond approves or rejects a request.
+func (c *Client) Respond(ctx context.Context, accResp *AccessResponse) error {
+	slog.Info("Responding to request")
+
+	ctx, token, err := c.prepare(ctx)
+	if err != nil {
+		return err
+	}
+
+	if _, err := do[updateRequestsResult](ctx, c.gqlClient(token), newUpdateRequestsRequest(
+		&updateRequestsInput{
+			ID:      accResp.ID,
+			Status:  ptr(accResp.Status),
+			Comment: ptr(accResp.Comment),
+		},
+		nil,
+	)); err != nil {
+		return fmt.Errorf("failed to execute: %w", err)
+	}
+
+	return nil
+}
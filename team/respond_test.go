@@ -0,0 +1,54 @@
+package team_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/csnewman/team-cli/internal/teamtest"
+	"github.com/csnewman/team-cli/team"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRespond(t *testing.T) {
+	t.Parallel()
+
+	srv, user := newTestServer(t)
+
+	req := srv.AddRequest(&teamtest.Request{
+		Email:     "bob@example.com",
+		StartTime: "2030-01-02T03:04:00Z",
+		Status:    "pending",
+		Approvers: []string{user.Email},
+	})
+
+	ctx := context.Background()
+	client := newTestClient(srv, user)
+
+	err := client.Respond(ctx, &team.AccessResponse{
+		ID:      req.ID,
+		Status:  "approved",
+		Comment: "ok",
+	})
+	require.NoError(t, err)
+
+	reqs := srv.Requests()
+	require.Equal(t, "approved", reqs[0].Status)
+	require.Equal(t, "ok", reqs[0].Comment)
+
+	// Empty comments are still sent
+	err = client.Respond(ctx, &team.AccessResponse{
+		ID:     req.ID,
+		Status: "rejected",
+	})
+	require.NoError(t, err)
+
+	reqs = srv.Requests()
+	require.Equal(t, "rejected", reqs[0].Status)
+	require.Empty(t, reqs[0].Comment)
+
+	err = client.Respond(ctx, &team.AccessResponse{
+		ID:     "unknown",
+		Status: "approved",
+	})
+	require.ErrorIs(t, err, team.ErrUnexpected)
+}
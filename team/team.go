@@ -1,5 +1,13 @@
+// Package team is a client for AWS TEAM (Temporary Elevated Access Management).
+//
+// Configuration is discovered from a deployment with ExtractConfig and users are authenticated with FetchToken or
+// FetchTokenViaDeviceCode. Operations are then performed via a Client, or any other implementation of API.
+//
+// The package follows the semantic versioning of the team-cli module.
 package team
 
+//go:generate go run ../internal/gql/gqlgen -schema schema/appsync.graphql,schema/schema.graphql -operations operations.graphql -package team -out operations_gen.go
+
 import (
 	"context"
 	"errors"
@@ -9,12 +17,17 @@ import (
 	"net/http"
 	"net/url"
 	"regexp"
+	"slices"
+	"strings"
 	"time"
+
+	"github.com/csnewman/team-cli/internal/gql"
 )
 
 var (
-	jsRegex    = regexp.MustCompile(`src="([\w./:_-]+\.js)"`)
-	scopeRegex = regexp.MustCompile(`"([\w:/._-]+)"`)
+	jsRegex     = regexp.MustCompile(`src="([\w./:_-]+\.js)"`)
+	scopeRegex  = regexp.MustCompile(`"([\w:/._-]+)"`)
+	portalRegex = regexp.MustCompile(`https://[\w-]+\.awsapps\.com/start\b`)
 )
 
 var configExtractors = map[string]*regexp.Regexp{
@@ -34,6 +47,39 @@ type RemoteConfig struct {
 	OAuthResponseType string   `json:"oauth_response_type"`
 	OAuthScopes       []string `json:"oauth_scopes"`
 	RedirectSignIn    string   `json:"redirectSignIn"`
+
+	// AccessPortalURL is the start URL of the IAM Identity Center access portal (e.g.
+	// https://d-1234567890.awsapps.com/start). It is discovered if the TEAM frontend links to it, otherwise it must be
+	// configured.
+	AccessPortalURL string `json:"access_portal_url,omitempty"`
+
+	// AccessPortalURLConfigured is set when AccessPortalURL was configured rather than discovered, so should be kept
+	// when the config is extracted again.
+	AccessPortalURLConfigured bool `json:"access_portal_url_configured,omitempty"`
+
+	// AccessPortalRegion is the region of IAM Identity Center, defaulting to the region of the GraphQL endpoint.
+	AccessPortalRegion string `json:"access_portal_region,omitempty"`
+}
+
+// PortalRegion returns the region of IAM Identity Center, if configured or discoverable from the GraphQL endpoint.
+func (c *RemoteConfig) PortalRegion() string {
+	if c.AccessPortalRegion != "" {
+		return c.AccessPortalRegion
+	}
+
+	// AppSync endpoints are of the form https://{id}.appsync-api.{region}.amazonaws.com/graphql
+	u, err := url.Parse(c.GraphQLEndpoint)
+	if err != nil {
+		return ""
+	}
+
+	parts := strings.Split(u.Hostname(), ".")
+
+	if len(parts) >= 4 && parts[1] == "appsync-api" {
+		return parts[2]
+	}
+
+	return ""
 }
 
 var ErrUnexpected = errors.New("unexpected error")
@@ -58,7 +104,7 @@ func ExtractConfig(ctx context.Context, addr string) (*RemoteConfig, error) {
 		return nil, fmt.Errorf("could not create request: %w", err)
 	}
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := gql.HTTPClient(ctx).Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("could not send request: %w", err)
 	}
@@ -106,7 +152,7 @@ func ExtractConfig(ctx context.Context, addr string) (*RemoteConfig, error) {
 		return nil, fmt.Errorf("could not create js request: %w", err)
 	}
 
-	resp, err = http.DefaultClient.Do(req)
+	resp, err = gql.HTTPClient(ctx).Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("could not send js request: %w", err)
 	}
@@ -152,6 +198,17 @@ func ExtractConfig(ctx context.Context, addr string) (*RemoteConfig, error) {
 		scopes = append(scopes, match[1])
 	}
 
+	// The access portal is only linked to by customised deployments, so is optional
+	var accessPortalURL string
+
+	portals := portalRegex.FindAllString(string(rawBody), -1)
+	slices.Sort(portals)
+	portals = slices.Compact(portals)
+
+	if len(portals) == 1 {
+		accessPortalURL = portals[0]
+	}
+
 	return &RemoteConfig{
 		Server:            server.String(),
 		GraphQLEndpoint:   raw["aws_appsync_graphqlEndpoint"],
@@ -160,5 +217,6 @@ func ExtractConfig(ctx context.Context, addr string) (*RemoteConfig, error) {
 		OAuthResponseType: raw["oauth_responseType"],
 		OAuthScopes:       scopes,
 		RedirectSignIn:    raw["redirectSignIn"],
+		AccessPortalURL:   accessPortalURL,
 	}, nil
 }
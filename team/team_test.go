@@ -0,0 +1,104 @@
+package team_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/csnewman/team-cli/internal/teamtest"
+	"github.com/csnewman/team-cli/team"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer(t *testing.T) (*teamtest.Server, *teamtest.User) {
+	t.Helper()
+
+	srv := teamtest.New(t)
+
+	user := srv.AddUser(&teamtest.User{
+		ID:       "user-1",
+		Username: "alice",
+		Email:    "alice@example.com",
+		GroupIDs: []string{"group-1", "group-2"},
+		Policy: []*teamtest.Entitlement{
+			{
+				Accounts:    []*teamtest.Account{{Name: "prod", ID: "111111111111"}},
+				Permissions: []*teamtest.Permission{{Name: "ReadOnlyAccess", ID: "perm-ro"}},
+				Duration:    4,
+			},
+			{
+				Accounts: []*teamtest.Account{{Name: "prod", ID: "111111111111"}},
+				Permissions: []*teamtest.Permission{
+					{Name: "ReadOnlyAccess", ID: "perm-ro"},
+					{Name: "AdministratorAccess", ID: "perm-admin"},
+				},
+				ApprovalRequired: true,
+				Duration:         8,
+			},
+		},
+	})
+
+	return srv, user
+}
+
+func newTestClient(srv *teamtest.Server, user *teamtest.User) *team.Client {
+	client := team.NewClient(srv.RemoteConfig(), team.StaticTokenSource(srv.Token(user)))
+	client.HTTPClient = srv.Client()
+
+	return client
+}
+
+func TestExtractConfig(t *testing.T) {
+	t.Parallel()
+
+	srv, _ := newTestServer(t)
+
+	cfg, err := team.ExtractConfig(srv.Context(context.Background()), srv.URL)
+	require.NoError(t, err)
+	require.Equal(t, srv.RemoteConfig(), cfg)
+	require.Empty(t, cfg.AccessPortalURL)
+
+	// The access portal is discovered when linked to by the frontend
+	srv.AccessPortalURL = "https://d-1234567890.awsapps.com/start"
+
+	cfg, err = team.ExtractConfig(srv.Context(context.Background()), srv.URL)
+	require.NoError(t, err)
+	require.Equal(t, srv.RemoteConfig(), cfg)
+	require.Equal(t, "https://d-1234567890.awsapps.com/start", cfg.AccessPortalURL)
+}
+
+func TestPortalRegion(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		cfg      *team.RemoteConfig
+		expected string
+	}{
+		{
+			name:     "appsync",
+			cfg:      &team.RemoteConfig{GraphQLEndpoint: "https://abc.appsync-api.eu-west-2.amazonaws.com/graphql"},
+			expected: "eu-west-2",
+		},
+		{
+			name: "configured",
+			cfg: &team.RemoteConfig{
+				GraphQLEndpoint:    "https://abc.appsync-api.eu-west-2.amazonaws.com/graphql",
+				AccessPortalRegion: "us-east-1",
+			},
+			expected: "us-east-1",
+		},
+		{
+			name:     "custom domain",
+			cfg:      &team.RemoteConfig{GraphQLEndpoint: "https://api.example.com/graphql"},
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tt.expected, tt.cfg.PortalRegion())
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package team
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/csnewman/team-cli/internal/gql"
+)
+
+// WaitForRequest waits until done reports true for the state of the request, returning that state. done is called with
+// the current state, and again after every update, until the context is cancelled.
+func (c *Client) WaitForRequest(
+	ctx context.Context,
+	id string,
+	done func(req *PermissionRequest) bool,
+) (*PermissionRequest, error) {
+	slog.Info("Waiting for request", "id", id)
+
+	ctx, token, err := c.prepare(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var result *PermissionRequest
+
+	// The subscription must be active before the current state is fetched, so no update is missed.
+	if err := gql.Subscribe(
+		ctx,
+		c.Remote.GraphQLEndpoint,
+		token.AccessToken,
+		newOnUpdateRequestsRequest(&modelSubscriptionRequestsFilterInput{
+			ID: &modelSubscriptionIDInput{Eq: ptr(id)},
+		}),
+		func(ctx context.Context) (bool, error) {
+			req, err := c.GetRequest(ctx, id)
+			if err != nil {
+				return false, err
+			}
+
+			result = req
+
+			return !done(req), nil
+		},
+		func(ctx context.Context, payload *gql.Payload) (bool, error) {
+			var rawData onUpdateRequestsResult
+
+			if err := payload.UnmarshalData(&rawData); err != nil {
+				return false, fmt.Errorf("failed to unmarshal payload: %w", err)
+			}
+
+			if rawData.OnUpdateRequests == nil || rawData.OnUpdateRequests.ID != id {
+				slog.Debug("Ignoring update of another request")
+
+				return true, nil
+			}
+
+			req, err := rawData.OnUpdateRequests.toPermissionRequest()
+			if err != nil {
+				return false, err
+			}
+
+			slog.Debug("Request updated", "id", id, "status", req.Status)
+
+			result = req
+
+			return !done(req), nil
+		},
+	); err != nil {
+		return nil, fmt.Errorf("failed to wait: %w", err)
+	}
+
+	return result, nil
+}
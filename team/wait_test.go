@@ -0,0 +1,74 @@
+package team_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/csnewman/team-cli/internal/teamtest"
+	"github.com/csnewman/team-cli/team"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWaitForRequest(t *testing.T) {
+	t.Parallel()
+
+	srv, user := newTestServer(t)
+
+	req := srv.AddRequest(&teamtest.Request{
+		Email:     user.Email,
+		StartTime: "2030-01-02T03:04:00Z",
+		Status:    "pending",
+	})
+
+	other := srv.AddRequest(&teamtest.Request{
+		Email:     user.Email,
+		StartTime: "2030-01-02T03:04:00Z",
+		Status:    "pending",
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client := newTestClient(srv, user)
+
+	active := func(req *team.PermissionRequest) bool {
+		return req.Status == "in progress"
+	}
+
+	// The current state is returned when it is already done
+	srv.UpdateRequest(other.ID, func(req *teamtest.Request) {
+		req.Status = "in progress"
+	})
+
+	result, err := client.WaitForRequest(ctx, other.ID, active)
+	require.NoError(t, err)
+	require.Equal(t, other.ID, result.ID)
+
+	// Otherwise updates are waited for, whether they happen before or after the subscription is active
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+
+		srv.UpdateRequest(other.ID, func(req *teamtest.Request) {
+			req.Status = "ended"
+		})
+
+		srv.UpdateRequest(req.ID, func(req *teamtest.Request) {
+			req.Status = "approved"
+		})
+
+		srv.UpdateRequest(req.ID, func(req *teamtest.Request) {
+			req.Status = "in progress"
+			req.EndTime = "2030-01-02T04:04:00Z"
+		})
+	}()
+
+	result, err = client.WaitForRequest(ctx, req.ID, active)
+	require.NoError(t, err)
+	require.Equal(t, req.ID, result.ID)
+	require.Equal(t, "in progress", result.Status)
+	require.Equal(t, time.Date(2030, 1, 2, 4, 4, 0, 0, time.UTC), result.EndTime)
+
+	_, err = client.WaitForRequest(ctx, "unknown", active)
+	require.ErrorIs(t, err, team.ErrUnexpected)
+}
@@ -0,0 +1,130 @@
+package team
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/csnewman/team-cli/internal/gql"
+)
+
+// WatchRequests calls onReady once subscribed to changes of requests, then fn with every request created or updated,
+// until the context is cancelled or a subscription fails. Requests listed by onReady are therefore kept up to date by
+// fn. Calls are not concurrent.
+func (c *Client) WatchRequests(
+	ctx context.Context,
+	onReady func(ctx context.Context) error,
+	fn func(req *PermissionRequest),
+) error {
+	slog.Info("Watching requests")
+
+	ctx, token, err := c.prepare(ctx)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	ready := make(chan struct{})
+	events := make(chan *requestFields)
+	errs := make(chan error, 2)
+
+	subscribe := func(subscription *gql.Request, field func(payload *gql.Payload) (*requestFields, error)) {
+		errs <- gql.Subscribe(
+			ctx,
+			c.Remote.GraphQLEndpoint,
+			token.AccessToken,
+			subscription,
+			func(ctx context.Context) (bool, error) {
+				select {
+				case ready <- struct{}{}:
+				case <-ctx.Done():
+				}
+
+				return true, nil
+			},
+			func(_ context.Context, payload *gql.Payload) (bool, error) {
+				raw, err := field(payload)
+				if err != nil {
+					return false, err
+				}
+
+				if raw == nil {
+					return true, nil
+				}
+
+				select {
+				case events <- raw:
+					return true, nil
+				case <-ctx.Done():
+					return false, nil
+				}
+			},
+		)
+	}
+
+	go subscribe(newOnCreateRequestsRequest(nil), func(payload *gql.Payload) (*requestFields, error) {
+		var rawData onCreateRequestsResult
+
+		if err := payload.UnmarshalData(&rawData); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal payload: %w", err)
+		}
+
+		return rawData.OnCreateRequests, nil
+	})
+
+	go subscribe(newOnUpdateRequestsRequest(nil), func(payload *gql.Payload) (*requestFields, error) {
+		var rawData onUpdateRequestsResult
+
+		if err := payload.UnmarshalData(&rawData); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal payload: %w", err)
+		}
+
+		return rawData.OnUpdateRequests, nil
+	})
+
+	// Both subscriptions must be active before onReady, so no change is missed
+	for subscribed := 0; subscribed < 2; {
+		select {
+		case <-ready:
+			subscribed++
+		case err := <-errs:
+			return fmt.Errorf("failed to subscribe: %w", err)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	slog.Debug("Watching requests ready")
+
+	if err := onReady(ctx); err != nil {
+		return fmt.Errorf("onReady error: %w", err)
+	}
+
+	for {
+		select {
+		case raw := <-events:
+			req, err := raw.toPermissionRequest()
+			if err != nil {
+				return fmt.Errorf("failed to parse request %q: %w", raw.ID, err)
+			}
+
+			slog.Debug("Request changed", "id", req.ID, "status", req.Status)
+
+			fn(req)
+		case err := <-errs:
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			if err == nil {
+				err = fmt.Errorf("%w: subscription ended", ErrUnexpected)
+			}
+
+			return fmt.Errorf("failed to watch: %w", err)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package team_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/csnewman/team-cli/internal/teamtest"
+	"github.com/csnewman/team-cli/team"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchRequests(t *testing.T) {
+	t.Parallel()
+
+	srv, user := newTestServer(t)
+	srv.Approvers = []string{user.Email}
+
+	bob := srv.AddUser(&teamtest.User{
+		ID:       "user-2",
+		Username: "bob",
+		Email:    "bob@example.com",
+	})
+
+	existing := srv.AddRequest(&teamtest.Request{
+		Email:     bob.Email,
+		StartTime: "2030-01-02T03:04:00Z",
+		Status:    "pending",
+		Approvers: []string{user.Email},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client := newTestClient(srv, user)
+	ready := make(chan []*team.PermissionRequest, 1)
+	changes := make(chan *team.PermissionRequest, 10)
+	done := make(chan error, 1)
+
+	go func() {
+		done <- client.WatchRequests(
+			ctx,
+			func(ctx context.Context) error {
+				requests, err := client.ListRequests(ctx, team.ListRequestsFilterRequiresMyApproval)
+				ready <- requests
+
+				return err
+			},
+			func(req *team.PermissionRequest) {
+				changes <- req
+			},
+		)
+	}()
+
+	requests := <-ready
+	require.Len(t, requests, 1)
+	require.Equal(t, existing.ID, requests[0].ID)
+
+	id, err := newTestClient(srv, bob).Request(ctx, &team.AccessRequest{
+		AccountID: "111111111111",
+		RoleID:    "perm-ro",
+		Duration:  1,
+		StartTime: time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC),
+		Ticket:    "INC-1",
+	})
+	require.NoError(t, err)
+
+	created := <-changes
+	require.Equal(t, id, created.ID)
+	require.True(t, created.RequiresApprovalBy(user.Email))
+	require.False(t, created.RequiresApprovalBy(bob.Email))
+
+	srv.UpdateRequest(existing.ID, func(req *teamtest.Request) {
+		req.Status = "approved"
+	})
+
+	updated := <-changes
+	require.Equal(t, existing.ID, updated.ID)
+	require.False(t, updated.RequiresApprovalBy(user.Email))
+
+	cancel()
+
+	require.ErrorIs(t, <-done, context.Canceled)
+}